@@ -39,6 +39,8 @@ SPDX-License-Identifier: Apache-2.0
 package resmgmt
 
 import (
+	gocontext "context"
+
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/lifecycle"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/sgx"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
@@ -84,7 +86,13 @@ func New(ctxProvider context.ClientProvider, opts ...resmgmt.ClientOption) (*Cli
 
 // LifecycleInitEnclave initializes and registers an enclave for a particular FPC chaincode.
 func (rc *Client) LifecycleInitEnclave(channelId string, req LifecycleInitEnclaveRequest, options ...resmgmt.RequestOption) (fab.TransactionID, error) {
-	txID, err := rc.lifecycleClient.LifecycleInitEnclave(channelId, lifecycle.LifecycleInitEnclaveRequest{
+	return rc.LifecycleInitEnclaveWithContext(gocontext.Background(), channelId, req, options...)
+}
+
+// LifecycleInitEnclaveWithContext is like LifecycleInitEnclave, but aborts with a *errors.ContextError (see package
+// core/errors) as soon as ctx is done.
+func (rc *Client) LifecycleInitEnclaveWithContext(ctx gocontext.Context, channelId string, req LifecycleInitEnclaveRequest, options ...resmgmt.RequestOption) (fab.TransactionID, error) {
+	txID, err := rc.lifecycleClient.LifecycleInitEnclaveWithContext(ctx, channelId, lifecycle.LifecycleInitEnclaveRequest{
 		ChaincodeID:         req.ChaincodeID,
 		EnclavePeerEndpoint: req.EnclavePeerEndpoint,
 		AttestationParams:   req.AttestationParams,
@@ -94,3 +102,16 @@ func (rc *Client) LifecycleInitEnclave(channelId string, req LifecycleInitEnclav
 	}
 	return fab.TransactionID(txID), nil
 }
+
+// LifecycleQueryEnclaves maps the given peers, e.g., the endorsing peers of an FPC chaincode discovered using the
+// standard Fabric Client SDK, to the enclaves they host and that are registered at the enclave registry.
+// The result is indexed by peer endpoint.
+func (rc *Client) LifecycleQueryEnclaves(channelId string, chaincodeId string, peerEndpoints ...string) (map[string]*lifecycle.PeerEnclave, error) {
+	return rc.lifecycleClient.LifecycleQueryEnclaves(channelId, chaincodeId, peerEndpoints...)
+}
+
+// LifecycleQueryEnclavesWithContext is like LifecycleQueryEnclaves, but aborts with a *errors.ContextError (see
+// package core/errors) as soon as ctx is done.
+func (rc *Client) LifecycleQueryEnclavesWithContext(ctx gocontext.Context, channelId string, chaincodeId string, peerEndpoints ...string) (map[string]*lifecycle.PeerEnclave, error) {
+	return rc.lifecycleClient.LifecycleQueryEnclavesWithContext(ctx, channelId, chaincodeId, peerEndpoints...)
+}
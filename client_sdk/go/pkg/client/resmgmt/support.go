@@ -1,6 +1,8 @@
 package resmgmt
 
 import (
+	gocontext "context"
+
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/lifecycle"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
@@ -22,7 +24,7 @@ type channelClient struct {
 	goSDKChannelClient GoSDKChannelClient
 }
 
-func (c *channelClient) Query(chaincodeID string, fcn string, args [][]byte, targetEndpoints ...string) ([]byte, error) {
+func (c *channelClient) Query(ctx gocontext.Context, chaincodeID string, fcn string, args [][]byte, targetEndpoints ...string) ([]byte, error) {
 	initRequest := channel.Request{
 		ChaincodeID: chaincodeID,
 		Fcn:         fcn,
@@ -32,6 +34,7 @@ func (c *channelClient) Query(chaincodeID string, fcn string, args [][]byte, tar
 	var initOpts []channel.RequestOption
 	initOpts = append(initOpts, channel.WithRetry(retry.Opts{Attempts: 0}))
 	initOpts = append(initOpts, channel.WithTargetEndpoints(targetEndpoints...))
+	initOpts = append(initOpts, channel.WithParentContext(ctx))
 
 	// send query to create (init) enclave at the target peer
 	initResponse, err := c.goSDKChannelClient.Query(initRequest, initOpts...)
@@ -41,14 +44,14 @@ func (c *channelClient) Query(chaincodeID string, fcn string, args [][]byte, tar
 	return initResponse.Payload, nil
 }
 
-func (c *channelClient) Execute(chaincodeID string, fcn string, args [][]byte) (string, error) {
+func (c *channelClient) Execute(ctx gocontext.Context, chaincodeID string, fcn string, args [][]byte) (string, error) {
 	request := channel.Request{
 		ChaincodeID: chaincodeID,
 		Fcn:         fcn,
 		Args:        args,
 	}
 
-	var opts []channel.RequestOption
+	opts := []channel.RequestOption{channel.WithParentContext(ctx)}
 	// TODO translate `resmgmt.RequestOption` to `channel.Option` options so we can pass it to execute
 	//opts = append(opts, options...)
 
@@ -0,0 +1,193 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// DefaultCacheTTL is the default time the chaincode encryption key, the verified enclave keys and the peer endpoints
+// returned by the enclave registry are cached
+const DefaultCacheTTL = time.Minute
+
+const (
+	cacheKeyCcEncryptionKey = "ccEncryptionKey"
+	cacheKeyVerifiedKeys    = "verifiedKeys"
+	cacheKeyPeerEndpoints   = "peerEndpoints"
+)
+
+// WithCacheTTL sets the time the chaincode encryption key, the verified enclave keys and the peer endpoints returned by
+// the enclave registry are cached; 0 disables caching, i.e., the enclave registry is queried for every transaction
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(c *contractImpl) {
+		c.cache.ttl = ttl
+	}
+}
+
+// WithSequenceCheckInterval enables checking the sequence of the committed chaincode definition at most once per
+// interval while values are cached. If the sequence has changed, e.g., due to a chaincode upgrade, the cache is
+// invalidated. This option only applies to contracts created with GetContract.
+func WithSequenceCheckInterval(interval time.Duration) Option {
+	return func(c *contractImpl) {
+		c.cache.sequenceCheckInterval = interval
+	}
+}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// cache holds the values queried from the enclave registry until they expire or the cache is invalidated
+type cache struct {
+	ttl                   time.Duration
+	sequenceCheckInterval time.Duration
+
+	mutex           sync.Mutex
+	entries         map[string]cacheEntry
+	sequence        int64
+	sequenceChecked time.Time
+}
+
+func (ca *cache) get(key string) (any, bool) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	e, ok := ca.entries[key]
+	if !ok || !time.Now().Before(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (ca *cache) put(key string, value any) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	if ca.entries == nil {
+		ca.entries = make(map[string]cacheEntry)
+	}
+	ca.entries[key] = cacheEntry{value: value, expires: time.Now().Add(ca.ttl)}
+}
+
+func (ca *cache) invalidate() {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	ca.entries = nil
+}
+
+// InvalidateCache drops the cached chaincode encryption key, verified enclave keys and peer endpoints, thus, they are
+// queried again from the enclave registry by the next transaction
+func (c *contractImpl) InvalidateCache() {
+	logger.Debugf("invalidating cache of chaincode '%s'", c.Name())
+	c.cache.invalidate()
+}
+
+// cached returns the value cached for key or, if there is none or the cache is disabled, fetches and caches it
+func (c *contractImpl) cached(ctx context.Context, key string, fetch func(ctx context.Context) (any, error)) (any, error) {
+	if c.cache.ttl <= 0 {
+		return fetch(ctx)
+	}
+
+	if err := c.checkSequence(ctx); err != nil {
+		return nil, err
+	}
+	if value, ok := c.cache.get(key); ok {
+		return value, nil
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put(key, value)
+	return value, nil
+}
+
+// checkSequence invalidates the cache if the sequence of the committed chaincode definition has changed since the last
+// check; the sequence is queried at most once per sequence check interval
+func (c *contractImpl) checkSequence(ctx context.Context) error {
+	if c.lifecycle == nil || c.cache.sequenceCheckInterval <= 0 {
+		return nil
+	}
+
+	c.cache.mutex.Lock()
+	due := c.cache.sequenceChecked.IsZero() || time.Since(c.cache.sequenceChecked) >= c.cache.sequenceCheckInterval
+	c.cache.mutex.Unlock()
+	if !due {
+		return nil
+	}
+
+	sequence, err := c.querySequence(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		// without the sequence, we cannot tell whether the cached values are still valid
+		logger.Warnf("cannot check the chaincode definition sequence, invalidating cache: %s", err)
+		c.cache.invalidate()
+		return nil
+	}
+
+	c.cache.mutex.Lock()
+	defer c.cache.mutex.Unlock()
+	if !c.cache.sequenceChecked.IsZero() && sequence != c.cache.sequence {
+		logger.Debugf("chaincode definition sequence changed from %d to %d, invalidating cache", c.cache.sequence, sequence)
+		c.cache.entries = nil
+	}
+	c.cache.sequence = sequence
+	c.cache.sequenceChecked = time.Now()
+	return nil
+}
+
+// querySequence returns the sequence of the committed chaincode definition
+func (c *contractImpl) querySequence(ctx context.Context) (int64, error) {
+	// note that we use Fabric's Marshall as it still uses protobuf V1
+	argsBytes, err := protoutil.Marshal(&lifecycle.QueryChaincodeDefinitionArgs{Name: c.Name()})
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.lifecycle.EvaluateTransaction(ctx, "QueryChaincodeDefinition", string(argsBytes))
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot query chaincode definition")
+	}
+
+	ccDef, err := utils.UnmarshalQueryChaincodeDefinitionResult(resp)
+	if err != nil {
+		return 0, err
+	}
+	return ccDef.GetSequence(), nil
+}
+
+// invalidateOnStaleKey calls f and, if it fails because the request was prepared with outdated cached values,
+// invalidates the cache and calls f once more. f must not have submitted the transaction for endorsement, as the
+// transaction could otherwise commit twice.
+func (c *contractImpl) invalidateOnStaleKey(ctx context.Context, f func() error) error {
+	err := f()
+	if err == nil || c.cache.ttl <= 0 || !isStaleKeyError(err) || ctx.Err() != nil {
+		return err
+	}
+
+	logger.Debugf("request rejected due to outdated cached values, retrying: %s", err)
+	c.InvalidateCache()
+	return f()
+}
+
+// isStaleKeyError returns true if a request failed as it was prepared with outdated cached values, i.e., ECC rejected
+// it with a StaleKeyError, or the response is signed by an enclave registered after the values were cached
+func isStaleKeyError(err error) bool {
+	var staleKeyErr *StaleKeyError
+	var unknownEnclaveErr *crypto.UnknownEnclaveError
+	return errors.As(err, &staleKeyErr) || errors.As(err, &unknownEnclaveErr)
+}
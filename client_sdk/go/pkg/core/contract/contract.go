@@ -9,25 +9,36 @@ SPDX-License-Identifier: Apache-2.0
 package contract
 
 import (
-	"strings"
+	"context"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	fpcerrors "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/errors"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
 )
 
 var logger = flogging.MustGetLogger("fpc-client-contract")
 
 // Transaction interface that is needed by the FPC contract implementation
 type Transaction interface {
-	Evaluate(args ...string) ([]byte, error)
+	Evaluate(ctx context.Context, args ...string) ([]byte, error)
 }
 
-// Contract interface
+// Contract interface that is needed by the FPC contract implementation. Implementations should pass ctx on to their
+// requests. SubmitTransaction must return a *StaleReadError if the transaction failed due to a stale read, and all
+// requests must return a *StaleKeyError if ECC rejected them with endorsement.StaleKeyStatus.
 type Contract interface {
 	Name() string
-	EvaluateTransaction(name string, args ...string) ([]byte, error)
-	SubmitTransaction(name string, args ...string) ([]byte, error)
+	EvaluateTransaction(ctx context.Context, name string, args ...string) ([]byte, error)
+	SubmitTransaction(ctx context.Context, name string, args ...string) ([]byte, error)
 	CreateTransaction(name string, peerEndpoints ...string) (Transaction, error)
 }
 
@@ -43,31 +54,92 @@ type Provider interface {
 //
 //	Returns:
 //	The contractImpl object
-func GetContract(p Provider, chaincodeID string) *contractImpl {
+func GetContract(p Provider, chaincodeID string, opts ...Option) *contractImpl {
 	ercc := p.GetContract("ercc")
-	return New(p.GetContract(chaincodeID), ercc, nil, &crypto.EncryptionProviderImpl{
-		CSP: crypto.GetDefaultCSP(),
-		GetCcEncryptionKey: func() ([]byte, error) {
-			// Note that this function is called during EncryptionProvider.NewEncryptionContext()
-			return ercc.EvaluateTransaction("queryChaincodeEncryptionKey", chaincodeID)
-		}})
+	ep := &crypto.EncryptionProviderImpl{CSP: crypto.GetDefaultCSP()}
+	c := New(p.GetContract(chaincodeID), ercc, nil, ep, opts...)
+
+	// Note that these functions are called during EncryptionProvider.NewEncryptionContext()
+	if c.verifier == nil {
+		ep.GetCcEncryptionKey = c.getCcEncryptionKey
+		if c.cache.sequenceCheckInterval > 0 {
+			c.lifecycle = p.GetContract("_lifecycle")
+		}
+		return c
+	}
+	ep.GetVerifiedKeys = c.getVerifiedKeys
+
+	if len(c.expectedMrenclave) == 0 || c.cache.sequenceCheckInterval > 0 {
+		// the expected mrenclave is taken from the committed chaincode definition
+		c.lifecycle = p.GetContract("_lifecycle")
+	}
+	return c
+}
+
+// DefaultStaleReadRetries is the default number of times a transaction is retried if it fails due to a stale read
+const DefaultStaleReadRetries = 3
+
+// BatchRequest is a single transaction invocation of a batch
+type BatchRequest struct {
+	Name string
+	Args []string
+}
+
+// BatchResult is the result of a single transaction invocation of a batch; Err is set if the invocation failed
+type BatchResult struct {
+	Payload []byte
+	Err     error
+}
+
+// Option configures a Contract
+type Option func(*contractImpl)
+
+// WithStaleReadRetries sets the number of times SubmitTransaction re-executes a transaction that fails due to a
+// stale read; 0 disables retries
+func WithStaleReadRetries(retries int) Option {
+	return func(c *contractImpl) {
+		c.staleReadRetries = retries
+	}
 }
 
 // contractImpl implements the client-side FPC protocol
 type contractImpl struct {
-	target        Contract
-	ercc          Contract
-	peerEndpoints []string
-	ep            crypto.EncryptionProvider
+	target           Contract
+	ercc             Contract
+	peerEndpoints    []string
+	ep               crypto.EncryptionProvider
+	staleReadRetries int
+
+	// enclave credential verification, see WithCredentialVerification
+	verifier          attestation.Verifier
+	expectedMrenclave string
+	crossCheckPeers   []string
+	lifecycle         Contract
+
+	// values queried from the enclave registry, see WithCacheTTL
+	cache cache
+
+	// enclave endpoint selection, see WithEndpointSelector
+	selector        EndpointSelector
+	endpointsMutex  sync.Mutex
+	enclaveHosts    enclaveHosts
+	failedEndpoints map[string]time.Time
 }
 
-func New(fpc Contract, ercc Contract, peerEndpoints []string, ep crypto.EncryptionProvider) *contractImpl {
-	return &contractImpl{
-		target:        fpc,
-		ercc:          ercc,
-		peerEndpoints: peerEndpoints,
-		ep:            ep,
+func New(fpc Contract, ercc Contract, peerEndpoints []string, ep crypto.EncryptionProvider, opts ...Option) *contractImpl {
+	c := &contractImpl{
+		target:           fpc,
+		ercc:             ercc,
+		peerEndpoints:    peerEndpoints,
+		ep:               ep,
+		staleReadRetries: DefaultStaleReadRetries,
+		cache:            cache{ttl: DefaultCacheTTL},
+		selector:         NewRoundRobinSelector(),
 	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
 }
 
 func (c *contractImpl) Name() string {
@@ -75,23 +147,37 @@ func (c *contractImpl) Name() string {
 }
 
 func (c *contractImpl) EvaluateTransaction(name string, args ...string) ([]byte, error) {
-	ctx, err := c.ep.NewEncryptionContext()
+	return c.EvaluateTransactionWithContext(context.Background(), name, args...)
+}
+
+// EvaluateTransactionWithContext is like EvaluateTransaction, but returns a *fpcerrors.ContextError as soon as ctx is done
+func (c *contractImpl) EvaluateTransactionWithContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var resp []byte
+	err := c.invalidateOnStaleKey(ctx, func() (err error) {
+		resp, err = c.evaluate(ctx, name, args...)
+		return err
+	})
+	return resp, fpcerrors.FromContext(ctx, "evaluate transaction "+name, err)
+}
+
+func (c *contractImpl) evaluate(ctx context.Context, name string, args ...string) ([]byte, error) {
+	encCtx, err := c.newEncryptionContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	encryptedRequest, err := ctx.Conceal(name, args)
+	encryptedRequest, err := encCtx.Conceal(name, args)
 	if err != nil {
 		return nil, err
 	}
 
 	// call __invoke
-	encryptedResponse, err := c.evaluateTransaction(encryptedRequest)
+	encryptedResponse, err := c.evaluateTransaction(ctx, encryptedRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	clearResponseBytes, err := ctx.Reveal(encryptedResponse)
+	clearResponseBytes, err := encCtx.Reveal(encryptedResponse)
 	if err != nil {
 		return nil, err
 	}
@@ -101,29 +187,115 @@ func (c *contractImpl) EvaluateTransaction(name string, args ...string) ([]byte,
 }
 
 func (c *contractImpl) SubmitTransaction(name string, args ...string) ([]byte, error) {
-	ctx, err := c.ep.NewEncryptionContext()
-	if err != nil {
-		return nil, err
+	return c.SubmitTransactionWithContext(context.Background(), name, args...)
+}
+
+// SubmitTransactionWithContext is like SubmitTransaction, but returns a *fpcerrors.ContextError as soon as ctx is done. Note
+// that a transaction that has already been sent to the ordering service may still be committed.
+func (c *contractImpl) SubmitTransactionWithContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var resp []byte
+	err := c.retryOnStaleRead(ctx, func() (err error) {
+		resp, err = c.submitTransaction(ctx, name, args...)
+		return err
+	})
+	return resp, fpcerrors.FromContext(ctx, "submit transaction "+name, err)
+}
+
+// SubmitBatch submits several transaction invocations as a single FPC transaction. All invocations are executed by
+// the enclave within a single `__invoke`, and their results are returned in the order of the requests.
+// If independent is false, the batch is atomic, i.e., an error is returned if one of the invocations fails and no
+// state updates are committed. If independent is true, the state updates of successful invocations are committed
+// and failed invocations are reported in the corresponding BatchResult.
+func (c *contractImpl) SubmitBatch(requests []BatchRequest, independent bool) ([]BatchResult, error) {
+	return c.SubmitBatchWithContext(context.Background(), requests, independent)
+}
+
+// SubmitBatchWithContext is like SubmitBatch, but returns a *fpcerrors.ContextError as soon as ctx is done
+func (c *contractImpl) SubmitBatchWithContext(ctx context.Context, requests []BatchRequest, independent bool) ([]BatchResult, error) {
+	var results []BatchResult
+	err := c.retryOnStaleRead(ctx, func() (err error) {
+		results, err = c.submitBatch(ctx, requests, independent)
+		return err
+	})
+	return results, fpcerrors.FromContext(ctx, "submit batch", err)
+}
+
+// retryOnStaleRead calls f until it succeeds, fails with an error other than a stale read, the retries are exhausted,
+// or ctx is done
+func (c *contractImpl) retryOnStaleRead(ctx context.Context, f func() error) error {
+	for i := 0; ; i++ {
+		err := f()
+		if err == nil || i >= c.staleReadRetries || !isStaleRead(err) || ctx.Err() != nil {
+			return err
+		}
+		// the enclave has read state that was updated concurrently, thus, we re-execute the transaction
+		logger.Debugf("stale read detected, retrying transaction (%d/%d): %s", i+1, c.staleReadRetries, err)
 	}
+}
+
+// isStaleRead returns true if the error is caused by a stale read, either detected by `__endorse` or by Fabric's
+// MVCC check at commit time
+func isStaleRead(err error) bool {
+	var staleReadErr *StaleReadError
+	return errors.As(err, &staleReadErr)
+}
+
+// invokeVerified conceals a request with a new encryption context, calls __invoke and verifies the response. If the
+// request was prepared with outdated cached values, it is prepared and invoked once more, see invalidateOnStaleKey;
+// as nothing has been submitted for endorsement yet, this cannot commit the transaction twice.
+func (c *contractImpl) invokeVerified(ctx context.Context, conceal func(crypto.EncryptionContext) (string, error)) (crypto.EncryptionContext, []byte, error) {
+	var encCtx crypto.EncryptionContext
+	var encryptedResponse []byte
+	err := c.invalidateOnStaleKey(ctx, func() (err error) {
+		encCtx, err = c.newEncryptionContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		encryptedRequest, err := conceal(encCtx)
+		if err != nil {
+			return err
+		}
+
+		// call __invoke
+		encryptedResponse, err = c.evaluateTransaction(ctx, encryptedRequest)
+		if err != nil {
+			return err
+		}
 
-	encryptedRequest, err := ctx.Conceal(name, args)
+		// only submit responses of a verified enclave that refer to our request for endorsement
+		return encCtx.Verify(encryptedResponse)
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return encCtx, encryptedResponse, nil
+}
 
-	// call __invoke
-	encryptedResponse, err := c.evaluateTransaction(encryptedRequest)
+// endorse submits the verified response to __endorse. Once submitted, the transaction may commit, thus, it is not
+// retried on outdated cached values; the cache is only invalidated for the next transaction.
+func (c *contractImpl) endorse(ctx context.Context, encryptedResponse []byte) error {
+	logger.Debugf("calling __endorse!")
+	_, err := c.target.SubmitTransaction(ctx, "__endorse", string(encryptedResponse))
+	if err != nil && isStaleKeyError(err) {
+		c.InvalidateCache()
+	}
+	return err
+}
+
+func (c *contractImpl) submitTransaction(ctx context.Context, name string, args ...string) ([]byte, error) {
+	encCtx, encryptedResponse, err := c.invokeVerified(ctx, func(encCtx crypto.EncryptionContext) (string, error) {
+		return encCtx.Conceal(name, args)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	logger.Debugf("calling __endorse!")
-	_, err = c.target.SubmitTransaction("__endorse", string(encryptedResponse))
-	if err != nil {
+	if err := c.endorse(ctx, encryptedResponse); err != nil {
 		return nil, err
 	}
 
-	clearResponseBytes, err := ctx.Reveal(encryptedResponse)
+	clearResponseBytes, err := encCtx.Reveal(encryptedResponse)
 	if err != nil {
 		return nil, err
 	}
@@ -132,33 +304,59 @@ func (c *contractImpl) SubmitTransaction(name string, args ...string) ([]byte, e
 	return utils.UnwrapResponse(clearResponseBytes)
 }
 
-// getPeerEndpoints returns an array of peer endpoints that host the FPC chaincode enclave
-// An endpoint is a simple string with the format `host:port`
-func (c *contractImpl) getPeerEndpoints() ([]string, error) {
-	if len(c.peerEndpoints) == 0 {
-		resp, err := c.ercc.EvaluateTransaction("queryChaincodeEndPoints", c.Name())
-		if err != nil {
-			return nil, err
-		}
-		c.peerEndpoints = strings.Split(string(resp), ",")
+func (c *contractImpl) submitBatch(ctx context.Context, requests []BatchRequest, independent bool) ([]BatchResult, error) {
+	batch := make([][]string, len(requests))
+	for i, r := range requests {
+		batch[i] = append([]string{r.Name}, r.Args...)
 	}
-	return c.peerEndpoints, nil
-}
 
-func (c *contractImpl) evaluateTransaction(args ...string) ([]byte, error) {
-	peers, err := c.getPeerEndpoints()
+	encCtx, encryptedResponse, err := c.invokeVerified(ctx, func(encCtx crypto.EncryptionContext) (string, error) {
+		return encCtx.ConcealBatch(batch, independent)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	txn, err := c.target.CreateTransaction(
-		"__invoke",
-		peers...,
-	)
+	if err := c.endorse(ctx, encryptedResponse); err != nil {
+		return nil, err
+	}
+
+	clearResponseBytes, err := encCtx.Reveal(encryptedResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	// unwrap Response.Payload
+	batchResponseBytes, err := utils.UnwrapResponse(clearResponseBytes)
 	if err != nil {
 		return nil, err
 	}
 
-	logger.Debugf("calling __invoke!")
-	return txn.Evaluate(args...)
+	batchResponse := &protos.CleartextChaincodeBatchResponse{}
+	if err := proto.Unmarshal(batchResponseBytes, batchResponse); err != nil {
+		return nil, errors.Wrap(err, "failed to extract batch response")
+	}
+
+	if len(batchResponse.GetResponses()) != len(requests) {
+		return nil, fmt.Errorf("%d responses for a batch of %d requests", len(batchResponse.GetResponses()), len(requests))
+	}
+
+	results := make([]BatchResult, len(requests))
+	for i, r := range batchResponse.GetResponses() {
+		if r.GetStatus() != shim.OK {
+			results[i].Err = errors.New(r.GetMessage())
+			continue
+		}
+		results[i].Payload = r.GetPayload()
+	}
+
+	return results, nil
+}
+
+// newEncryptionContext creates a new encryption context, unless ctx is already done
+func (c *contractImpl) newEncryptionContext(ctx context.Context) (crypto.EncryptionContext, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.ep.NewEncryptionContext(ctx)
 }
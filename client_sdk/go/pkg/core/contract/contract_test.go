@@ -7,15 +7,29 @@ SPDX-License-Identifier: Apache-2.0
 package contract_test
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	fpccontract "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract/fakes"
+	fpcerrors "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/errors"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
 	"github.com/hyperledger/fabric/protoutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 //go:generate counterfeiter -o fakes/contract_provider.go -fake-name ContractProvider . contractProvider
@@ -120,8 +134,9 @@ func TestContractEvaluateTransactionSuccess(t *testing.T) {
 
 	// check that the transaction was evaluates with correct args
 	assert.Equal(t, 1, txn.EvaluateCallCount())
-	assert.Len(t, txn.EvaluateArgsForCall(0), 1)
-	assert.Equal(t, expectedEvalArgs, txn.EvaluateArgsForCall(0)[0])
+	_, evalArgs := txn.EvaluateArgsForCall(0)
+	assert.Len(t, evalArgs, 1)
+	assert.Equal(t, expectedEvalArgs, evalArgs[0])
 
 }
 
@@ -246,6 +261,741 @@ func TestContractSubmitTransaction(t *testing.T) {
 
 	// check that SubmitTransaction was invoked once
 	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+
+	// the response is verified before it is submitted to __endorse
+	assert.Equal(t, 1, mockEncryptionContext.VerifyCallCount())
+	assert.Equal(t, expectedResult, mockEncryptionContext.VerifyArgsForCall(0))
+
+	// a response that fails the verification is never submitted
+	invokeTx.EvaluateReturns(expectedResult, nil)
+	mockContract.CreateTransactionReturns(invokeTx, nil)
+	mockEncryptionContext.VerifyReturns(fmt.Errorf("enclave signature verification failed"))
+	resp, err = contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "enclave signature verification failed")
+	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+	assert.Equal(t, 1, mockEncryptionContext.RevealCallCount())
+}
+
+func TestContractSubmitTransactionStaleReadRetry(t *testing.T) {
+	expectedResult := []byte("result")
+
+	invokeTx := &fakes.Transaction{}
+	invokeTx.EvaluateReturns(expectedResult, nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(invokeTx, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1,peer2,peer3"), nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.ConcealReturns("someEncryptedArgs", nil)
+	mockEncryptionContext.RevealCalls(func(input []byte) ([]byte, error) {
+		return asResponseBytes(input), nil
+	})
+
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	// __endorse detects a stale read first, then the transaction is invalidated at commit, then it succeeds
+	staleReadErr := &fpccontract.StaleReadError{Err: &endorsement.StaleReadError{Key: "someKey"}}
+	mockContract.SubmitTransactionReturnsOnCall(0, nil, staleReadErr)
+	mockContract.SubmitTransactionReturnsOnCall(1, nil, &fpccontract.StaleReadError{Err: fmt.Errorf("commit failed with code MVCC_READ_CONFLICT")})
+	mockContract.SubmitTransactionReturnsOnCall(2, nil, nil)
+
+	contract := fpccontract.New(mockContract, mockERCC, nil, mockEncryptionProvider)
+	resp, err := contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResult, resp)
+	assert.Equal(t, 3, invokeTx.EvaluateCallCount())
+	assert.Equal(t, 3, mockContract.SubmitTransactionCallCount())
+	assert.Equal(t, 3, mockEncryptionProvider.NewEncryptionContextCallCount())
+
+	// retries exhausted
+	mockContract.SubmitTransactionReturns(nil, staleReadErr)
+	contract = fpccontract.New(mockContract, mockERCC, nil, mockEncryptionProvider, fpccontract.WithStaleReadRetries(1))
+	resp, err = contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.Nil(t, resp)
+	assert.True(t, endorsement.IsStaleReadError(err))
+	assert.Equal(t, 5, mockContract.SubmitTransactionCallCount())
+
+	// no retries for other errors
+	mockContract.SubmitTransactionReturns(nil, fmt.Errorf("endorse failed"))
+	resp, err = contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "endorse failed")
+	assert.Equal(t, 6, mockContract.SubmitTransactionCallCount())
+
+	// stale reads are not detected from error messages
+	mockContract.SubmitTransactionReturns(nil, fmt.Errorf("commit failed with code MVCC_READ_CONFLICT"))
+	_, err = contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.Error(t, err)
+	assert.Equal(t, 7, mockContract.SubmitTransactionCallCount())
+}
+
+func TestContractSubmitTransactionStaleKeyRetry(t *testing.T) {
+	expectedResult := []byte("result")
+
+	invokeTx := &fakes.Transaction{}
+	invokeTx.EvaluateReturns(expectedResult, nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(invokeTx, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1"), nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.ConcealReturns("someEncryptedArgs", nil)
+	mockEncryptionContext.RevealCalls(func(input []byte) ([]byte, error) {
+		return asResponseBytes(input), nil
+	})
+
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	contract := fpccontract.New(mockContract, mockERCC, nil, mockEncryptionProvider)
+
+	// the response comes from an enclave unknown to the client, thus, the request is prepared and invoked once more
+	// before anything is submitted
+	mockEncryptionContext.VerifyReturnsOnCall(0, &crypto.UnknownEnclaveError{EnclaveId: "enclave2"})
+	resp, err := contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResult, resp)
+	assert.Equal(t, 2, invokeTx.EvaluateCallCount())
+	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+	assert.Equal(t, 2, mockERCC.EvaluateTransactionCallCount())
+
+	// Reveal fails after a successful __endorse, thus, the transaction may have committed and is not retried
+	mockEncryptionContext.RevealReturns(nil, &crypto.UnknownEnclaveError{EnclaveId: "enclave2"})
+	mockEncryptionContext.RevealCalls(nil)
+	resp, err = contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "response from unknown enclave 'enclave2'")
+	assert.Equal(t, 3, invokeTx.EvaluateCallCount())
+	assert.Equal(t, 2, mockContract.SubmitTransactionCallCount())
+
+	// __endorse rejects the response as the enclave was created for another chaincode definition; the transaction is
+	// not retried, but the cache is invalidated for the next one
+	mockContract.SubmitTransactionReturns(nil, &fpccontract.StaleKeyError{Err: fmt.Errorf("ccParams don't match")})
+	resp, err = contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "ccParams don't match")
+	assert.Equal(t, 4, invokeTx.EvaluateCallCount())
+	assert.Equal(t, 3, mockContract.SubmitTransactionCallCount())
+	ercc := mockERCC.EvaluateTransactionCallCount()
+	_, _ = contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.Equal(t, ercc+1, mockERCC.EvaluateTransactionCallCount())
+
+	// the same holds for batches
+	mockContract.SubmitTransactionReturns(nil, nil)
+	mockEncryptionContext.ConcealBatchReturns("someEncryptedBatch", nil)
+	submitted := mockContract.SubmitTransactionCallCount()
+	results, err := contract.SubmitBatch([]fpccontract.BatchRequest{{Name: "f1"}}, false)
+	assert.Nil(t, results)
+	assert.EqualError(t, err, "response from unknown enclave 'enclave2'")
+	assert.Equal(t, submitted+1, mockContract.SubmitTransactionCallCount())
+}
+
+func TestContractSubmitBatch(t *testing.T) {
+	batchResponse := &protos.CleartextChaincodeBatchResponse{
+		Responses: []*peer.Response{
+			{Status: 200, Payload: []byte("result1")},
+			{Status: 500, Message: "some error"},
+		},
+	}
+	batchResponseBytes, _ := proto.Marshal(batchResponse)
+
+	invokeTx := &fakes.Transaction{}
+	invokeTx.EvaluateReturns(batchResponseBytes, nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(invokeTx, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1,peer2,peer3"), nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.ConcealBatchReturns("someEncryptedBatch", nil)
+	mockEncryptionContext.RevealCalls(func(input []byte) ([]byte, error) {
+		return asResponseBytes(input), nil
+	})
+
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	contract := fpccontract.New(mockContract, mockERCC, nil, mockEncryptionProvider)
+
+	requests := []fpccontract.BatchRequest{
+		{Name: "f1", Args: []string{"arg1"}},
+		{Name: "f2"},
+	}
+
+	// success
+	results, err := contract.SubmitBatch(requests, true)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, []byte("result1"), results[0].Payload)
+	assert.NoError(t, results[0].Err)
+	assert.Nil(t, results[1].Payload)
+	assert.EqualError(t, results[1].Err, "some error")
+
+	batch, independent := mockEncryptionContext.ConcealBatchArgsForCall(0)
+	assert.Equal(t, [][]string{{"f1", "arg1"}, {"f2"}}, batch)
+	assert.True(t, independent)
+	_, evalArgs := invokeTx.EvaluateArgsForCall(0)
+	assert.Equal(t, "someEncryptedBatch", evalArgs[0])
+	_, name, args := mockContract.SubmitTransactionArgsForCall(0)
+	assert.Equal(t, "__endorse", name)
+	assert.Equal(t, []string{string(batchResponseBytes)}, args)
+
+	// number of responses does not match
+	results, err = contract.SubmitBatch(requests[:1], false)
+	assert.Nil(t, results)
+	assert.EqualError(t, err, "2 responses for a batch of 1 requests")
+
+	// batch fails as a whole
+	mockEncryptionContext.RevealReturns(protoutil.MarshalOrPanic(&peer.Response{Status: 500, Message: "batch invocation 1 failed: some error"}), nil)
+	results, err = contract.SubmitBatch(requests, false)
+	assert.Nil(t, results)
+	assert.Error(t, err)
+
+	// a response that fails the verification is never submitted
+	submitted := mockContract.SubmitTransactionCallCount()
+	mockEncryptionContext.VerifyReturns(&crypto.UnknownEnclaveError{EnclaveId: "enclave1"})
+	results, err = contract.SubmitBatch(requests, false)
+	assert.Nil(t, results)
+	assert.EqualError(t, err, "response from unknown enclave 'enclave1'")
+	assert.Equal(t, submitted, mockContract.SubmitTransactionCallCount())
+
+	// conceal fails
+	mockEncryptionContext.ConcealBatchReturns("", fmt.Errorf("conceal failed"))
+	results, err = contract.SubmitBatch(requests, false)
+	assert.Nil(t, results)
+	assert.EqualError(t, err, "conceal failed")
+}
+
+func TestContractWithContext(t *testing.T) {
+	invokeTx := &fakes.Transaction{}
+	invokeTx.EvaluateReturns([]byte("result"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(invokeTx, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1,peer2,peer3"), nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.ConcealReturns("someEncryptedArgs", nil)
+	mockEncryptionContext.RevealCalls(func(input []byte) ([]byte, error) {
+		return asResponseBytes(input), nil
+	})
+
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	contract := fpccontract.New(mockContract, mockERCC, nil, mockEncryptionProvider)
+
+	// the context is passed to the ercc lookup, __invoke and __endorse
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	resp, err := contract.SubmitTransactionWithContext(ctx, "someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), resp)
+	assert.Equal(t, ctx, mockEncryptionProvider.NewEncryptionContextArgsForCall(0))
+	erccCtx, _, _ := mockERCC.EvaluateTransactionArgsForCall(0)
+	assert.Equal(t, ctx, erccCtx)
+	invokeCtx, _ := invokeTx.EvaluateArgsForCall(0)
+	assert.Equal(t, ctx, invokeCtx)
+	endorseCtx, _, _ := mockContract.SubmitTransactionArgsForCall(0)
+	assert.Equal(t, ctx, endorseCtx)
+
+	// __invoke hangs until the deadline is exceeded
+	invokeTx.EvaluateCalls(func(ctx context.Context, args ...string) ([]byte, error) {
+		<-ctx.Done()
+		return nil, fmt.Errorf("rpc error: %s", ctx.Err())
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	resp, err = contract.EvaluateTransactionWithContext(ctx, "someFunction", "arg1")
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "evaluate transaction someFunction aborted: context deadline exceeded")
+	var contextErr *fpcerrors.ContextError
+	assert.ErrorAs(t, err, &contextErr)
+	assert.Equal(t, "evaluate transaction someFunction", contextErr.Op)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, fpcerrors.IsTimeout(err))
+
+	// a cancelled context fails before the request is encrypted
+	calls := mockEncryptionProvider.NewEncryptionContextCallCount()
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	results, err := contract.SubmitBatchWithContext(ctx, []fpccontract.BatchRequest{{Name: "f1"}}, false)
+	assert.Nil(t, results)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, fpcerrors.IsTimeout(err))
+	assert.Equal(t, calls, mockEncryptionProvider.NewEncryptionContextCallCount())
+
+	// stale read retries stop when the context is done
+	invokeTx.EvaluateReturns([]byte("result"), nil)
+	invokeTx.EvaluateCalls(nil)
+	ctx, cancel = context.WithCancel(context.Background())
+	mockContract.SubmitTransactionCalls(func(context.Context, string, ...string) ([]byte, error) {
+		cancel()
+		return nil, &fpccontract.StaleReadError{Err: &endorsement.StaleReadError{Key: "someKey"}}
+	})
+	endorsements := mockContract.SubmitTransactionCallCount()
+	_, err = contract.SubmitTransactionWithContext(ctx, "someFunction")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, endorsements+1, mockContract.SubmitTransactionCallCount())
+}
+
+func newCredentials(t *testing.T, chaincodeID, mrenclave string, enclaveVk, chaincodeEk []byte) string {
+	return newHostCredentials(t, chaincodeID, mrenclave, enclaveVk, chaincodeEk, &protos.HostParameters{})
+}
+
+func newHostCredentials(t *testing.T, chaincodeID, mrenclave string, enclaveVk, chaincodeEk []byte, hostParams *protos.HostParameters) string {
+	attestedData, err := anypb.New(&protos.AttestedData{
+		CcParams:    &protos.CCParameters{ChaincodeId: chaincodeID, Version: mrenclave},
+		HostParams:  hostParams,
+		EnclaveVk:   enclaveVk,
+		ChaincodeEk: chaincodeEk,
+	})
+	require.NoError(t, err)
+	return utils.MarshallProtoBase64(&protos.Credentials{
+		SerializedAttestedData: attestedData,
+		Evidence:               []byte(`{"attestation_type":"simulated","evidence":"MA=="}`),
+	})
+}
+
+func TestContractCredentialVerification(t *testing.T) {
+	chaincodeID := "myChaincode"
+	mrenclave := "98aed61c91f258a37c68ed4943297695647ec7bbe6008cc111b0a12650ebeb91"
+	otherMrenclave := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	chaincodeEk, _, err := crypto.GetDefaultCSP().NewRSAKeys()
+	require.NoError(t, err)
+	enclave1 := newCredentials(t, chaincodeID, mrenclave, []byte("enclave1"), chaincodeEk)
+	enclave2 := newCredentials(t, chaincodeID, mrenclave, []byte("enclave2"), chaincodeEk)
+
+	ccDef, err := protoutil.Marshal(&lifecycle.QueryChaincodeDefinitionResult{Version: mrenclave})
+	require.NoError(t, err)
+
+	setup := func(credentials ...string) (*fakes.ContractProvider, *fakes.Contract, *fakes.Contract, *fakes.Transaction) {
+		invokeTx := &fakes.Transaction{}
+		invokeTx.EvaluateReturns([]byte("encrypted response"), nil)
+		mockContract := &fakes.Contract{}
+		mockContract.NameReturns(chaincodeID)
+		mockContract.CreateTransactionReturns(invokeTx, nil)
+
+		credentialsJSON, err := json.Marshal(credentials)
+		require.NoError(t, err)
+		mockERCC := &fakes.Contract{}
+		mockERCC.EvaluateTransactionCalls(func(_ context.Context, name string, args ...string) ([]byte, error) {
+			switch name {
+			case "QueryListEnclaveCredentials":
+				return credentialsJSON, nil
+			case "queryChaincodeEndPoints":
+				return []byte("peer1"), nil
+			}
+			return nil, fmt.Errorf("unexpected transaction %s", name)
+		})
+
+		mockLifecycle := &fakes.Contract{}
+		mockLifecycle.EvaluateTransactionReturns(ccDef, nil)
+
+		mockProvider := &fakes.ContractProvider{}
+		mockProvider.GetContractCalls(func(id string) fpccontract.Contract {
+			switch id {
+			case "ercc":
+				return mockERCC
+			case "_lifecycle":
+				return mockLifecycle
+			}
+			return mockContract
+		})
+		return mockProvider, mockERCC, mockLifecycle, invokeTx
+	}
+
+	verifier := attestation.NewCredentialVerifier(simulation.NewSimulationVerifier())
+
+	// the verified chaincode encryption key is used to encrypt the request, thus, __invoke is called; the response
+	// cannot be decrypted though
+	mockProvider, mockERCC, mockLifecycle, invokeTx := setup(enclave1, enclave2)
+	contract := fpccontract.GetContract(mockProvider, chaincodeID, fpccontract.WithCredentialVerification(verifier))
+	_, err = contract.EvaluateTransaction("someFunction", "arg1")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "enclave credential verification failed")
+	assert.Equal(t, 1, invokeTx.EvaluateCallCount())
+	assert.Equal(t, "_lifecycle", mockProvider.GetContractArgsForCall(2))
+	_, name, args := mockLifecycle.EvaluateTransactionArgsForCall(0)
+	assert.Equal(t, "QueryChaincodeDefinition", name)
+	assert.Equal(t, []string{string(protoutil.MarshalOrPanic(&lifecycle.QueryChaincodeDefinitionArgs{Name: chaincodeID}))}, args)
+	_, name, args = mockERCC.EvaluateTransactionArgsForCall(0)
+	assert.Equal(t, "QueryListEnclaveCredentials", name)
+	assert.Equal(t, []string{chaincodeID}, args)
+
+	// pinned mrenclave, the chaincode definition is not queried
+	mockProvider, _, mockLifecycle, invokeTx = setup(enclave1)
+	contract = fpccontract.GetContract(mockProvider, chaincodeID, fpccontract.WithCredentialVerification(verifier), fpccontract.WithExpectedMrenclave(mrenclave))
+	_, _ = contract.EvaluateTransaction("someFunction", "arg1")
+	assert.Equal(t, 1, invokeTx.EvaluateCallCount())
+	assert.Equal(t, 2, mockProvider.GetContractCallCount())
+	assert.Equal(t, 0, mockLifecycle.EvaluateTransactionCallCount())
+
+	tests := []struct {
+		name        string
+		credentials []string
+		opts        []fpccontract.Option
+		err         string
+	}{
+		{"no enclaves", nil, nil, "no enclave registered for chaincode 'myChaincode'"},
+		{"mrenclave", []string{enclave1}, []fpccontract.Option{fpccontract.WithExpectedMrenclave(otherMrenclave)}, "has mrenclave " + mrenclave + ", expected " + otherMrenclave},
+		{"chaincode", []string{newCredentials(t, "otherChaincode", mrenclave, []byte("enclave1"), chaincodeEk)}, nil, "is registered for chaincode 'otherChaincode'"},
+		{"no chaincode ek", []string{newCredentials(t, chaincodeID, mrenclave, []byte("enclave1"), nil)}, nil, "attests no chaincode encryption key"},
+		{"no enclave vk", []string{newCredentials(t, chaincodeID, mrenclave, nil, chaincodeEk)}, nil, "attests no enclave verification key"},
+		{"different chaincode eks", []string{enclave1, newCredentials(t, chaincodeID, mrenclave, []byte("enclave2"), []byte("other key"))}, nil, "enclaves of chaincode 'myChaincode' attest different chaincode encryption keys"},
+		{"policy", []string{enclave1}, []fpccontract.Option{fpccontract.WithCredentialVerification(attestation.NewCredentialVerifierWithPolicy(&attestation.VerificationPolicy{AllowedStatuses: []string{"OK"}}, simulation.NewSimulationVerifier()))}, "verification policy violated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProvider, _, _, invokeTx := setup(tt.credentials...)
+			opts := append([]fpccontract.Option{fpccontract.WithCredentialVerification(verifier)}, tt.opts...)
+			contract := fpccontract.GetContract(mockProvider, chaincodeID, opts...)
+			resp, err := contract.EvaluateTransaction("someFunction", "arg1")
+			assert.Nil(t, resp)
+			assert.ErrorContains(t, err, "enclave credential verification failed")
+			assert.ErrorContains(t, err, tt.err)
+			assert.Equal(t, 0, invokeTx.EvaluateCallCount())
+		})
+	}
+}
+
+func TestContractCredentialVerificationCrossCheck(t *testing.T) {
+	chaincodeID := "myChaincode"
+	mrenclave := "98aed61c91f258a37c68ed4943297695647ec7bbe6008cc111b0a12650ebeb91"
+	chaincodeEk, _, err := crypto.GetDefaultCSP().NewRSAKeys()
+	require.NoError(t, err)
+	enclave1 := newCredentials(t, chaincodeID, mrenclave, []byte("enclave1"), chaincodeEk)
+	enclave2 := newCredentials(t, chaincodeID, mrenclave, []byte("enclave2"), chaincodeEk)
+
+	queryTx := func(resp string) *fakes.Transaction {
+		txn := &fakes.Transaction{}
+		txn.EvaluateReturns([]byte(resp), nil)
+		return txn
+	}
+
+	mockContract := &fakes.Contract{}
+	mockContract.NameReturns(chaincodeID)
+	mockERCC := &fakes.Contract{}
+	mockProvider := &fakes.ContractProvider{}
+	mockProvider.GetContractCalls(func(id string) fpccontract.Contract {
+		if id == "ercc" {
+			return mockERCC
+		}
+		return mockContract
+	})
+
+	contract := fpccontract.GetContract(mockProvider, chaincodeID,
+		fpccontract.WithCredentialVerification(attestation.NewCredentialVerifier(simulation.NewSimulationVerifier())),
+		fpccontract.WithExpectedMrenclave(mrenclave),
+		fpccontract.WithCrossCheckPeers("peer1", "peer2"))
+
+	// both peers return the same credentials, in a different order; the request is concealed but __invoke fails
+	mockERCC.CreateTransactionReturnsOnCall(0, queryTx(`["`+enclave1+`","`+enclave2+`"]`), nil)
+	mockERCC.CreateTransactionReturnsOnCall(1, queryTx(`["`+enclave2+`","`+enclave1+`"]`), nil)
+	mockERCC.EvaluateTransactionReturns(nil, fmt.Errorf("no endpoints"))
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.EqualError(t, err, "no endpoints")
+	for i, peer := range []string{"peer1", "peer2"} {
+		name, peers := mockERCC.CreateTransactionArgsForCall(i)
+		assert.Equal(t, "QueryListEnclaveCredentials", name)
+		assert.Equal(t, []string{peer}, peers)
+	}
+
+	// the verified keys are cached, thus, the credentials are only queried again after invalidating the cache
+	contract.InvalidateCache()
+
+	// the second peer hides an enclave
+	mockERCC.CreateTransactionReturnsOnCall(2, queryTx(`["`+enclave1+`","`+enclave2+`"]`), nil)
+	mockERCC.CreateTransactionReturnsOnCall(3, queryTx(`["`+enclave1+`"]`), nil)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.ErrorContains(t, err, "peers return different enclave credentials for chaincode 'myChaincode'")
+
+	// the second peer fails
+	mockERCC.CreateTransactionReturnsOnCall(4, queryTx(`["`+enclave1+`"]`), nil)
+	failingTx := &fakes.Transaction{}
+	failingTx.EvaluateReturns(nil, fmt.Errorf("peer down"))
+	mockERCC.CreateTransactionReturnsOnCall(5, failingTx, nil)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.ErrorContains(t, err, "query at peer peer2 failed: peer down")
+}
+
+// staleKeyErr is returned by the contract if ECC rejects a request as it cannot decrypt the key transport message
+var staleKeyErr = &fpccontract.StaleKeyError{Err: fmt.Errorf("decryption of key transport message failed")}
+
+func TestContractCache(t *testing.T) {
+	chaincodeID := "myChaincode"
+	chaincodeEk, _, err := crypto.GetDefaultCSP().NewRSAKeys()
+	require.NoError(t, err)
+
+	type fixture struct {
+		contract *fakes.Contract
+		ercc     *fakes.Contract
+		invokeTx *fakes.Transaction
+		sequence int64
+		evaluate func() string
+		fpc      interface{ InvalidateCache() }
+	}
+
+	setup := func(opts ...fpccontract.Option) *fixture {
+		f := &fixture{sequence: 1}
+		invokeTx := &fakes.Transaction{}
+		invokeTx.EvaluateReturns(nil, fmt.Errorf("someError"))
+		mockContract := &fakes.Contract{}
+		mockContract.NameReturns(chaincodeID)
+		mockContract.CreateTransactionReturns(invokeTx, nil)
+
+		mockERCC := &fakes.Contract{}
+		mockERCC.EvaluateTransactionCalls(func(_ context.Context, name string, args ...string) ([]byte, error) {
+			switch name {
+			case "queryChaincodeEncryptionKey":
+				return []byte(base64.StdEncoding.EncodeToString(chaincodeEk)), nil
+			case "queryChaincodeEndPoints":
+				return []byte("peer1"), nil
+			}
+			return nil, fmt.Errorf("unexpected transaction %s", name)
+		})
+
+		mockLifecycle := &fakes.Contract{}
+		mockLifecycle.EvaluateTransactionCalls(func(_ context.Context, name string, args ...string) ([]byte, error) {
+			return protoutil.Marshal(&lifecycle.QueryChaincodeDefinitionResult{Sequence: f.sequence})
+		})
+
+		mockProvider := &fakes.ContractProvider{}
+		mockProvider.GetContractCalls(func(id string) fpccontract.Contract {
+			switch id {
+			case "ercc":
+				return mockERCC
+			case "_lifecycle":
+				return mockLifecycle
+			}
+			return mockContract
+		})
+
+		contract := fpccontract.GetContract(mockProvider, chaincodeID, opts...)
+		f.contract, f.ercc, f.invokeTx, f.fpc = mockContract, mockERCC, invokeTx, contract
+		f.evaluate = func() string {
+			_, err := contract.EvaluateTransaction("someFunction")
+			require.Error(t, err)
+			return err.Error()
+		}
+		return f
+	}
+
+	// the key and the endpoints are queried once
+	f := setup()
+	assert.Equal(t, "someError", f.evaluate())
+	assert.Equal(t, "someError", f.evaluate())
+	assert.Equal(t, 2, f.ercc.EvaluateTransactionCallCount())
+	_, peers := f.contract.CreateTransactionArgsForCall(1)
+	assert.Equal(t, []string{"peer1"}, peers)
+
+	// an enclave rejects the request as it has another key, thus, the cache is invalidated and the request retried
+	f.invokeTx.EvaluateReturnsOnCall(2, nil, staleKeyErr)
+	assert.Equal(t, "someError", f.evaluate())
+	assert.Equal(t, 4, f.invokeTx.EvaluateCallCount())
+	assert.Equal(t, 4, f.ercc.EvaluateTransactionCallCount())
+
+	// the error message is not relevant, only a StaleKeyError invalidates the cache
+	f.invokeTx.EvaluateReturnsOnCall(4, nil, fmt.Errorf("decryption of key transport message failed"))
+	f.evaluate()
+	assert.Equal(t, 5, f.invokeTx.EvaluateCallCount())
+	assert.Equal(t, 4, f.ercc.EvaluateTransactionCallCount())
+
+	// explicit invalidation
+	f.fpc.InvalidateCache()
+	f.evaluate()
+	assert.Equal(t, 6, f.ercc.EvaluateTransactionCallCount())
+
+	// values expire
+	f = setup(fpccontract.WithCacheTTL(time.Millisecond))
+	f.evaluate()
+	time.Sleep(2 * time.Millisecond)
+	f.evaluate()
+	assert.Equal(t, 4, f.ercc.EvaluateTransactionCallCount())
+
+	// caching disabled, a rejected request is not retried
+	f = setup(fpccontract.WithCacheTTL(0))
+	f.invokeTx.EvaluateReturns(nil, staleKeyErr)
+	f.evaluate()
+	f.evaluate()
+	assert.Equal(t, 4, f.ercc.EvaluateTransactionCallCount())
+	assert.Equal(t, 2, f.invokeTx.EvaluateCallCount())
+
+	// the cache is invalidated if the sequence of the chaincode definition changes
+	f = setup(fpccontract.WithSequenceCheckInterval(time.Nanosecond))
+	f.evaluate()
+	f.evaluate()
+	assert.Equal(t, 2, f.ercc.EvaluateTransactionCallCount())
+	f.sequence = 2
+	f.evaluate()
+	assert.Equal(t, 4, f.ercc.EvaluateTransactionCallCount())
+}
+
+func TestContractEndpointFailover(t *testing.T) {
+	txns := make(map[string]*fakes.Transaction)
+	for _, peer := range []string{"peer1", "peer2", "peer3"} {
+		txns[peer] = &fakes.Transaction{}
+		txns[peer].EvaluateReturns([]byte(peer), nil)
+	}
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionCalls(func(name string, peers ...string) (fpccontract.Transaction, error) {
+		require.Len(t, peers, 1)
+		return txns[peers[0]], nil
+	})
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1,peer2,peer3"), nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.RevealCalls(func(input []byte) ([]byte, error) {
+		return asResponseBytes(input), nil
+	})
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	contract := fpccontract.New(mockContract, mockERCC, nil, mockEncryptionProvider)
+
+	// round robin
+	for _, expected := range []string{"peer1", "peer2", "peer3", "peer1"} {
+		resp, err := contract.EvaluateTransaction("someFunction")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(resp))
+	}
+
+	// peer2 is down, the next enclave is tried, and peer2 is tried last afterwards
+	txns["peer2"].EvaluateReturns(nil, fmt.Errorf("connection refused"))
+	resp, err := contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, "peer3", string(resp))
+	calls := txns["peer2"].EvaluateCallCount()
+	for _, expected := range []string{"peer3", "peer1", "peer3"} {
+		resp, err = contract.EvaluateTransaction("someFunction")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(resp))
+	}
+	assert.Equal(t, calls, txns["peer2"].EvaluateCallCount())
+
+	// all enclaves fail
+	txns["peer1"].EvaluateReturns(nil, fmt.Errorf("connection refused"))
+	txns["peer3"].EvaluateReturns(nil, fmt.Errorf("enclave failure"))
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.Error(t, err)
+
+	// an outdated key is not retried at another enclave
+	for _, txn := range txns {
+		txn.EvaluateReturns(nil, staleKeyErr)
+	}
+	calls = mockContract.CreateTransactionCallCount()
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.ErrorContains(t, err, "key transport message")
+	// once more after invalidating the cache
+	assert.Equal(t, calls+2, mockContract.CreateTransactionCallCount())
+
+	// static endpoints
+	txns["peer1"].EvaluateReturns([]byte("peer1"), nil)
+	contract = fpccontract.New(mockContract, mockERCC, []string{"peer2", "peer1"}, mockEncryptionProvider)
+	resp, err = contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, "peer1", string(resp))
+}
+
+func TestContractEndpointVerification(t *testing.T) {
+	chaincodeID := "myChaincode"
+	mrenclave := "98aed61c91f258a37c68ed4943297695647ec7bbe6008cc111b0a12650ebeb91"
+	otherMrenclave := "0000000000000000000000000000000000000000000000000000000000000000"
+	chaincodeEk, _, err := crypto.GetDefaultCSP().NewRSAKeys()
+	require.NoError(t, err)
+
+	credentials, err := json.Marshal([]string{
+		newHostCredentials(t, chaincodeID, mrenclave, []byte("enclave1"), chaincodeEk, &protos.HostParameters{PeerEndpoint: "peer1", PeerMspId: "Org1MSP"}),
+		newHostCredentials(t, chaincodeID, otherMrenclave, []byte("enclave2"), chaincodeEk, &protos.HostParameters{PeerEndpoint: "peer2", PeerMspId: "Org2MSP"}),
+		newHostCredentials(t, chaincodeID, mrenclave, []byte("enclave3"), chaincodeEk, &protos.HostParameters{PeerEndpoint: "peer3", PeerMspId: "Org3MSP"}),
+	})
+	require.NoError(t, err)
+
+	invokeTx := &fakes.Transaction{}
+	invokeTx.EvaluateReturns(nil, fmt.Errorf("someError"))
+	mockContract := &fakes.Contract{}
+	mockContract.NameReturns(chaincodeID)
+	mockContract.CreateTransactionReturns(invokeTx, nil)
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionCalls(func(_ context.Context, name string, args ...string) ([]byte, error) {
+		if name == "QueryListEnclaveCredentials" {
+			return credentials, nil
+		}
+		return []byte("peer1,peer2,peer3"), nil
+	})
+	mockProvider := &fakes.ContractProvider{}
+	mockProvider.GetContractCalls(func(id string) fpccontract.Contract {
+		if id == "ercc" {
+			return mockERCC
+		}
+		return mockContract
+	})
+
+	// the endpoint of the enclave with invalid credentials is skipped, and Org3 is preferred
+	contract := fpccontract.GetContract(mockProvider, chaincodeID,
+		fpccontract.WithCredentialVerification(attestation.NewCredentialVerifier(simulation.NewSimulationVerifier())),
+		fpccontract.WithExpectedMrenclave(mrenclave),
+		fpccontract.WithEndpointSelector(fpccontract.NewOrgPreferenceSelector(fpccontract.NewRoundRobinSelector(), "Org3MSP")))
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.EqualError(t, err, "someError")
+	require.Equal(t, 2, mockContract.CreateTransactionCallCount())
+	for i, expected := range []string{"peer3", "peer1"} {
+		_, peers := mockContract.CreateTransactionArgsForCall(i)
+		assert.Equal(t, []string{expected}, peers)
+	}
+}
+
+func TestEndpointSelectors(t *testing.T) {
+	endpoints := []fpccontract.Endpoint{
+		{Address: "peer1", MspId: "Org1MSP"},
+		{Address: "peer2", MspId: "Org2MSP"},
+		{Address: "peer3", MspId: "Org1MSP"},
+	}
+	addresses := func(endpoints []fpccontract.Endpoint) []string {
+		var l []string
+		for _, e := range endpoints {
+			l = append(l, e.Address)
+		}
+		return l
+	}
+
+	rr := fpccontract.NewRoundRobinSelector()
+	assert.Equal(t, []string{"peer1", "peer2", "peer3"}, addresses(rr.Select(endpoints)))
+	assert.Equal(t, []string{"peer2", "peer3", "peer1"}, addresses(rr.Select(endpoints)))
+	assert.Empty(t, rr.Select(nil))
+
+	// endpoints without measurement first, failures are ignored
+	ll := fpccontract.NewLeastLatencySelector()
+	ll.Report(endpoints[0], 30*time.Millisecond, nil)
+	ll.Report(endpoints[1], 10*time.Millisecond, nil)
+	ll.Report(endpoints[1], time.Millisecond, fmt.Errorf("someError"))
+	assert.Equal(t, []string{"peer3", "peer2", "peer1"}, addresses(ll.Select(endpoints)))
+	ll.Report(endpoints[2], 100*time.Millisecond, nil)
+	assert.Equal(t, []string{"peer2", "peer1", "peer3"}, addresses(ll.Select(endpoints)))
+	// moving average
+	for i := 0; i < 20; i++ {
+		ll.Report(endpoints[2], time.Millisecond, nil)
+	}
+	assert.Equal(t, []string{"peer3", "peer2", "peer1"}, addresses(ll.Select(endpoints)))
+
+	org := fpccontract.NewOrgPreferenceSelector(fpccontract.NewRoundRobinSelector(), "Org2MSP", "Org1MSP")
+	assert.Equal(t, []string{"peer2", "peer1", "peer3"}, addresses(org.Select(endpoints)))
+	assert.Equal(t, []string{"peer2", "peer3", "peer1"}, addresses(org.Select(endpoints)))
 }
 
 func asResponseBytes(input []byte) []byte {
@@ -0,0 +1,263 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// WithCredentialVerification enables the verification of the enclave credentials registered at the enclave registry
+// before a request is encrypted. Instead of trusting the chaincode encryption key returned by a single peer, the
+// client queries all credentials of the chaincode, verifies their attestation evidence with the given verifier, e.g.,
+// attestation.NewDefaultCredentialVerifier(), and takes the chaincode encryption key from the verified attested data.
+// Simulated credentials are only accepted with attestation.NewCredentialVerifierWithSimulation.
+// Responses are only accepted if they are signed by one of the verified enclaves and refer to the request sent.
+// The expected mrenclave is the version of the committed chaincode definition, unless set with WithExpectedMrenclave.
+// This option only applies to contracts created with GetContract.
+func WithCredentialVerification(verifier attestation.Verifier) Option {
+	return func(c *contractImpl) {
+		c.verifier = verifier
+	}
+}
+
+// WithExpectedMrenclave pins the mrenclave expected in the enclave credentials, instead of querying the committed
+// chaincode definition; requires WithCredentialVerification
+func WithExpectedMrenclave(mrenclave string) Option {
+	return func(c *contractImpl) {
+		c.expectedMrenclave = mrenclave
+	}
+}
+
+// WithCrossCheckPeers queries the enclave credentials and the chaincode definition at each of the given peers, and
+// fails if the peers return different results; requires WithCredentialVerification
+func WithCrossCheckPeers(peerEndpoints ...string) Option {
+	return func(c *contractImpl) {
+		c.crossCheckPeers = peerEndpoints
+	}
+}
+
+// getCcEncryptionKey returns the base64-encoded chaincode encryption key as returned by the enclave registry
+func (c *contractImpl) getCcEncryptionKey(ctx context.Context) ([]byte, error) {
+	key, err := c.cached(ctx, cacheKeyCcEncryptionKey, func(ctx context.Context) (any, error) {
+		return c.ercc.EvaluateTransaction(ctx, "queryChaincodeEncryptionKey", c.Name())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key.([]byte), nil
+}
+
+// getVerifiedKeys verifies all enclave credentials registered for the chaincode and returns the chaincode encryption
+// key they attest and the enclave verification keys
+func (c *contractImpl) getVerifiedKeys(ctx context.Context) (*crypto.VerifiedKeys, error) {
+	keys, err := c.cached(ctx, cacheKeyVerifiedKeys, func(ctx context.Context) (any, error) {
+		return c.verifyEnclaves(ctx)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "enclave credential verification failed")
+	}
+	return keys.(*crypto.VerifiedKeys), nil
+}
+
+func (c *contractImpl) verifyEnclaves(ctx context.Context) (*crypto.VerifiedKeys, error) {
+	mrenclave, err := c.getExpectedMrenclave(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialsList, err := c.queryEnclaveCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentialsList) == 0 {
+		return nil, fmt.Errorf("no enclave registered for chaincode '%s'", c.Name())
+	}
+
+	keys := &crypto.VerifiedKeys{EnclaveVerificationKeys: make(map[string][]byte)}
+	hosts := enclaveHosts{verified: make(map[string]string), rejected: make(map[string]bool)}
+	var verificationErr error
+	for _, credentialsBase64 := range credentialsList {
+		attestedData, err := c.verifyCredentials(credentialsBase64, mrenclave)
+		if err != nil {
+			// the enclave is skipped, and so is the endpoint of its peer, unless it hosts another valid enclave
+			logger.Warnf("skipping enclave: %s", err)
+			if attestedData != nil {
+				hosts.rejected[attestedData.GetHostParams().GetPeerEndpoint()] = true
+			}
+			if verificationErr == nil {
+				verificationErr = err
+			}
+			continue
+		}
+
+		if keys.ChaincodeEncryptionKey == nil {
+			keys.ChaincodeEncryptionKey = attestedData.GetChaincodeEk()
+		} else if !bytes.Equal(keys.ChaincodeEncryptionKey, attestedData.GetChaincodeEk()) {
+			return nil, fmt.Errorf("enclaves of chaincode '%s' attest different chaincode encryption keys", c.Name())
+		}
+		keys.EnclaveVerificationKeys[utils.GetEnclaveId(attestedData)] = attestedData.GetEnclaveVk()
+		hosts.verified[attestedData.GetHostParams().GetPeerEndpoint()] = attestedData.GetHostParams().GetPeerMspId()
+	}
+	if len(keys.EnclaveVerificationKeys) == 0 {
+		return nil, errors.Wrapf(verificationErr, "no enclave of chaincode '%s' has valid credentials", c.Name())
+	}
+
+	c.endpointsMutex.Lock()
+	c.enclaveHosts = hosts
+	c.endpointsMutex.Unlock()
+	return keys, nil
+}
+
+// verifyCredentials verifies the attestation evidence of the credentials and checks that the attested data belongs
+// to an enclave of this chaincode with the expected mrenclave. If the verification fails, the attested data is returned
+// along with the error, unless it cannot be decoded.
+func (c *contractImpl) verifyCredentials(credentialsBase64, mrenclave string) (*protos.AttestedData, error) {
+	credentials, err := utils.UnmarshalCredentials(credentialsBase64)
+	if err != nil {
+		return nil, err
+	}
+
+	attestedData, err := utils.UnmarshalAttestedData(credentials.GetSerializedAttestedData())
+	if err != nil {
+		return nil, err
+	}
+	enclaveId := utils.GetEnclaveId(attestedData)
+
+	if attestedData.GetCcParams().GetChaincodeId() != c.Name() {
+		return attestedData, fmt.Errorf("enclave %s is registered for chaincode '%s'", enclaveId, attestedData.GetCcParams().GetChaincodeId())
+	}
+	if attestedData.GetCcParams().GetVersion() != mrenclave {
+		return attestedData, fmt.Errorf("enclave %s has mrenclave %s, expected %s", enclaveId, attestedData.GetCcParams().GetVersion(), mrenclave)
+	}
+	if len(attestedData.GetChaincodeEk()) == 0 {
+		return attestedData, fmt.Errorf("enclave %s attests no chaincode encryption key", enclaveId)
+	}
+	if len(attestedData.GetEnclaveVk()) == 0 {
+		return attestedData, fmt.Errorf("enclave %s attests no enclave verification key", enclaveId)
+	}
+
+	if err := c.verifier.VerifyCredentials(credentials, mrenclave, time.Now()); err != nil {
+		return attestedData, errors.Wrapf(err, "invalid credentials of enclave %s", enclaveId)
+	}
+
+	logger.Debugf("verified credentials of enclave %s", enclaveId)
+	return attestedData, nil
+}
+
+// getExpectedMrenclave returns the pinned mrenclave or the version of the committed chaincode definition
+func (c *contractImpl) getExpectedMrenclave(ctx context.Context) (string, error) {
+	if len(c.expectedMrenclave) > 0 {
+		return c.expectedMrenclave, nil
+	}
+	if c.lifecycle == nil {
+		return "", fmt.Errorf("no expected mrenclave given and no lifecycle contract to query the chaincode definition")
+	}
+
+	// note that we use Fabric's Marshall as it still uses protobuf V1
+	argsBytes, err := protoutil.Marshal(&lifecycle.QueryChaincodeDefinitionArgs{Name: c.Name()})
+	if err != nil {
+		return "", err
+	}
+
+	responses, err := c.query(ctx, c.lifecycle, "QueryChaincodeDefinition", string(argsBytes))
+	if err != nil {
+		return "", errors.Wrap(err, "cannot query chaincode definition")
+	}
+
+	// note that the serialized definitions are not compared, as the approvals map is not serialized deterministically
+	var mrenclave string
+	for i, resp := range responses {
+		ccDef, err := utils.UnmarshalQueryChaincodeDefinitionResult(resp)
+		if err != nil {
+			return "", err
+		}
+		m, err := utils.ExtractMrEnclave(ccDef)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 && m != mrenclave {
+			return "", fmt.Errorf("peers return different chaincode definitions for chaincode '%s'", c.Name())
+		}
+		mrenclave = m
+	}
+	return mrenclave, nil
+}
+
+// queryEnclaveCredentials returns the (base64-encoded) credentials of all enclaves registered for the chaincode
+func (c *contractImpl) queryEnclaveCredentials(ctx context.Context) ([]string, error) {
+	responses, err := c.query(ctx, c.ercc, "QueryListEnclaveCredentials", c.Name())
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot query enclave credentials")
+	}
+
+	var credentialsList []string
+	for i, resp := range responses {
+		var l []string
+		if len(resp) > 0 {
+			if err := json.Unmarshal(resp, &l); err != nil {
+				return nil, errors.Wrap(err, "cannot unmarshal enclave credentials")
+			}
+		}
+		sort.Strings(l)
+		if i > 0 && !equalStrings(l, credentialsList) {
+			return nil, fmt.Errorf("peers return different enclave credentials for chaincode '%s'", c.Name())
+		}
+		credentialsList = l
+	}
+	return credentialsList, nil
+}
+
+// query evaluates a transaction at each cross-check peer or, if none are configured, once at the peers chosen by the
+// contract
+func (c *contractImpl) query(ctx context.Context, contract Contract, name string, args ...string) ([][]byte, error) {
+	if len(c.crossCheckPeers) == 0 {
+		resp, err := contract.EvaluateTransaction(ctx, name, args...)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{resp}, nil
+	}
+
+	var responses [][]byte
+	for _, p := range c.crossCheckPeers {
+		txn, err := contract.CreateTransaction(name, p)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := txn.Evaluate(ctx, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "query at peer %s failed", p)
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
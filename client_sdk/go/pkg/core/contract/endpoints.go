@@ -0,0 +1,251 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// endpointCooldown is the time an endpoint is tried only after all others, once an invocation at it has failed
+const endpointCooldown = 30 * time.Second
+
+// Endpoint is a peer endpoint hosting an enclave of the chaincode
+type Endpoint struct {
+	// Address has the format `host:port`
+	Address string
+	// MspId is the MSP ID of the peer hosting the enclave; it is taken from the enclave credentials, thus, it is only
+	// known if the credentials are verified, see WithCredentialVerification
+	MspId string
+}
+
+// EndpointSelector selects the order in which the enclave endpoints are tried for an invocation. The first endpoint
+// is tried first, the others in turn if the invocation fails. Implementations must be safe for concurrent use.
+type EndpointSelector interface {
+	// Select returns the endpoints in the order they are tried
+	Select(endpoints []Endpoint) []Endpoint
+	// Report is called with the latency and the error of each invocation at an endpoint
+	Report(endpoint Endpoint, latency time.Duration, err error)
+}
+
+// WithEndpointSelector sets the strategy to select the enclave endpoints, e.g., NewLeastLatencySelector(); the
+// default is NewRoundRobinSelector()
+func WithEndpointSelector(selector EndpointSelector) Option {
+	return func(c *contractImpl) {
+		c.selector = selector
+	}
+}
+
+type roundRobinSelector struct {
+	next uint64
+}
+
+// NewRoundRobinSelector returns a selector that distributes the invocations evenly among the endpoints
+func NewRoundRobinSelector() EndpointSelector {
+	return &roundRobinSelector{}
+}
+
+func (s *roundRobinSelector) Select(endpoints []Endpoint) []Endpoint {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	start := int((atomic.AddUint64(&s.next, 1) - 1) % uint64(len(endpoints)))
+	return append(append([]Endpoint{}, endpoints[start:]...), endpoints[:start]...)
+}
+
+func (s *roundRobinSelector) Report(Endpoint, time.Duration, error) {}
+
+// latencyWeight is the weight of a new measurement in the moving average of the latency of an endpoint
+const latencyWeight = 0.2
+
+type leastLatencySelector struct {
+	mutex     sync.Mutex
+	latencies map[string]time.Duration
+}
+
+// NewLeastLatencySelector returns a selector that prefers the endpoint with the lowest average latency of successful
+// invocations; endpoints without measurement are tried first
+func NewLeastLatencySelector() EndpointSelector {
+	return &leastLatencySelector{latencies: make(map[string]time.Duration)}
+}
+
+func (s *leastLatencySelector) Select(endpoints []Endpoint) []Endpoint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	selected := append([]Endpoint{}, endpoints...)
+	sort.SliceStable(selected, func(i, j int) bool {
+		return s.latencies[selected[i].Address] < s.latencies[selected[j].Address]
+	})
+	return selected
+}
+
+func (s *leastLatencySelector) Report(endpoint Endpoint, latency time.Duration, err error) {
+	if err != nil {
+		// failed endpoints are tried last anyway, see endpointCooldown
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if l, ok := s.latencies[endpoint.Address]; ok {
+		latency = time.Duration((1-latencyWeight)*float64(l) + latencyWeight*float64(latency))
+	}
+	s.latencies[endpoint.Address] = latency
+}
+
+type orgPreferenceSelector struct {
+	next   EndpointSelector
+	mspIds []string
+}
+
+// NewOrgPreferenceSelector returns a selector that prefers the endpoints of the given organizations, in the given
+// order, over the endpoints of other organizations; the endpoints of each organization are ordered by next.
+// Note that the organization of an endpoint is only known if the credentials are verified.
+func NewOrgPreferenceSelector(next EndpointSelector, mspIds ...string) EndpointSelector {
+	return &orgPreferenceSelector{next: next, mspIds: mspIds}
+}
+
+func (s *orgPreferenceSelector) Select(endpoints []Endpoint) []Endpoint {
+	rank := func(e Endpoint) int {
+		for i, mspId := range s.mspIds {
+			if e.MspId == mspId {
+				return i
+			}
+		}
+		return len(s.mspIds)
+	}
+
+	selected := s.next.Select(endpoints)
+	sort.SliceStable(selected, func(i, j int) bool {
+		return rank(selected[i]) < rank(selected[j])
+	})
+	return selected
+}
+
+func (s *orgPreferenceSelector) Report(endpoint Endpoint, latency time.Duration, err error) {
+	s.next.Report(endpoint, latency, err)
+}
+
+// enclaveHosts are the endpoints of the enclaves whose credentials were verified last, see verifyEnclaves
+type enclaveHosts struct {
+	// verified maps the endpoints of enclaves with valid credentials to the MSP ID of their peer
+	verified map[string]string
+	// rejected are the endpoints of enclaves with invalid credentials
+	rejected map[string]bool
+}
+
+// getPeerEndpoints returns the peer endpoints that host the FPC chaincode enclave, without the endpoints that only
+// host enclaves with invalid credentials
+func (c *contractImpl) getPeerEndpoints(ctx context.Context) ([]Endpoint, error) {
+	addresses := c.peerEndpoints
+	if len(addresses) == 0 {
+		peers, err := c.cached(ctx, cacheKeyPeerEndpoints, func(ctx context.Context) (any, error) {
+			resp, err := c.ercc.EvaluateTransaction(ctx, "queryChaincodeEndPoints", c.Name())
+			if err != nil {
+				return nil, err
+			}
+			return strings.Split(string(resp), ","), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		addresses = peers.([]string)
+	}
+
+	c.endpointsMutex.Lock()
+	hosts := c.enclaveHosts
+	c.endpointsMutex.Unlock()
+
+	var endpoints []Endpoint
+	for _, address := range addresses {
+		mspId, verified := hosts.verified[address]
+		if hosts.rejected[address] && !verified {
+			logger.Debugf("skipping endpoint %s, its enclave credentials are invalid", address)
+			continue
+		}
+		endpoints = append(endpoints, Endpoint{Address: address, MspId: mspId})
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoint with a valid enclave for chaincode '%s'", c.Name())
+	}
+	return endpoints, nil
+}
+
+// orderEndpoints returns the endpoints in the order chosen by the selector, but with the endpoints that failed
+// recently last
+func (c *contractImpl) orderEndpoints(endpoints []Endpoint) []Endpoint {
+	selected := c.selector.Select(endpoints)
+
+	c.endpointsMutex.Lock()
+	defer c.endpointsMutex.Unlock()
+	failed := func(e Endpoint) bool {
+		t, ok := c.failedEndpoints[e.Address]
+		return ok && time.Since(t) < endpointCooldown
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return !failed(selected[i]) && failed(selected[j])
+	})
+	return selected
+}
+
+func (c *contractImpl) reportEndpoint(endpoint Endpoint, latency time.Duration, err error) {
+	c.selector.Report(endpoint, latency, err)
+
+	c.endpointsMutex.Lock()
+	defer c.endpointsMutex.Unlock()
+	if err == nil {
+		delete(c.failedEndpoints, endpoint.Address)
+		return
+	}
+	if c.failedEndpoints == nil {
+		c.failedEndpoints = make(map[string]time.Time)
+	}
+	c.failedEndpoints[endpoint.Address] = time.Now()
+}
+
+// evaluateTransaction calls __invoke at the enclave endpoints in the order chosen by the selector until it succeeds
+func (c *contractImpl) evaluateTransaction(ctx context.Context, args ...string) ([]byte, error) {
+	endpoints, err := c.getPeerEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, endpoint := range c.orderEndpoints(endpoints) {
+		var resp []byte
+		resp, err = c.invoke(ctx, endpoint, args...)
+		if err == nil || ctx.Err() != nil || isStaleKeyError(err) {
+			// an outdated key is not fixed by another enclave, see invalidateOnStaleKey
+			return resp, err
+		}
+		logger.Warnf("__invoke at %s failed, trying next enclave: %s", endpoint.Address, err)
+	}
+	return nil, err
+}
+
+func (c *contractImpl) invoke(ctx context.Context, endpoint Endpoint, args ...string) ([]byte, error) {
+	txn, err := c.target.CreateTransaction(
+		"__invoke",
+		endpoint.Address,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debugf("calling __invoke at %s!", endpoint.Address)
+	start := time.Now()
+	resp, err := txn.Evaluate(ctx, args...)
+	if ctx.Err() == nil && (err == nil || !isStaleKeyError(err)) {
+		c.reportEndpoint(endpoint, time.Since(start), err)
+	}
+	return resp, err
+}
@@ -0,0 +1,37 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package contract
+
+// StaleReadError is returned by Contract.SubmitTransaction if the transaction failed due to a stale read, either
+// detected by `__endorse` (see endorsement.StaleReadStatus) or by Fabric's MVCC check at commit time. Such a
+// transaction is re-executed, see WithStaleReadRetries.
+type StaleReadError struct {
+	Err error
+}
+
+func (e *StaleReadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StaleReadError) Unwrap() error {
+	return e.Err
+}
+
+// StaleKeyError is returned by the Contract a FPC contract is based on if ECC rejected a request with
+// endorsement.StaleKeyStatus, i.e., the request was prepared with outdated chaincode or enclave keys. In this case,
+// the cached values are invalidated, see WithCacheTTL.
+type StaleKeyError struct {
+	Err error
+}
+
+func (e *StaleKeyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StaleKeyError) Unwrap() error {
+	return e.Err
+}
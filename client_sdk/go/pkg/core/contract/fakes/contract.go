@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract"
@@ -22,11 +23,12 @@ type Contract struct {
 		result1 contract.Transaction
 		result2 error
 	}
-	EvaluateTransactionStub        func(string, ...string) ([]byte, error)
+	EvaluateTransactionStub        func(context.Context, string, ...string) ([]byte, error)
 	evaluateTransactionMutex       sync.RWMutex
 	evaluateTransactionArgsForCall []struct {
-		arg1 string
-		arg2 []string
+		arg1 context.Context
+		arg2 string
+		arg3 []string
 	}
 	evaluateTransactionReturns struct {
 		result1 []byte
@@ -46,11 +48,12 @@ type Contract struct {
 	nameReturnsOnCall map[int]struct {
 		result1 string
 	}
-	SubmitTransactionStub        func(string, ...string) ([]byte, error)
+	SubmitTransactionStub        func(context.Context, string, ...string) ([]byte, error)
 	submitTransactionMutex       sync.RWMutex
 	submitTransactionArgsForCall []struct {
-		arg1 string
-		arg2 []string
+		arg1 context.Context
+		arg2 string
+		arg3 []string
 	}
 	submitTransactionReturns struct {
 		result1 []byte
@@ -129,19 +132,20 @@ func (fake *Contract) CreateTransactionReturnsOnCall(i int, result1 contract.Tra
 	}{result1, result2}
 }
 
-func (fake *Contract) EvaluateTransaction(arg1 string, arg2 ...string) ([]byte, error) {
+func (fake *Contract) EvaluateTransaction(arg1 context.Context, arg2 string, arg3 ...string) ([]byte, error) {
 	fake.evaluateTransactionMutex.Lock()
 	ret, specificReturn := fake.evaluateTransactionReturnsOnCall[len(fake.evaluateTransactionArgsForCall)]
 	fake.evaluateTransactionArgsForCall = append(fake.evaluateTransactionArgsForCall, struct {
-		arg1 string
-		arg2 []string
-	}{arg1, arg2})
+		arg1 context.Context
+		arg2 string
+		arg3 []string
+	}{arg1, arg2, arg3})
 	stub := fake.EvaluateTransactionStub
 	fakeReturns := fake.evaluateTransactionReturns
-	fake.recordInvocation("EvaluateTransaction", []interface{}{arg1, arg2})
+	fake.recordInvocation("EvaluateTransaction", []interface{}{arg1, arg2, arg3})
 	fake.evaluateTransactionMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2...)
+		return stub(arg1, arg2, arg3...)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -155,17 +159,17 @@ func (fake *Contract) EvaluateTransactionCallCount() int {
 	return len(fake.evaluateTransactionArgsForCall)
 }
 
-func (fake *Contract) EvaluateTransactionCalls(stub func(string, ...string) ([]byte, error)) {
+func (fake *Contract) EvaluateTransactionCalls(stub func(context.Context, string, ...string) ([]byte, error)) {
 	fake.evaluateTransactionMutex.Lock()
 	defer fake.evaluateTransactionMutex.Unlock()
 	fake.EvaluateTransactionStub = stub
 }
 
-func (fake *Contract) EvaluateTransactionArgsForCall(i int) (string, []string) {
+func (fake *Contract) EvaluateTransactionArgsForCall(i int) (context.Context, string, []string) {
 	fake.evaluateTransactionMutex.RLock()
 	defer fake.evaluateTransactionMutex.RUnlock()
 	argsForCall := fake.evaluateTransactionArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *Contract) EvaluateTransactionReturns(result1 []byte, result2 error) {
@@ -247,19 +251,20 @@ func (fake *Contract) NameReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
-func (fake *Contract) SubmitTransaction(arg1 string, arg2 ...string) ([]byte, error) {
+func (fake *Contract) SubmitTransaction(arg1 context.Context, arg2 string, arg3 ...string) ([]byte, error) {
 	fake.submitTransactionMutex.Lock()
 	ret, specificReturn := fake.submitTransactionReturnsOnCall[len(fake.submitTransactionArgsForCall)]
 	fake.submitTransactionArgsForCall = append(fake.submitTransactionArgsForCall, struct {
-		arg1 string
-		arg2 []string
-	}{arg1, arg2})
+		arg1 context.Context
+		arg2 string
+		arg3 []string
+	}{arg1, arg2, arg3})
 	stub := fake.SubmitTransactionStub
 	fakeReturns := fake.submitTransactionReturns
-	fake.recordInvocation("SubmitTransaction", []interface{}{arg1, arg2})
+	fake.recordInvocation("SubmitTransaction", []interface{}{arg1, arg2, arg3})
 	fake.submitTransactionMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2...)
+		return stub(arg1, arg2, arg3...)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -273,17 +278,17 @@ func (fake *Contract) SubmitTransactionCallCount() int {
 	return len(fake.submitTransactionArgsForCall)
 }
 
-func (fake *Contract) SubmitTransactionCalls(stub func(string, ...string) ([]byte, error)) {
+func (fake *Contract) SubmitTransactionCalls(stub func(context.Context, string, ...string) ([]byte, error)) {
 	fake.submitTransactionMutex.Lock()
 	defer fake.submitTransactionMutex.Unlock()
 	fake.SubmitTransactionStub = stub
 }
 
-func (fake *Contract) SubmitTransactionArgsForCall(i int) (string, []string) {
+func (fake *Contract) SubmitTransactionArgsForCall(i int) (context.Context, string, []string) {
 	fake.submitTransactionMutex.RLock()
 	defer fake.submitTransactionMutex.RUnlock()
 	argsForCall := fake.submitTransactionArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *Contract) SubmitTransactionReturns(result1 []byte, result2 error) {
@@ -20,6 +20,20 @@ type EncryptionContext struct {
 		result1 string
 		result2 error
 	}
+	ConcealBatchStub        func([][]string, bool) (string, error)
+	concealBatchMutex       sync.RWMutex
+	concealBatchArgsForCall []struct {
+		arg1 [][]string
+		arg2 bool
+	}
+	concealBatchReturns struct {
+		result1 string
+		result2 error
+	}
+	concealBatchReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	RevealStub        func([]byte) ([]byte, error)
 	revealMutex       sync.RWMutex
 	revealArgsForCall []struct {
@@ -33,6 +47,17 @@ type EncryptionContext struct {
 		result1 []byte
 		result2 error
 	}
+	VerifyStub        func([]byte) error
+	verifyMutex       sync.RWMutex
+	verifyArgsForCall []struct {
+		arg1 []byte
+	}
+	verifyReturns struct {
+		result1 error
+	}
+	verifyReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -107,6 +132,76 @@ func (fake *EncryptionContext) ConcealReturnsOnCall(i int, result1 string, resul
 	}{result1, result2}
 }
 
+func (fake *EncryptionContext) ConcealBatch(arg1 [][]string, arg2 bool) (string, error) {
+	var arg1Copy [][]string
+	if arg1 != nil {
+		arg1Copy = make([][]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.concealBatchMutex.Lock()
+	ret, specificReturn := fake.concealBatchReturnsOnCall[len(fake.concealBatchArgsForCall)]
+	fake.concealBatchArgsForCall = append(fake.concealBatchArgsForCall, struct {
+		arg1 [][]string
+		arg2 bool
+	}{arg1Copy, arg2})
+	stub := fake.ConcealBatchStub
+	fakeReturns := fake.concealBatchReturns
+	fake.recordInvocation("ConcealBatch", []interface{}{arg1Copy, arg2})
+	fake.concealBatchMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *EncryptionContext) ConcealBatchCallCount() int {
+	fake.concealBatchMutex.RLock()
+	defer fake.concealBatchMutex.RUnlock()
+	return len(fake.concealBatchArgsForCall)
+}
+
+func (fake *EncryptionContext) ConcealBatchCalls(stub func([][]string, bool) (string, error)) {
+	fake.concealBatchMutex.Lock()
+	defer fake.concealBatchMutex.Unlock()
+	fake.ConcealBatchStub = stub
+}
+
+func (fake *EncryptionContext) ConcealBatchArgsForCall(i int) ([][]string, bool) {
+	fake.concealBatchMutex.RLock()
+	defer fake.concealBatchMutex.RUnlock()
+	argsForCall := fake.concealBatchArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *EncryptionContext) ConcealBatchReturns(result1 string, result2 error) {
+	fake.concealBatchMutex.Lock()
+	defer fake.concealBatchMutex.Unlock()
+	fake.ConcealBatchStub = nil
+	fake.concealBatchReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *EncryptionContext) ConcealBatchReturnsOnCall(i int, result1 string, result2 error) {
+	fake.concealBatchMutex.Lock()
+	defer fake.concealBatchMutex.Unlock()
+	fake.ConcealBatchStub = nil
+	if fake.concealBatchReturnsOnCall == nil {
+		fake.concealBatchReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.concealBatchReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *EncryptionContext) Reveal(arg1 []byte) ([]byte, error) {
 	var arg1Copy []byte
 	if arg1 != nil {
@@ -176,13 +271,83 @@ func (fake *EncryptionContext) RevealReturnsOnCall(i int, result1 []byte, result
 	}{result1, result2}
 }
 
+func (fake *EncryptionContext) Verify(arg1 []byte) error {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.verifyMutex.Lock()
+	ret, specificReturn := fake.verifyReturnsOnCall[len(fake.verifyArgsForCall)]
+	fake.verifyArgsForCall = append(fake.verifyArgsForCall, struct {
+		arg1 []byte
+	}{arg1Copy})
+	stub := fake.VerifyStub
+	fakeReturns := fake.verifyReturns
+	fake.recordInvocation("Verify", []interface{}{arg1Copy})
+	fake.verifyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *EncryptionContext) VerifyCallCount() int {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	return len(fake.verifyArgsForCall)
+}
+
+func (fake *EncryptionContext) VerifyCalls(stub func([]byte) error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = stub
+}
+
+func (fake *EncryptionContext) VerifyArgsForCall(i int) []byte {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	argsForCall := fake.verifyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *EncryptionContext) VerifyReturns(result1 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	fake.verifyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *EncryptionContext) VerifyReturnsOnCall(i int, result1 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	if fake.verifyReturnsOnCall == nil {
+		fake.verifyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.verifyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *EncryptionContext) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.concealMutex.RLock()
 	defer fake.concealMutex.RUnlock()
+	fake.concealBatchMutex.RLock()
+	defer fake.concealBatchMutex.RUnlock()
 	fake.revealMutex.RLock()
 	defer fake.revealMutex.RUnlock()
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -2,15 +2,17 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
 )
 
 type EncryptionProvider struct {
-	NewEncryptionContextStub        func() (crypto.EncryptionContext, error)
+	NewEncryptionContextStub        func(context.Context) (crypto.EncryptionContext, error)
 	newEncryptionContextMutex       sync.RWMutex
 	newEncryptionContextArgsForCall []struct {
+		arg1 context.Context
 	}
 	newEncryptionContextReturns struct {
 		result1 crypto.EncryptionContext
@@ -24,17 +26,18 @@ type EncryptionProvider struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *EncryptionProvider) NewEncryptionContext() (crypto.EncryptionContext, error) {
+func (fake *EncryptionProvider) NewEncryptionContext(arg1 context.Context) (crypto.EncryptionContext, error) {
 	fake.newEncryptionContextMutex.Lock()
 	ret, specificReturn := fake.newEncryptionContextReturnsOnCall[len(fake.newEncryptionContextArgsForCall)]
 	fake.newEncryptionContextArgsForCall = append(fake.newEncryptionContextArgsForCall, struct {
-	}{})
+		arg1 context.Context
+	}{arg1})
 	stub := fake.NewEncryptionContextStub
 	fakeReturns := fake.newEncryptionContextReturns
-	fake.recordInvocation("NewEncryptionContext", []interface{}{})
+	fake.recordInvocation("NewEncryptionContext", []interface{}{arg1})
 	fake.newEncryptionContextMutex.Unlock()
 	if stub != nil {
-		return stub()
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -48,12 +51,19 @@ func (fake *EncryptionProvider) NewEncryptionContextCallCount() int {
 	return len(fake.newEncryptionContextArgsForCall)
 }
 
-func (fake *EncryptionProvider) NewEncryptionContextCalls(stub func() (crypto.EncryptionContext, error)) {
+func (fake *EncryptionProvider) NewEncryptionContextCalls(stub func(context.Context) (crypto.EncryptionContext, error)) {
 	fake.newEncryptionContextMutex.Lock()
 	defer fake.newEncryptionContextMutex.Unlock()
 	fake.NewEncryptionContextStub = stub
 }
 
+func (fake *EncryptionProvider) NewEncryptionContextArgsForCall(i int) context.Context {
+	fake.newEncryptionContextMutex.RLock()
+	defer fake.newEncryptionContextMutex.RUnlock()
+	argsForCall := fake.newEncryptionContextArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *EncryptionProvider) NewEncryptionContextReturns(result1 crypto.EncryptionContext, result2 error) {
 	fake.newEncryptionContextMutex.Lock()
 	defer fake.newEncryptionContextMutex.Unlock()
@@ -2,14 +2,16 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 )
 
 type Transaction struct {
-	EvaluateStub        func(...string) ([]byte, error)
+	EvaluateStub        func(context.Context, ...string) ([]byte, error)
 	evaluateMutex       sync.RWMutex
 	evaluateArgsForCall []struct {
-		arg1 []string
+		arg1 context.Context
+		arg2 []string
 	}
 	evaluateReturns struct {
 		result1 []byte
@@ -23,18 +25,19 @@ type Transaction struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *Transaction) Evaluate(arg1 ...string) ([]byte, error) {
+func (fake *Transaction) Evaluate(arg1 context.Context, arg2 ...string) ([]byte, error) {
 	fake.evaluateMutex.Lock()
 	ret, specificReturn := fake.evaluateReturnsOnCall[len(fake.evaluateArgsForCall)]
 	fake.evaluateArgsForCall = append(fake.evaluateArgsForCall, struct {
-		arg1 []string
-	}{arg1})
+		arg1 context.Context
+		arg2 []string
+	}{arg1, arg2})
 	stub := fake.EvaluateStub
 	fakeReturns := fake.evaluateReturns
-	fake.recordInvocation("Evaluate", []interface{}{arg1})
+	fake.recordInvocation("Evaluate", []interface{}{arg1, arg2})
 	fake.evaluateMutex.Unlock()
 	if stub != nil {
-		return stub(arg1...)
+		return stub(arg1, arg2...)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -48,17 +51,17 @@ func (fake *Transaction) EvaluateCallCount() int {
 	return len(fake.evaluateArgsForCall)
 }
 
-func (fake *Transaction) EvaluateCalls(stub func(...string) ([]byte, error)) {
+func (fake *Transaction) EvaluateCalls(stub func(context.Context, ...string) ([]byte, error)) {
 	fake.evaluateMutex.Lock()
 	defer fake.evaluateMutex.Unlock()
 	fake.EvaluateStub = stub
 }
 
-func (fake *Transaction) EvaluateArgsForCall(i int) []string {
+func (fake *Transaction) EvaluateArgsForCall(i int) (context.Context, []string) {
 	fake.evaluateMutex.RLock()
 	defer fake.evaluateMutex.RUnlock()
 	argsForCall := fake.evaluateArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *Transaction) EvaluateReturns(result1 []byte, result2 error) {
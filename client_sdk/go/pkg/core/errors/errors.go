@@ -0,0 +1,50 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package errors provides the errors shared by the FPC client packages.
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ContextError is returned if a call is aborted because its context is done. Use errors.Is with
+// context.DeadlineExceeded or context.Canceled to distinguish a timeout from a cancellation.
+type ContextError struct {
+	// Op is the aborted operation
+	Op string
+	// Err is the error of the context, i.e., context.DeadlineExceeded or context.Canceled
+	Err error
+}
+
+func (e *ContextError) Error() string {
+	return fmt.Sprintf("%s aborted: %s", e.Op, e.Err)
+}
+
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+// IsTimeout returns true if err is caused by an exceeded deadline
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// FromContext returns a ContextError for op if err is not nil and ctx is done, and err otherwise. A ContextError
+// returned by a nested operation is kept.
+func FromContext(ctx context.Context, op string, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	var contextErr *ContextError
+	if errors.As(err, &contextErr) {
+		return err
+	}
+	return &ContextError{Op: op, Err: ctx.Err()}
+}
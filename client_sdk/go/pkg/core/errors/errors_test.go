@@ -0,0 +1,45 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	someErr := fmt.Errorf("some error")
+
+	// errors are kept as long as the context is not done
+	assert.NoError(t, FromContext(context.Background(), "op", nil))
+	assert.Equal(t, someErr, FromContext(context.Background(), "op", someErr))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, FromContext(ctx, "op", nil))
+
+	err := FromContext(ctx, "op", someErr)
+	assert.EqualError(t, err, "op aborted: context canceled")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, IsTimeout(err))
+
+	// the innermost aborted operation is reported
+	wrapped := fmt.Errorf("wrapped: %w", err)
+	assert.Equal(t, wrapped, FromContext(ctx, "outer op", wrapped))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	err = FromContext(ctx, "op", someErr)
+	var contextErr *ContextError
+	assert.ErrorAs(t, err, &contextErr)
+	assert.Equal(t, "op", contextErr.Op)
+	assert.True(t, IsTimeout(err))
+}
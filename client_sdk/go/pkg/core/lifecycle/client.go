@@ -39,9 +39,12 @@ SPDX-License-Identifier: Apache-2.0
 package lifecycle
 
 import (
+	"context"
+
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/pkg/errors"
 
+	fpcerrors "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/errors"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/sgx"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
@@ -49,9 +52,11 @@ import (
 )
 
 const (
-	ERCC               = "ercc"
-	InitEnclaveCMD     = "__initEnclave"
-	RegisterEnclaveCMD = "registerEnclave"
+	ERCC                       = "ercc"
+	InitEnclaveCMD             = "__initEnclave"
+	GetEnclaveIdCMD            = "__getEnclaveId"
+	RegisterEnclaveCMD         = "registerEnclave"
+	QueryEnclaveCredentialsCMD = "queryEnclaveCredentials"
 )
 
 var logger = flogging.MustGetLogger("fpc-client-lifecycle")
@@ -65,14 +70,21 @@ type LifecycleInitEnclaveRequest struct {
 	AttestationParams   *sgx.AttestationParams
 }
 
+// PeerEnclave describes the enclave hosted by a peer, as registered at the enclave registry.
+type PeerEnclave struct {
+	PeerEndpoint string
+	EnclaveId    string
+	Credentials  *protos.Credentials
+}
+
 type CredentialConverter interface {
 	ConvertCredentials(credentialsOnlyAttestation string) (credentialsWithEvidence string, err error)
 }
 
-// ChannelClient models an interface to query and execute chaincodes
+// ChannelClient models an interface to query and execute chaincodes. Implementations must return when ctx is done.
 type ChannelClient interface {
-	Query(chaincodeID string, fcn string, args [][]byte, targetEndpoints ...string) ([]byte, error)
-	Execute(chaincodeID string, fcn string, args [][]byte) (string, error)
+	Query(ctx context.Context, chaincodeID string, fcn string, args [][]byte, targetEndpoints ...string) ([]byte, error)
+	Execute(ctx context.Context, chaincodeID string, fcn string, args [][]byte) (string, error)
 }
 
 type GetChannelClientFunction func(channelID string) (ChannelClient, error)
@@ -99,6 +111,17 @@ func New(getChannelClient GetChannelClientFunction) (*Client, error) {
 
 // LifecycleInitEnclave initializes and registers an enclave for a particular FPC chaincode.
 func (rc *Client) LifecycleInitEnclave(channelID string, req LifecycleInitEnclaveRequest) (string, error) {
+	return rc.LifecycleInitEnclaveWithContext(context.Background(), channelID, req)
+}
+
+// LifecycleInitEnclaveWithContext is like LifecycleInitEnclave, but returns a *fpcerrors.ContextError as soon as ctx
+// is done. Note that the enclave may still be registered if the registration has already been submitted.
+func (rc *Client) LifecycleInitEnclaveWithContext(ctx context.Context, channelID string, req LifecycleInitEnclaveRequest) (string, error) {
+	txID, err := rc.initEnclave(ctx, channelID, req)
+	return txID, fpcerrors.FromContext(ctx, "init enclave", err)
+}
+
+func (rc *Client) initEnclave(ctx context.Context, channelID string, req LifecycleInitEnclaveRequest) (string, error) {
 	err := rc.verifyInitEnclaveRequest(req)
 	if err != nil {
 		return "", err
@@ -127,7 +150,7 @@ func (rc *Client) LifecycleInitEnclave(channelID string, req LifecycleInitEnclav
 
 	logger.Debugf("calling __initEnclave (%v)", initMsg)
 	// send query to create (init) enclave at the target peer
-	payload, err := channelClient.Query(
+	payload, err := channelClient.Query(ctx,
 		req.ChaincodeID, InitEnclaveCMD, [][]byte{[]byte(utils.MarshallProtoBase64(initMsg))},
 		req.EnclavePeerEndpoint,
 	)
@@ -143,7 +166,7 @@ func (rc *Client) LifecycleInitEnclave(channelID string, req LifecycleInitEnclav
 
 	logger.Debugf("calling registerEnclave")
 	// invoke registerEnclave at enclave registry
-	txID, err := channelClient.Execute(ERCC, RegisterEnclaveCMD, [][]byte{[]byte(convertedCredentials)})
+	txID, err := channelClient.Execute(ctx, ERCC, RegisterEnclaveCMD, [][]byte{[]byte(convertedCredentials)})
 	if err != nil {
 		return "", errors.Wrap(err, "Failed to execute register enclave")
 	}
@@ -151,6 +174,68 @@ func (rc *Client) LifecycleInitEnclave(channelID string, req LifecycleInitEnclav
 	return txID, nil
 }
 
+// LifecycleQueryEnclaves maps the given peers, e.g., the endorsing peers discovered for the FPC chaincode, to the
+// enclaves they host. For each peer, the enclave id is queried via `__getEnclaveId` and the corresponding credentials
+// are looked up at the enclave registry. Peers that do not host an (initialized) enclave, or whose enclave is not
+// registered, are not included in the result.
+func (rc *Client) LifecycleQueryEnclaves(channelID string, chaincodeID string, peerEndpoints ...string) (map[string]*PeerEnclave, error) {
+	return rc.LifecycleQueryEnclavesWithContext(context.Background(), channelID, chaincodeID, peerEndpoints...)
+}
+
+// LifecycleQueryEnclavesWithContext is like LifecycleQueryEnclaves, but returns a *fpcerrors.ContextError as soon as
+// ctx is done.
+func (rc *Client) LifecycleQueryEnclavesWithContext(ctx context.Context, channelID string, chaincodeID string, peerEndpoints ...string) (map[string]*PeerEnclave, error) {
+	enclaves, err := rc.queryEnclaves(ctx, channelID, chaincodeID, peerEndpoints...)
+	return enclaves, fpcerrors.FromContext(ctx, "query enclaves", err)
+}
+
+func (rc *Client) queryEnclaves(ctx context.Context, channelID string, chaincodeID string, peerEndpoints ...string) (map[string]*PeerEnclave, error) {
+	if chaincodeID == "" {
+		return nil, errors.New("chaincodeId is required")
+	}
+
+	channelClient, err := rc.GetChannelClient(channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create new channel client")
+	}
+
+	enclaves := make(map[string]*PeerEnclave)
+	for _, peerEndpoint := range peerEndpoints {
+		logger.Debugf("calling %s at %s", GetEnclaveIdCMD, peerEndpoint)
+		enclaveId, err := channelClient.Query(ctx, chaincodeID, GetEnclaveIdCMD, nil, peerEndpoint)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			logger.Debugf("no enclave at %s: %s", peerEndpoint, err)
+			continue
+		}
+
+		logger.Debugf("calling %s for enclave %s", QueryEnclaveCredentialsCMD, enclaveId)
+		credentialsBase64, err := channelClient.Query(ctx, ERCC, QueryEnclaveCredentialsCMD, [][]byte{[]byte(chaincodeID), enclaveId})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to query credentials of enclave %s", enclaveId)
+		}
+		if len(credentialsBase64) == 0 {
+			logger.Debugf("enclave %s at %s is not registered", enclaveId, peerEndpoint)
+			continue
+		}
+
+		credentials, err := utils.UnmarshalCredentials(string(credentialsBase64))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid credentials of enclave %s", enclaveId)
+		}
+
+		enclaves[peerEndpoint] = &PeerEnclave{
+			PeerEndpoint: peerEndpoint,
+			EnclaveId:    string(enclaveId),
+			Credentials:  credentials,
+		}
+	}
+
+	return enclaves, nil
+}
+
 func (rc *Client) verifyInitEnclaveRequest(req LifecycleInitEnclaveRequest) error {
 	if req.ChaincodeID == "" {
 		return errors.New("chaincodeId is required")
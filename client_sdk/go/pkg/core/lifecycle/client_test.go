@@ -7,14 +7,19 @@ SPDX-License-Identifier: Apache-2.0
 package lifecycle_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	fpcerrors "github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/errors"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/lifecycle"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/lifecycle/fakes"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/sgx"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"google.golang.org/protobuf/proto"
 )
 
 //go:generate counterfeiter -o fakes/channelclient.go -fake-name ChannelClient . chClient
@@ -185,13 +190,93 @@ func TestLifecycleInitEnclaveSuccess(t *testing.T) {
 	assert.Equal(t, 1, fakeChannelClient.QueryCallCount())
 	assert.Equal(t, 1, fakeChannelClient.ExecuteCallCount())
 
-	chaincodeID, Fcn, Args, _ := fakeChannelClient.QueryArgsForCall(0)
+	_, chaincodeID, Fcn, Args, _ := fakeChannelClient.QueryArgsForCall(0)
 	assert.Equal(t, chaincodeId, chaincodeID)
 	assert.Equal(t, lifecycle.InitEnclaveCMD, Fcn)
 	assert.Len(t, Args, 1)
 
-	chaincodeID, Fcn, Args = fakeChannelClient.ExecuteArgsForCall(0)
+	_, chaincodeID, Fcn, Args = fakeChannelClient.ExecuteArgsForCall(0)
 	assert.Equal(t, lifecycle.ERCC, chaincodeID)
 	assert.Equal(t, lifecycle.RegisterEnclaveCMD, Fcn)
 	assert.Len(t, Args, 1)
 }
+
+func TestLifecycleQueryEnclaves(t *testing.T) {
+	fakeChannelClient := &fakes.ChannelClient{}
+	client := setupClient(fakeChannelClient, &fakes.CredentialConverter{})
+
+	// no chaincode id
+	_, err := client.LifecycleQueryEnclaves(channelID, "", enclavePeerEndpoint)
+	assert.Error(t, err)
+
+	credentials := &protos.Credentials{Attestation: []byte("someAttestation")}
+	credentialsBase64 := utils.MarshallProtoBase64(credentials)
+
+	const (
+		noEnclavePeer       = "peer1.org1.example.com"
+		unregisteredPeer    = "peer2.org1.example.com"
+		enclaveId           = "someEnclaveId"
+		unregisteredEnclave = "someUnregisteredEnclaveId"
+	)
+	fakeChannelClient.QueryCalls(func(_ context.Context, chaincodeID string, fcn string, args [][]byte, endpoints ...string) ([]byte, error) {
+		switch fcn {
+		case lifecycle.GetEnclaveIdCMD:
+			assert.Equal(t, chaincodeId, chaincodeID)
+			switch endpoints[0] {
+			case enclavePeerEndpoint:
+				return []byte(enclaveId), nil
+			case unregisteredPeer:
+				return []byte(unregisteredEnclave), nil
+			default:
+				return nil, fmt.Errorf("cannot query enclave in state uninitialized")
+			}
+		case lifecycle.QueryEnclaveCredentialsCMD:
+			assert.Equal(t, lifecycle.ERCC, chaincodeID)
+			assert.Equal(t, chaincodeId, string(args[0]))
+			if string(args[1]) == enclaveId {
+				return []byte(credentialsBase64), nil
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected function %s", fcn)
+	})
+
+	enclaves, err := client.LifecycleQueryEnclaves(channelID, chaincodeId, enclavePeerEndpoint, noEnclavePeer, unregisteredPeer)
+	assert.NoError(t, err)
+	assert.Len(t, enclaves, 1)
+	assert.Equal(t, enclavePeerEndpoint, enclaves[enclavePeerEndpoint].PeerEndpoint)
+	assert.Equal(t, enclaveId, enclaves[enclavePeerEndpoint].EnclaveId)
+	assert.True(t, proto.Equal(credentials, enclaves[enclavePeerEndpoint].Credentials))
+
+	// ercc query fails
+	expectedError := fmt.Errorf("someQueryError")
+	fakeChannelClient.QueryCalls(func(_ context.Context, chaincodeID string, fcn string, args [][]byte, endpoints ...string) ([]byte, error) {
+		if fcn == lifecycle.GetEnclaveIdCMD {
+			return []byte(enclaveId), nil
+		}
+		return nil, expectedError
+	})
+	_, err = client.LifecycleQueryEnclaves(channelID, chaincodeId, enclavePeerEndpoint)
+	assert.ErrorIs(t, err, expectedError)
+}
+
+func TestLifecycleQueryEnclavesWithContext(t *testing.T) {
+	fakeChannelClient := &fakes.ChannelClient{}
+	client := setupClient(fakeChannelClient, &fakes.CredentialConverter{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fakeChannelClient.QueryCalls(func(ctx context.Context, chaincodeID string, fcn string, args [][]byte, endpoints ...string) ([]byte, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+
+	// a cancelled query aborts instead of skipping the peer
+	_, err := client.LifecycleQueryEnclavesWithContext(ctx, channelID, chaincodeId, enclavePeerEndpoint, "peer1.org1.example.com")
+	var ctxErr *fpcerrors.ContextError
+	assert.ErrorAs(t, err, &ctxErr)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, fpcerrors.IsTimeout(err))
+	assert.Equal(t, 1, fakeChannelClient.QueryCallCount())
+	queryCtx, _, _, _, _ := fakeChannelClient.QueryArgsForCall(0)
+	assert.Equal(t, ctx, queryCtx)
+}
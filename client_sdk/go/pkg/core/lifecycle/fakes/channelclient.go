@@ -2,16 +2,18 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 )
 
 type ChannelClient struct {
-	ExecuteStub        func(string, string, [][]byte) (string, error)
+	ExecuteStub        func(context.Context, string, string, [][]byte) (string, error)
 	executeMutex       sync.RWMutex
 	executeArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 [][]byte
+		arg3 string
+		arg4 [][]byte
 	}
 	executeReturns struct {
 		result1 string
@@ -21,13 +23,14 @@ type ChannelClient struct {
 		result1 string
 		result2 error
 	}
-	QueryStub        func(string, string, [][]byte, ...string) ([]byte, error)
+	QueryStub        func(context.Context, string, string, [][]byte, ...string) ([]byte, error)
 	queryMutex       sync.RWMutex
 	queryArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 [][]byte
-		arg4 []string
+		arg3 string
+		arg4 [][]byte
+		arg5 []string
 	}
 	queryReturns struct {
 		result1 []byte
@@ -41,25 +44,26 @@ type ChannelClient struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *ChannelClient) Execute(arg1 string, arg2 string, arg3 [][]byte) (string, error) {
-	var arg3Copy [][]byte
-	if arg3 != nil {
-		arg3Copy = make([][]byte, len(arg3))
-		copy(arg3Copy, arg3)
+func (fake *ChannelClient) Execute(arg1 context.Context, arg2 string, arg3 string, arg4 [][]byte) (string, error) {
+	var arg4Copy [][]byte
+	if arg4 != nil {
+		arg4Copy = make([][]byte, len(arg4))
+		copy(arg4Copy, arg4)
 	}
 	fake.executeMutex.Lock()
 	ret, specificReturn := fake.executeReturnsOnCall[len(fake.executeArgsForCall)]
 	fake.executeArgsForCall = append(fake.executeArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 [][]byte
-	}{arg1, arg2, arg3Copy})
+		arg3 string
+		arg4 [][]byte
+	}{arg1, arg2, arg3, arg4Copy})
 	stub := fake.ExecuteStub
 	fakeReturns := fake.executeReturns
-	fake.recordInvocation("Execute", []interface{}{arg1, arg2, arg3Copy})
+	fake.recordInvocation("Execute", []interface{}{arg1, arg2, arg3, arg4Copy})
 	fake.executeMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -73,17 +77,17 @@ func (fake *ChannelClient) ExecuteCallCount() int {
 	return len(fake.executeArgsForCall)
 }
 
-func (fake *ChannelClient) ExecuteCalls(stub func(string, string, [][]byte) (string, error)) {
+func (fake *ChannelClient) ExecuteCalls(stub func(context.Context, string, string, [][]byte) (string, error)) {
 	fake.executeMutex.Lock()
 	defer fake.executeMutex.Unlock()
 	fake.ExecuteStub = stub
 }
 
-func (fake *ChannelClient) ExecuteArgsForCall(i int) (string, string, [][]byte) {
+func (fake *ChannelClient) ExecuteArgsForCall(i int) (context.Context, string, string, [][]byte) {
 	fake.executeMutex.RLock()
 	defer fake.executeMutex.RUnlock()
 	argsForCall := fake.executeArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *ChannelClient) ExecuteReturns(result1 string, result2 error) {
@@ -112,26 +116,27 @@ func (fake *ChannelClient) ExecuteReturnsOnCall(i int, result1 string, result2 e
 	}{result1, result2}
 }
 
-func (fake *ChannelClient) Query(arg1 string, arg2 string, arg3 [][]byte, arg4 ...string) ([]byte, error) {
-	var arg3Copy [][]byte
-	if arg3 != nil {
-		arg3Copy = make([][]byte, len(arg3))
-		copy(arg3Copy, arg3)
+func (fake *ChannelClient) Query(arg1 context.Context, arg2 string, arg3 string, arg4 [][]byte, arg5 ...string) ([]byte, error) {
+	var arg4Copy [][]byte
+	if arg4 != nil {
+		arg4Copy = make([][]byte, len(arg4))
+		copy(arg4Copy, arg4)
 	}
 	fake.queryMutex.Lock()
 	ret, specificReturn := fake.queryReturnsOnCall[len(fake.queryArgsForCall)]
 	fake.queryArgsForCall = append(fake.queryArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 [][]byte
-		arg4 []string
-	}{arg1, arg2, arg3Copy, arg4})
+		arg3 string
+		arg4 [][]byte
+		arg5 []string
+	}{arg1, arg2, arg3, arg4Copy, arg5})
 	stub := fake.QueryStub
 	fakeReturns := fake.queryReturns
-	fake.recordInvocation("Query", []interface{}{arg1, arg2, arg3Copy, arg4})
+	fake.recordInvocation("Query", []interface{}{arg1, arg2, arg3, arg4Copy, arg5})
 	fake.queryMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3, arg4...)
+		return stub(arg1, arg2, arg3, arg4, arg5...)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -145,17 +150,17 @@ func (fake *ChannelClient) QueryCallCount() int {
 	return len(fake.queryArgsForCall)
 }
 
-func (fake *ChannelClient) QueryCalls(stub func(string, string, [][]byte, ...string) ([]byte, error)) {
+func (fake *ChannelClient) QueryCalls(stub func(context.Context, string, string, [][]byte, ...string) ([]byte, error)) {
 	fake.queryMutex.Lock()
 	defer fake.queryMutex.Unlock()
 	fake.QueryStub = stub
 }
 
-func (fake *ChannelClient) QueryArgsForCall(i int) (string, string, [][]byte, []string) {
+func (fake *ChannelClient) QueryArgsForCall(i int) (context.Context, string, string, [][]byte, []string) {
 	fake.queryMutex.RLock()
 	defer fake.queryMutex.RUnlock()
 	argsForCall := fake.queryArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *ChannelClient) QueryReturns(result1 []byte, result2 error) {
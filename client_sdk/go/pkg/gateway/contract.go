@@ -9,8 +9,16 @@ SPDX-License-Identifier: Apache-2.0
 package gateway
 
 import (
+	"context"
+
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"github.com/pkg/errors"
 )
 
 // Contract provides functions to query/invoke FPC chaincodes based on the Gateway API.
@@ -46,6 +54,35 @@ type Contract interface {
 	//  Returns:
 	//  The return value of the transaction function in the smart contract.
 	SubmitTransaction(name string, args ...string) ([]byte, error)
+
+	// SubmitBatch will submit several transaction invocations to the ledger as a single FPC transaction.
+	// All invocations are executed by the chaincode enclave as part of a single enclave invocation.
+	//  Parameters:
+	//  requests are the transaction functions and their arguments, executed in the given order.
+	//  independent defines whether the batch is atomic (false), i.e., if one invocation fails the whole batch fails,
+	//  or whether the state updates of each successful invocation are committed independently (true).
+	//
+	//  Returns:
+	//  The results of the transaction functions in the order of the requests.
+	SubmitBatch(requests []contract.BatchRequest, independent bool) ([]contract.BatchResult, error)
+
+	// EvaluateTransactionWithContext is like EvaluateTransaction, but aborts as soon as ctx is done.
+	// In this case, the returned error is a *errors.ContextError (see package core/errors); use errors.IsTimeout to
+	// check for an exceeded deadline. A Contract created with GetContractWithChannelClient passes ctx on to the
+	// requests to the peers; as the gateway API does not support contexts, a Contract created with GetContract checks
+	// ctx before each request only.
+	EvaluateTransactionWithContext(ctx context.Context, name string, args ...string) ([]byte, error)
+
+	// SubmitTransactionWithContext is like SubmitTransaction, but aborts as soon as ctx is done.
+	// Note that a transaction that has already been sent to the ordering service may still be committed.
+	SubmitTransactionWithContext(ctx context.Context, name string, args ...string) ([]byte, error)
+
+	// SubmitBatchWithContext is like SubmitBatch, but aborts as soon as ctx is done.
+	SubmitBatchWithContext(ctx context.Context, requests []contract.BatchRequest, independent bool) ([]contract.BatchResult, error)
+
+	// InvalidateCache drops the cached chaincode encryption key, enclave keys and peer endpoints, which are then queried
+	// again from the enclave registry by the next transaction. See contract.WithCacheTTL.
+	InvalidateCache()
 }
 
 // Network interface that is needed by the FPC contract implementation
@@ -61,16 +98,163 @@ func (c *gatewayContract) Name() string {
 	return c.c.Name()
 }
 
-func (c *gatewayContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
-	return c.c.EvaluateTransaction(name, args...)
+// EvaluateTransaction evaluates the transaction if ctx is not done yet. As the gateway API of the Fabric Go SDK does not
+// support contexts, the evaluation itself is bounded by the timeout of the gateway only, see gateway.WithTimeout.
+func (c *gatewayContract) EvaluateTransaction(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp, err := c.c.EvaluateTransaction(name, args...)
+	return resp, typedError(err)
+}
+
+// SubmitTransaction submits the transaction if ctx is not done yet. As with EvaluateTransaction, the submission itself
+// is bounded by the timeout of the gateway only.
+func (c *gatewayContract) SubmitTransaction(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp, err := c.c.SubmitTransaction(name, args...)
+	return resp, typedError(err)
+}
+
+// typedError returns a *contract.StaleReadError or *contract.StaleKeyError wrapping err if its status reports a stale
+// read or a request prepared with outdated keys, respectively, and err otherwise
+func typedError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case isStaleRead(err):
+		return &contract.StaleReadError{Err: err}
+	case isStaleKey(err):
+		return &contract.StaleKeyError{Err: err}
+	}
+	return err
 }
 
-func (c *gatewayContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
-	return c.c.SubmitTransaction(name, args...)
+// isStaleRead returns true if the status of err reports a stale read, i.e., `__endorse` returned
+// endorsement.StaleReadStatus or the transaction was invalidated with MVCC_READ_CONFLICT at commit time
+func isStaleRead(err error) bool {
+	return hasStatus(err, func(s *status.Status) bool {
+		switch s.Group {
+		case status.EndorserServerStatus, status.ChaincodeStatus:
+			return s.Code == endorsement.StaleReadStatus
+		case status.EventServerStatus:
+			return s.Code == int32(peer.TxValidationCode_MVCC_READ_CONFLICT)
+		}
+		return false
+	})
+}
+
+// isStaleKey returns true if the status of err reports that ECC rejected the request with endorsement.StaleKeyStatus
+func isStaleKey(err error) bool {
+	return hasStatus(err, func(s *status.Status) bool {
+		return (s.Group == status.EndorserServerStatus || s.Group == status.ChaincodeStatus) && s.Code == endorsement.StaleKeyStatus
+	})
+}
+
+// hasStatus returns true if match returns true for the status of err or, if err combines the errors of several
+// endorsers, for the status of one of them
+func hasStatus(err error, match func(s *status.Status) bool) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+
+	if s.Group == status.ClientStatus && s.Code == status.MultipleErrors.ToInt32() {
+		for _, d := range s.Details {
+			if e, ok := d.(error); ok && hasStatus(e, match) {
+				return true
+			}
+		}
+		return false
+	}
+	return match(s)
 }
 
 func (c *gatewayContract) CreateTransaction(name string, peerEndpoints ...string) (contract.Transaction, error) {
-	return c.c.CreateTransaction(name, gateway.WithEndorsingPeers(peerEndpoints...))
+	txn, err := c.c.CreateTransaction(name, gateway.WithEndorsingPeers(peerEndpoints...))
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayTransaction{txn}, nil
+}
+
+type gatewayTransaction struct {
+	txn *gateway.Transaction
+}
+
+func (t *gatewayTransaction) Evaluate(ctx context.Context, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp, err := t.txn.Evaluate(args...)
+	return resp, typedError(err)
+}
+
+// ChannelClient is the part of the channel client of the Fabric Go SDK (see channel.Client) that is needed by the FPC
+// contract implementation
+type ChannelClient interface {
+	Query(request channel.Request, options ...channel.RequestOption) (channel.Response, error)
+	Execute(request channel.Request, options ...channel.RequestOption) (channel.Response, error)
+}
+
+// channelContract implements contract.Contract based on a channel client. Unlike the gateway API, the channel client
+// takes the context of a request, thus, a request is aborted as soon as its context is done.
+type channelContract struct {
+	client      ChannelClient
+	chaincodeID string
+}
+
+func (c *channelContract) Name() string {
+	return c.chaincodeID
+}
+
+func (c *channelContract) EvaluateTransaction(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return c.query(ctx, name, args)
+}
+
+func (c *channelContract) SubmitTransaction(ctx context.Context, name string, args ...string) ([]byte, error) {
+	resp, err := c.client.Execute(c.request(name, args), channel.WithParentContext(ctx), channel.WithRetry(retry.DefaultChannelOpts))
+	if err != nil {
+		return nil, typedError(errors.Wrap(err, "Failed to submit"))
+	}
+	return resp.Payload, nil
+}
+
+func (c *channelContract) CreateTransaction(name string, peerEndpoints ...string) (contract.Transaction, error) {
+	return &channelTransaction{contract: c, name: name, peerEndpoints: peerEndpoints}, nil
+}
+
+func (c *channelContract) query(ctx context.Context, name string, args []string, peerEndpoints ...string) ([]byte, error) {
+	options := []channel.RequestOption{channel.WithParentContext(ctx)}
+	if len(peerEndpoints) > 0 {
+		options = append(options, channel.WithTargetEndpoints(peerEndpoints...))
+	}
+
+	resp, err := c.client.Query(c.request(name, args), options...)
+	if err != nil {
+		return nil, typedError(errors.Wrap(err, "Failed to evaluate"))
+	}
+	return resp.Payload, nil
+}
+
+func (c *channelContract) request(name string, args []string) channel.Request {
+	request := channel.Request{ChaincodeID: c.chaincodeID, Fcn: name}
+	for _, arg := range args {
+		request.Args = append(request.Args, []byte(arg))
+	}
+	return request
+}
+
+type channelTransaction struct {
+	contract      *channelContract
+	name          string
+	peerEndpoints []string
+}
+
+func (t *channelTransaction) Evaluate(ctx context.Context, args ...string) ([]byte, error) {
+	return t.contract.query(ctx, t.name, args, t.peerEndpoints...)
 }
 
 type contractProvider struct {
@@ -81,14 +265,32 @@ func (cp *contractProvider) GetContract(id string) contract.Contract {
 	return &gatewayContract{cp.network.GetContract(id)}
 }
 
+type channelContractProvider struct {
+	client ChannelClient
+}
+
+func (cp *channelContractProvider) GetContract(id string) contract.Contract {
+	return &channelContract{client: cp.client, chaincodeID: id}
+}
+
 // GetContract is the factory method for creating FPC Contract objects.
 //
 //	Parameters:
 //	network is an initialized Fabric network object
 //	chaincodeID is the ID of the target chaincode
+//	opts are optional settings, e.g., contract.WithStaleReadRetries, contract.WithCredentialVerification, contract.WithCacheTTL
+//	or contract.WithEndpointSelector
 //
 //	Returns:
 //	The contract object
-func GetContract(network Network, chaincodeID string) Contract {
-	return contract.GetContract(&contractProvider{network: network}, chaincodeID)
+func GetContract(network Network, chaincodeID string, opts ...contract.Option) Contract {
+	return contract.GetContract(&contractProvider{network: network}, chaincodeID, opts...)
+}
+
+// GetContractWithChannelClient is like GetContract, but the FPC Contract object sends its requests using the given
+// channel client, e.g., created with channel.New(sdk.ChannelContext(channelID, ...)). In contrast to a Contract created
+// with GetContract, the context passed to the *WithContext functions is passed on to the channel client, thus, a
+// request is aborted as soon as the context is done.
+func GetContractWithChannelClient(client ChannelClient, chaincodeID string, opts ...contract.Option) Contract {
+	return contract.GetContract(&channelContractProvider{client: client}, chaincodeID, opts...)
 }
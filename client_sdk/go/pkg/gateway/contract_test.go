@@ -0,0 +1,139 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/core/contract"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+
+//counterfeiter:generate -o fakes/channelclient.go -fake-name ChannelClient . channelClient
+//lint:ignore U1000 This is just used to generate fake
+type channelClient interface {
+	ChannelClient
+}
+
+func TestIsStaleRead(t *testing.T) {
+	endorseErr := status.New(status.EndorserServerStatus, endorsement.StaleReadStatus, "stale read of key someKey: value hash mismatch", nil)
+	assert.True(t, isStaleRead(errors.Wrap(endorseErr, "Failed to submit")))
+
+	commitErr := status.New(status.EventServerStatus, int32(peer.TxValidationCode_MVCC_READ_CONFLICT), "received invalid transaction", nil)
+	assert.True(t, isStaleRead(errors.Wrap(commitErr, "Failed to submit")))
+
+	otherErr := status.New(status.EndorserServerStatus, 500, "stale read of key someKey: value hash mismatch", nil)
+	assert.True(t, isStaleRead(errors.Wrap(multi.New(otherErr, endorseErr), "Failed to submit")))
+	assert.False(t, isStaleRead(errors.Wrap(multi.New(otherErr, otherErr), "Failed to submit")))
+
+	// the error message is not relevant
+	assert.False(t, isStaleRead(otherErr))
+	assert.False(t, isStaleRead(status.New(status.EventServerStatus, int32(peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE), "MVCC_READ_CONFLICT", nil)))
+	assert.False(t, isStaleRead(fmt.Errorf("commit failed with code MVCC_READ_CONFLICT")))
+}
+
+func TestIsStaleKey(t *testing.T) {
+	invokeErr := status.New(status.ChaincodeStatus, endorsement.StaleKeyStatus, "t.Enclave.Invoke failed: decryption of key transport message failed", nil)
+	assert.True(t, isStaleKey(errors.Wrap(invokeErr, "Failed to evaluate")))
+
+	endorseErr := status.New(status.EndorserServerStatus, endorsement.StaleKeyStatus, "ccParams don't match", nil)
+	otherErr := status.New(status.EndorserServerStatus, 500, "ccParams don't match", nil)
+	assert.True(t, isStaleKey(errors.Wrap(multi.New(otherErr, endorseErr), "Failed to submit")))
+
+	// the error message is not relevant
+	assert.False(t, isStaleKey(otherErr))
+	assert.False(t, isStaleKey(fmt.Errorf("decryption of key transport message failed")))
+	assert.False(t, isStaleKey(status.New(status.EndorserServerStatus, endorsement.StaleReadStatus, "stale read", nil)))
+
+	var staleKeyErr *contract.StaleKeyError
+	assert.ErrorAs(t, typedError(invokeErr), &staleKeyErr)
+	var staleReadErr *contract.StaleReadError
+	assert.ErrorAs(t, typedError(status.New(status.EndorserServerStatus, endorsement.StaleReadStatus, "stale read", nil)), &staleReadErr)
+	assert.Equal(t, otherErr, typedError(otherErr))
+	assert.NoError(t, typedError(nil))
+}
+
+func TestChannelContract(t *testing.T) {
+	client := &fakes.ChannelClient{}
+	c := (&channelContractProvider{client: client}).GetContract("someChaincode")
+	assert.Equal(t, "someChaincode", c.Name())
+	ctx := context.Background()
+
+	client.QueryReturns(channel.Response{Payload: []byte("result")}, nil)
+	resp, err := c.EvaluateTransaction(ctx, "someFunction", "arg1", "arg2")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), resp)
+	request, options := client.QueryArgsForCall(0)
+	assert.Equal(t, channel.Request{ChaincodeID: "someChaincode", Fcn: "someFunction", Args: [][]byte{[]byte("arg1"), []byte("arg2")}}, request)
+	// the parent context
+	assert.Len(t, options, 1)
+
+	txn, err := c.CreateTransaction("someFunction", "peer0.org1.example.com:7051")
+	require.NoError(t, err)
+	resp, err = txn.Evaluate(ctx, "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), resp)
+	request, options = client.QueryArgsForCall(1)
+	assert.Equal(t, channel.Request{ChaincodeID: "someChaincode", Fcn: "someFunction", Args: [][]byte{[]byte("arg1")}}, request)
+	// the parent context and the target endpoints
+	assert.Len(t, options, 2)
+
+	client.QueryReturns(channel.Response{}, fmt.Errorf("some error"))
+	_, err = c.EvaluateTransaction(ctx, "someFunction")
+	assert.EqualError(t, err, "Failed to evaluate: some error")
+
+	client.QueryReturns(channel.Response{}, status.New(status.ChaincodeStatus, endorsement.StaleKeyStatus, "decryption of key transport message failed", nil))
+	_, err = txn.Evaluate(ctx, "arg1")
+	var staleKey *contract.StaleKeyError
+	assert.ErrorAs(t, err, &staleKey)
+
+	client.ExecuteReturns(channel.Response{Payload: []byte("result")}, nil)
+	resp, err = c.SubmitTransaction(ctx, "__endorse", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), resp)
+	request, options = client.ExecuteArgsForCall(0)
+	assert.Equal(t, channel.Request{ChaincodeID: "someChaincode", Fcn: "__endorse", Args: [][]byte{[]byte("arg1")}}, request)
+	// the parent context and the retry options
+	assert.Len(t, options, 2)
+
+	staleReadErr := status.New(status.EndorserServerStatus, endorsement.StaleReadStatus, "stale read of key someKey: value hash mismatch", nil)
+	client.ExecuteReturns(channel.Response{}, staleReadErr)
+	_, err = c.SubmitTransaction(ctx, "__endorse", "arg1")
+	var staleRead *contract.StaleReadError
+	assert.ErrorAs(t, err, &staleRead)
+
+	client.ExecuteReturns(channel.Response{}, fmt.Errorf("some error"))
+	_, err = c.SubmitTransaction(ctx, "__endorse", "arg1")
+	assert.EqualError(t, err, "Failed to submit: some error")
+	assert.False(t, errors.As(err, &staleRead))
+}
+
+func TestGatewayContractDoneContext(t *testing.T) {
+	// the gateway contract is not called once the context is done
+	c := &gatewayContract{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.EvaluateTransaction(ctx, "someFunction")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = c.SubmitTransaction(ctx, "someFunction")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = (&gatewayTransaction{}).Evaluate(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
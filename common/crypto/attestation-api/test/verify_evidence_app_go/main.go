@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid/pdo"
@@ -45,7 +46,7 @@ func main() {
 		Evidence: []byte(evidenceJson),
 	}
 
-	err = verifier.VerifyCredentials(cred, expectedMrenclave)
+	err = verifier.VerifyCredentials(cred, expectedMrenclave, time.Now())
 	exitIfError(err)
 }
 
@@ -56,6 +56,119 @@ bool cc_data::generate_keys()
     return true;
 }
 
+static bool encode_bytes_field(pb_ostream_t* ostream, uint32_t tag, const uint8_t* data, size_t size)
+{
+    return pb_encode_tag(ostream, PB_WT_STRING, tag) && pb_encode_string(ostream, data, size);
+}
+
+static bool encode_string_field(pb_ostream_t* ostream, uint32_t tag, const std::string& s)
+{
+    return encode_bytes_field(ostream, tag, (const uint8_t*)s.c_str(), s.length());
+}
+
+bool cc_data::serialize_state(ByteArray& state)
+{
+    pb_ostream_t ostream;
+    std::string enclave_sk;
+    std::string enclave_vk;
+    std::string enclave_dk;
+    std::string chaincode_dk;
+    std::string chaincode_ek;
+    bool b;
+
+    try
+    {
+        enclave_sk = signature_key_.Serialize();
+        enclave_vk = verification_key_.Serialize();
+        enclave_dk = decryption_key_.Serialize();
+        chaincode_dk = cc_decryption_key_.Serialize();
+        chaincode_ek = cc_encryption_key_.Serialize();
+    }
+    catch (...)
+    {
+        LOG_ERROR("cannot serialize keys");
+        return false;
+    }
+
+    // NOTE: the size is roughly estimated, it should be adapted
+    CATCH(b, state.resize(enclave_sk.length() + enclave_vk.length() + enclave_dk.length() +
+                          chaincode_dk.length() + chaincode_ek.length() +
+                          state_encryption_key_.size() + cc_parameters_.size() +
+                          host_parameters_.size() + attestation_parameters_.size() + 1024));
+    COND2ERR(!b);
+
+    ostream = pb_ostream_from_buffer(state.data(), state.size());
+    COND2ERR(!encode_string_field(&ostream, fpc_EnclaveState_enclave_sk_tag, enclave_sk));
+    COND2ERR(!encode_string_field(&ostream, fpc_EnclaveState_enclave_vk_tag, enclave_vk));
+    COND2ERR(!encode_string_field(&ostream, fpc_EnclaveState_enclave_dk_tag, enclave_dk));
+    COND2ERR(!encode_string_field(&ostream, fpc_EnclaveState_chaincode_dk_tag, chaincode_dk));
+    COND2ERR(!encode_string_field(&ostream, fpc_EnclaveState_chaincode_ek_tag, chaincode_ek));
+    COND2ERR(!encode_bytes_field(&ostream, fpc_EnclaveState_state_encryption_key_tag,
+        state_encryption_key_.data(), state_encryption_key_.size()));
+    COND2ERR(!encode_bytes_field(
+        &ostream, fpc_EnclaveState_cc_params_tag, cc_parameters_.data(), cc_parameters_.size()));
+    COND2ERR(!encode_bytes_field(&ostream, fpc_EnclaveState_host_params_tag,
+        host_parameters_.data(), host_parameters_.size()));
+    COND2ERR(!encode_bytes_field(&ostream, fpc_EnclaveState_attestation_params_tag,
+        attestation_parameters_.data(), attestation_parameters_.size()));
+
+    // resize array to fit written data
+    state.resize(ostream.bytes_written);
+
+    return true;
+
+err:
+    return false;
+}
+
+#define BYTES_TO_STRING(b) (b == NULL ? std::string() : std::string((const char*)b->bytes, b->size))
+#define ASSIGN_BYTES(array, b) \
+    (b == NULL ? array.clear() : array.assign(b->bytes, b->bytes + b->size))
+
+bool cc_data::restore_state(const ByteArray& state)
+{
+    fpc_EnclaveState enclave_state = fpc_EnclaveState_init_zero;
+    pb_istream_t istream;
+    bool b;
+
+    istream = pb_istream_from_buffer((const unsigned char*)state.data(), state.size());
+    b = pb_decode(&istream, fpc_EnclaveState_fields, &enclave_state);
+    COND2LOGERR(!b, PB_GET_ERROR(&istream));
+
+    try
+    {
+        signature_key_.Deserialize(BYTES_TO_STRING(enclave_state.enclave_sk));
+        verification_key_ = signature_key_.GetPublicKey();
+        decryption_key_.Deserialize(BYTES_TO_STRING(enclave_state.enclave_dk));
+        encryption_key_ = decryption_key_.GetPublicKey();
+        cc_decryption_key_.Deserialize(BYTES_TO_STRING(enclave_state.chaincode_dk));
+        cc_encryption_key_ = cc_decryption_key_.GetPublicKey();
+
+        ASSIGN_BYTES(state_encryption_key_, enclave_state.state_encryption_key);
+        ASSIGN_BYTES(cc_parameters_, enclave_state.cc_params);
+        ASSIGN_BYTES(host_parameters_, enclave_state.host_params);
+        ASSIGN_BYTES(attestation_parameters_, enclave_state.attestation_params);
+    }
+    catch (...)
+    {
+        LOG_ERROR("cannot restore keys");
+        pb_release(fpc_EnclaveState_fields, &enclave_state);
+        return false;
+    }
+    pb_release(fpc_EnclaveState_fields, &enclave_state);
+
+    {
+        std::string s = get_enclave_id();
+        LOG_DEBUG("restored enclave id: %s", s.c_str());
+    }
+
+    return true;
+
+err:
+    pb_release(fpc_EnclaveState_fields, &enclave_state);
+    return false;
+}
+
 bool cc_data::build_attested_data(ByteArray& attested_data)
 {
     // estimate attested data size
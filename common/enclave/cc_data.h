@@ -32,6 +32,11 @@ private:
 public:
     bool generate();
 
+    // serializes the keys and parameters as fpc.EnclaveState, and restores them, respectively;
+    // used to seal the enclave state
+    bool serialize_state(ByteArray& state);
+    bool restore_state(const ByteArray& state);
+
     bool get_credentials(const uint8_t* attestation_parameters,
         uint32_t ap_size,
         const uint8_t* cc_parameters,
@@ -13,6 +13,7 @@
 #include <assert.h>
 #include <string>
 
+#include "sgx_tseal.h"
 #include "sgx_utils.h"
 
 #include "attestation-api/attestation/attestation.h"
@@ -22,24 +23,15 @@
 #include "protos/fpc/fpc.pb.h"
 
 #include "cc_data.h"
+#include "fpc-types.h"
 
 // enclave sk and pk (both are little endian) used for out signatures
 sgx_ec256_private_t enclave_sk = {0};
 sgx_ec256_public_t enclave_pk = {0};
 
-// creates new identity if not exists
-int ecall_init(const uint8_t* attestation_parameters,
-    uint32_t ap_size,
-    const uint8_t* cc_parameters,
-    uint32_t ccp_size,
-    const uint8_t* host_parameters,
-    uint32_t hp_size,
-    uint8_t* credentials,
-    uint32_t credentials_max_size,
-    uint32_t* credentials_size)
+// creates the enclave key pair used for reports, see ecall_create_report
+static int create_report_key_pair()
 {
-    bool b;
-
     // create new pub/prv key pair
     sgx_ecc_state_handle_t ecc_handle = NULL;
     sgx_status_t sgx_ret = sgx_ecc256_open_context(&ecc_handle);
@@ -63,6 +55,27 @@ int ecall_init(const uint8_t* attestation_parameters,
     LOG_DEBUG("Enc: Enclave pk (little endian): %s", base64_pk.c_str());
 
     LOG_DEBUG("Enc: Identity generated!");
+    return SGX_SUCCESS;
+}
+
+// creates new identity if not exists
+int ecall_init(const uint8_t* attestation_parameters,
+    uint32_t ap_size,
+    const uint8_t* cc_parameters,
+    uint32_t ccp_size,
+    const uint8_t* host_parameters,
+    uint32_t hp_size,
+    uint8_t* credentials,
+    uint32_t credentials_max_size,
+    uint32_t* credentials_size)
+{
+    bool b;
+
+    int ret = create_report_key_pair();
+    if (ret != SGX_SUCCESS)
+    {
+        return ret;
+    }
 
     // NOTE:
     // cc_data is a global pointer, meant to reference a global variable of cc_data type.
@@ -93,6 +106,101 @@ err:
     return SGX_ERROR_UNEXPECTED;
 }
 
+// restores the identity from a sealed enclave state, see ecall_seal_state, and returns the
+// (null-terminated) enclave id of the restored enclave
+int ecall_restore(const uint8_t* sealed_state,
+    uint32_t sealed_state_size,
+    char* enclave_id,
+    uint32_t enclave_id_max_size)
+{
+    ByteArray state;
+    uint32_t state_size;
+    sgx_status_t sgx_ret;
+    std::string id;
+    bool b;
+
+    int ret = create_report_key_pair();
+    if (ret != SGX_SUCCESS)
+    {
+        return ret;
+    }
+
+    COND2LOGERR(g_cc_data != NULL, "cc data already created");
+
+    COND2LOGERR(sealed_state_size < sizeof(sgx_sealed_data_t), "sealed state too short");
+    state_size = sgx_get_encrypt_txt_len((const sgx_sealed_data_t*)sealed_state);
+    COND2LOGERR(state_size == UINT32_MAX ||
+                    sgx_calc_sealed_data_size(0, state_size) > sealed_state_size,
+        "invalid sealed state");
+
+    CATCH(b, state.resize(state_size));
+    COND2ERR(!b);
+
+    sgx_ret = sgx_unseal_data(
+        (const sgx_sealed_data_t*)sealed_state, NULL, NULL, state.data(), &state_size);
+    COND2LOGERR(sgx_ret != SGX_SUCCESS, "cannot unseal enclave state");
+
+    g_cc_data = new cc_data;
+    COND2LOGERR(g_cc_data == NULL, "error creating cc data object");
+
+    b = g_cc_data->restore_state(state);
+    if (!b)
+    {
+        delete g_cc_data;
+        g_cc_data = NULL;
+    }
+    COND2LOGERR(!b, "error restoring cc data");
+
+    id = g_cc_data->get_enclave_id();
+    COND2LOGERR(id.length() + 1 > enclave_id_max_size, "enclave id buffer too small");
+    memcpy(enclave_id, id.c_str(), id.length() + 1);
+
+    LOG_DEBUG("restore enclave successful");
+    return SGX_SUCCESS;
+
+err:
+    return SGX_ERROR_UNEXPECTED;
+}
+
+// seals the enclave state (keys and parameters) to this enclave (MRENCLAVE), so that the enclave can
+// be restored after a restart using ecall_restore
+int ecall_seal_state(
+    uint8_t* sealed_state, uint32_t sealed_state_max_size, uint32_t* sealed_state_size)
+{
+    ByteArray state;
+    uint32_t size;
+    sgx_status_t sgx_ret;
+    sgx_attributes_t attribute_mask;
+    bool b;
+
+    *sealed_state_size = 0;
+    COND2LOGERR(g_cc_data == NULL, "enclave not initialized");
+
+    b = g_cc_data->serialize_state(state);
+    COND2LOGERR(!b, "cannot serialize enclave state");
+
+    size = sgx_calc_sealed_data_size(0, state.size());
+    COND2LOGERR(size == UINT32_MAX, "cannot calculate sealed state size");
+    if (size > sealed_state_max_size)
+    {
+        // tell the caller the required buffer size
+        *sealed_state_size = size;
+        return FPC_ERROR_BUFFER_TOO_SMALL;
+    }
+
+    attribute_mask.flags = TSEAL_DEFAULT_FLAGSMASK;
+    attribute_mask.xfrm = 0x0;
+    sgx_ret = sgx_seal_data_ex(SGX_KEYPOLICY_MRENCLAVE, attribute_mask, TSEAL_DEFAULT_MISCMASK, 0,
+        NULL, state.size(), state.data(), size, (sgx_sealed_data_t*)sealed_state);
+    COND2LOGERR(sgx_ret != SGX_SUCCESS, "cannot seal enclave state");
+
+    *sealed_state_size = size;
+    return SGX_SUCCESS;
+
+err:
+    return SGX_ERROR_UNEXPECTED;
+}
+
 // returns report (containing enclave pk hash) and enclave pk in big endian format
 int ecall_create_report(
     const sgx_target_info_t* target, sgx_report_t* report_out, uint8_t* pubkey_out)
@@ -12,6 +12,14 @@
 #include <stdio.h>
 #include <stdlib.h>
 
+// returned by the enclave (and sgxcc_invoke) if the response buffer is too small;
+// in this case, the required buffer size is returned as response length
+#define FPC_ERROR_BUFFER_TOO_SMALL 0x7F000001
+
+// returned by the enclave (and sgxcc_invoke) if the key transport message of a request cannot be decrypted,
+// i.e., the request was encrypted with another chaincode encryption key
+#define FPC_ERROR_KEY_TRANSPORT 0x7F000002
+
 typedef uint64_t enclave_id_t;
 typedef uint8_t* quote_t;
 typedef struct spid_t
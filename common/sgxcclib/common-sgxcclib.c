@@ -47,6 +47,52 @@ int sgxcc_create_enclave(sgx_enclave_id_t* eid,
     return SGX_SUCCESS;
 }
 
+int sgxcc_restore_enclave(enclave_id_t* eid,
+    const char* enclave_file,
+    uint8_t* sealed_state,
+    uint32_t sealed_state_size,
+    char* enclave_id,
+    uint32_t enclave_id_max_size)
+{
+    if (access(enclave_file, F_OK) == -1)
+    {
+        LOG_ERROR("Lib: enclave file does not exist! %s", enclave_file);
+        return SGX_ERROR_UNEXPECTED;
+    }
+
+    sgx_launch_token_t token = {0};
+    int updated = 0;
+
+    int ret = sgx_create_enclave(enclave_file, SGX_DEBUG_FLAG, &token, &updated, eid, NULL);
+    CHECK_SGX_ERROR_AND_RETURN_ON_ERROR(ret)
+
+    int enclave_ret = SGX_ERROR_UNEXPECTED;
+    ret = ecall_restore(
+        *eid, &enclave_ret, sealed_state, sealed_state_size, enclave_id, enclave_id_max_size);
+    if (ret == SGX_SUCCESS && enclave_ret != SGX_SUCCESS)
+    {
+        // do not leave an enclave behind that could not be restored
+        sgx_destroy_enclave(*eid);
+    }
+    CHECK_SGX_ERROR_AND_RETURN_ON_ERROR(ret)
+    CHECK_SGX_ERROR_AND_RETURN_ON_ERROR(enclave_ret)
+
+    return SGX_SUCCESS;
+}
+
+int sgxcc_seal_enclave_state(enclave_id_t eid,
+    uint8_t* sealed_state,
+    uint32_t sealed_state_max_size,
+    uint32_t* sealed_state_size)
+{
+    int enclave_ret = SGX_ERROR_UNEXPECTED;
+    int ret = ecall_seal_state(
+        eid, &enclave_ret, sealed_state, sealed_state_max_size, sealed_state_size);
+    CHECK_SGX_ERROR_AND_RETURN_ON_ERROR(ret)
+    CHECK_SGX_ERROR_AND_RETURN_ON_ERROR(enclave_ret)
+    return SGX_SUCCESS;
+}
+
 int sgxcc_destroy_enclave(enclave_id_t eid)
 {
     int ret = sgx_destroy_enclave((sgx_enclave_id_t)eid);
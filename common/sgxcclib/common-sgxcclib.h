@@ -25,6 +25,16 @@ int sgxcc_create_enclave(enclave_id_t* eid,
     uint8_t* credentials,
     uint32_t credentials_max_size,
     uint32_t* credentials_size);
+int sgxcc_restore_enclave(enclave_id_t* eid,
+    const char* enclave_file,
+    uint8_t* sealed_state,
+    uint32_t sealed_state_size,
+    char* enclave_id,
+    uint32_t enclave_id_max_size);
+int sgxcc_seal_enclave_state(enclave_id_t eid,
+    uint8_t* sealed_state,
+    uint32_t sealed_state_max_size,
+    uint32_t* sealed_state_size);
 int sgxcc_destroy_enclave(enclave_id_t eid);
 int sgxcc_get_quote_size(uint8_t* p_sig_rl, uint32_t sig_rl_size, uint32_t* p_quote_size);
 int sgxcc_get_target_info(enclave_id_t eid, target_info_t* target_info);
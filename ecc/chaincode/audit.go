@@ -0,0 +1,147 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chaincode
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+)
+
+// AuditLogEnv defines the file the audit records are appended to; if not set, no audit records are written
+const AuditLogEnv = "FPC_AUDIT_LOG"
+
+// ReasonAudit is the failure reason if the audit record of a request cannot be written
+const ReasonAudit = "audit_failed"
+
+// AuditRecord records an __endorse or __initEnclave request. It contains only metadata; in particular, no (decrypted)
+// request or response content, state keys or values, and no error messages, which may contain state keys.
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	Function string    `json:"function"`
+	TxId     string    `json:"tx_id"`
+	// CreatorMSPID is the MSP ID of the creator of the transaction
+	CreatorMSPID string `json:"creator_msp_id,omitempty"`
+	// EnclaveId is the enclave that processed the request (__endorse) or that was created (__initEnclave)
+	EnclaveId string               `json:"enclave_id,omitempty"`
+	CCParams  *protos.CCParameters `json:"cc_params,omitempty"`
+	// ProposalTxId is the transaction id of the original proposal processed by the enclave (__endorse)
+	ProposalTxId string `json:"proposal_tx_id,omitempty"`
+	// RequestHash is the hex-encoded hash of the (encrypted) chaincode request message (__endorse)
+	RequestHash string `json:"request_hash,omitempty"`
+	// Reads and Writes are the number of keys read and written by the enclave (__endorse)
+	Reads  int `json:"reads"`
+	Writes int `json:"writes"`
+	// Outcome is "success" or "failure"; Reason is the failure reason, as reported in the request_failures metric
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// AuditSink stores audit records
+type AuditSink interface {
+	Write(record *AuditRecord) error
+}
+
+// JSONLinesSink appends audit records as JSON lines to a writer
+type JSONLinesSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesSink returns a sink that writes one JSON-encoded audit record per line to w
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{w: w}
+}
+
+func (s *JSONLinesSink) Write(record *AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(line); err != nil {
+		return err
+	}
+	// make sure the record is persisted before the request completes
+	if f, ok := s.w.(interface{ Sync() error }); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+// AuditSinkFromEnv returns a JSONLinesSink appending to the file defined by FPC_AUDIT_LOG, or nil if FPC_AUDIT_LOG
+// is not set
+func AuditSinkFromEnv() (AuditSink, error) {
+	path := os.Getenv(AuditLogEnv)
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open audit log %s", path)
+	}
+	return NewJSONLinesSink(f), nil
+}
+
+// newAuditRecord returns the audit record for the request processed with the given stub
+func newAuditRecord(stub shim.ChaincodeStubInterface, function string) *AuditRecord {
+	record := &AuditRecord{
+		Time:     time.Now().UTC(),
+		Function: function,
+		TxId:     stub.GetTxID(),
+	}
+	if creator, err := stub.GetCreator(); err == nil {
+		record.CreatorMSPID, _ = utils.ExtractMSPID(creator)
+	}
+	return record
+}
+
+// setResponse records the enclave, the original proposal, the request hash and the rwset size of a chaincode response
+func (r *AuditRecord) setResponse(responseMsg *protos.ChaincodeResponseMessage) {
+	r.EnclaveId = responseMsg.GetEnclaveId()
+	r.RequestHash = hex.EncodeToString(responseMsg.GetChaincodeRequestMessageHash())
+	if proposal := responseMsg.GetProposal(); proposal != nil {
+		r.ProposalTxId, _, _ = utils.GetTxIdAndCreatorFromSignedProposal(proposal)
+	}
+	r.Reads = len(responseMsg.GetFpcRwSet().GetRwSet().GetReads())
+	r.Writes = len(responseMsg.GetFpcRwSet().GetRwSet().GetWrites())
+}
+
+// audit writes the audit record of a request with the given response and failure reason. If the record cannot be
+// written, a successful response is turned into an error, so that no request succeeds without an audit record.
+func (t *EnclaveChaincode) audit(record *AuditRecord, resp pb.Response, reason string) (pb.Response, string) {
+	if t.Audit == nil {
+		return resp, reason
+	}
+
+	record.Outcome = "success"
+	if resp.Status != shim.OK {
+		record.Outcome = "failure"
+		record.Reason = reason
+	}
+
+	if err := t.Audit.Write(record); err != nil {
+		logger.Errorf("cannot write audit record for tx %s: %s", record.TxId, err)
+		if resp.Status == shim.OK {
+			return shim.Error(fmt.Sprintf("cannot write audit record: %s", err)), ReasonAudit
+		}
+	}
+	return resp, reason
+}
@@ -0,0 +1,151 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chaincode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+type failingSink struct{}
+
+func (failingSink) Write(*AuditRecord) error {
+	return fmt.Errorf("disk full")
+}
+
+func readAuditRecords(t *testing.T, data []byte) []*AuditRecord {
+	var records []*AuditRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		record := &AuditRecord{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAuditSinkFromEnv(t *testing.T) {
+	t.Setenv(AuditLogEnv, "")
+	sink, err := AuditSinkFromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, sink)
+
+	t.Setenv(AuditLogEnv, filepath.Join(t.TempDir(), "missing", "audit.log"))
+	_, err = AuditSinkFromEnv()
+	assert.Error(t, err)
+
+	// records are appended to an existing log
+	path := filepath.Join(t.TempDir(), "audit.log")
+	assert.NoError(t, os.WriteFile(path, []byte("{\"tx_id\":\"someOldTxId\"}\n"), 0600))
+	t.Setenv(AuditLogEnv, path)
+	sink, err = AuditSinkFromEnv()
+	require.NoError(t, err)
+	assert.NoError(t, sink.Write(&AuditRecord{TxId: "someTxId"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	records := readAuditRecords(t, data)
+	require.Len(t, records, 2)
+	assert.Equal(t, "someOldTxId", records[0].TxId)
+	assert.Equal(t, "someTxId", records[1].TxId)
+}
+
+func TestAudit(t *testing.T) {
+	stub := &fakes.ChaincodeStub{}
+	stub.GetTxIDReturns("someTxId")
+	ec, val, ex, ercc := newFakes()
+	ecc := newECC(ec, val, ex, ercc)
+	log := &bytes.Buffer{}
+	ecc.Audit = NewJSONLinesSink(log)
+
+	expectedCCParams := &protos.CCParameters{ChaincodeId: "someCCID", ChannelId: "someChannel"}
+	serializedAttestedData, _ := anypb.New(&protos.AttestedData{
+		CcParams:   expectedCCParams,
+		HostParams: &protos.HostParameters{PeerMspId: "someMSP"},
+	})
+	expectedResp := &protos.ChaincodeResponseMessage{
+		EncryptedResponse:           []byte("someEncryptedResponse"),
+		ChaincodeRequestMessageHash: []byte{0xca, 0xfe},
+		EnclaveId:                   "someEnclaveId",
+		FpcRwSet: &protos.FPCKVSet{
+			RwSet: &kvrwset.KVRWSet{
+				Reads:  []*kvrwset.KVRead{{Key: "someSecretKey"}, {Key: "someOtherSecretKey"}},
+				Writes: []*kvrwset.KVWrite{{Key: "someSecretKey", Value: []byte("someSecretValue")}},
+			},
+		},
+	}
+	ex.GetChaincodeParamsReturns(expectedCCParams, nil)
+	ex.GetChaincodeResponseMessagesReturns(&protos.SignedChaincodeResponseMessage{}, expectedResp, nil)
+	ex.GetEndorserMSPIDReturns("someMSP", nil)
+	ercc.QueryEnclaveCredentialsReturns(&protos.Credentials{SerializedAttestedData: serializedAttestedData}, nil)
+
+	// successful endorse
+	stub.GetFunctionAndParametersReturns("__endorse", nil)
+	r := ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+
+	// failed endorse
+	val.ValidateReturns(fmt.Errorf("invalid signature"))
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.ERROR, r.Status)
+
+	// successful initEnclave
+	stub.GetFunctionAndParametersReturns("__initEnclave", nil)
+	ex.GetInitEnclaveMessageReturns(&protos.InitEnclaveMessage{}, nil)
+	ex.GetHostParamsReturns(&protos.HostParameters{}, nil)
+	ec.InitReturns([]byte("someCredentials"), nil)
+	ec.GetEnclaveIdReturns("someNewEnclaveId", nil)
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+
+	// no plaintext, state keys or values in the audit log
+	assert.NotContains(t, log.String(), "someSecret")
+
+	records := readAuditRecords(t, log.Bytes())
+	require.Len(t, records, 3)
+
+	assert.Equal(t, "__endorse", records[0].Function)
+	assert.Equal(t, "someTxId", records[0].TxId)
+	assert.Equal(t, "someEnclaveId", records[0].EnclaveId)
+	assert.Equal(t, "someCCID", records[0].CCParams.GetChaincodeId())
+	assert.Equal(t, "cafe", records[0].RequestHash)
+	assert.Equal(t, 2, records[0].Reads)
+	assert.Equal(t, 1, records[0].Writes)
+	assert.Equal(t, "success", records[0].Outcome)
+	assert.Empty(t, records[0].Reason)
+
+	assert.Equal(t, "__endorse", records[1].Function)
+	assert.Equal(t, "failure", records[1].Outcome)
+	assert.Equal(t, ReasonSignature, records[1].Reason)
+
+	assert.Equal(t, "__initEnclave", records[2].Function)
+	assert.Equal(t, "someNewEnclaveId", records[2].EnclaveId)
+	assert.Equal(t, "someCCID", records[2].CCParams.GetChaincodeId())
+	assert.Equal(t, "success", records[2].Outcome)
+
+	// requests do not succeed without audit record
+	ecc.Audit = failingSink{}
+	r = ecc.Invoke(stub)
+	expectError(t, "cannot write audit record: disk full", r)
+	stub.GetFunctionAndParametersReturns("__endorse", nil)
+	val.ValidateReturns(nil)
+	r = ecc.Invoke(stub)
+	expectError(t, "cannot write audit record: disk full", r)
+}
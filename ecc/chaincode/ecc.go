@@ -8,7 +8,10 @@ package chaincode
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/ercc"
@@ -18,6 +21,7 @@ import (
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
 )
 
 var logger = flogging.MustGetLogger("ecc")
@@ -28,8 +32,30 @@ type EnclaveChaincode struct {
 	Validator endorsement.Validation
 	Extractor Extractors
 	Ercc      ercc.Stub
+	// AllowedHostMSPIDs lists the MSP IDs whose enclaves may be endorsed by this peer, in addition to the
+	// enclaves hosted by the peer's own org
+	AllowedHostMSPIDs []string
+	// Metrics are the metrics of the enclave chaincode; if nil, metrics are disabled
+	Metrics *Metrics
+	// Audit stores an audit record for every __endorse and __initEnclave; if nil, no audit records are written
+	Audit AuditSink
+
+	// restoreMu guards restoreState and info
+	restoreMu    sync.Mutex
+	restoreState restoreState
+	info         *enclaveInfo
+	counters     counters
 }
 
+// restoreState tracks whether the enclave was restored from its sealed state and checked against ercc
+type restoreState int
+
+const (
+	notRestored restoreState = iota
+	restoredUnverified
+	restoredVerified
+)
+
 // Init sets the chaincode state to "init"
 func (t *EnclaveChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
 	return shim.Success(nil)
@@ -40,6 +66,16 @@ func (t *EnclaveChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response
 	function, _ := stub.GetFunctionAndParameters()
 	logger.Infof("Invoke is running [%s]", function)
 
+	switch function {
+	case "__initEnclave", "__invoke", "__getEnclaveId":
+		// these functions use the enclave hosted by this peer; if it was restored, check it first
+		if err := t.verifyRestoredEnclave(stub); err != nil {
+			errMsg := fmt.Sprintf("cannot verify restored enclave: %s", err.Error())
+			logger.Errorf(errMsg)
+			return shim.Error(errMsg)
+		}
+	}
+
 	switch function {
 	case "__initEnclave":
 		return t.initEnclave(stub)
@@ -47,17 +83,108 @@ func (t *EnclaveChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response
 		return t.invoke(stub)
 	case "__endorse":
 		return t.endorse(stub)
+	case "__getEnclaveId":
+		return t.getEnclaveId(stub)
+	case "__status":
+		return t.getStatus(stub)
 	default:
 		return shim.Error("invalid invocation")
 	}
 }
 
-func (t *EnclaveChaincode) initEnclave(stub shim.ChaincodeStubInterface) pb.Response {
+// RestoreEnclave restores the enclave from its sealed state after a chaincode restart, if the enclave supports it
+// (see SealedEnclave). As ercc can only be queried within a transaction, the restored enclave is checked against its
+// registration at ercc with the next invocation that uses the enclave.
+func (t *EnclaveChaincode) RestoreEnclave() error {
+	sealedEnclave, ok := t.Enclave.(SealedEnclave)
+	if !ok {
+		return nil
+	}
+
+	restored, err := sealedEnclave.Restore()
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return nil
+	}
+
+	t.restoreMu.Lock()
+	defer t.restoreMu.Unlock()
+	t.restoreState = restoredUnverified
+	logger.Infof("enclave restored from sealed state")
+	return nil
+}
+
+// verifyRestoredEnclave checks that a restored enclave is registered at ercc for the current chaincode definition.
+// If not, the restored enclave is discarded and a new enclave must be initialized. If ercc cannot be queried, an error
+// is returned and the check is repeated with the next invocation.
+func (t *EnclaveChaincode) verifyRestoredEnclave(stub shim.ChaincodeStubInterface) error {
+	t.restoreMu.Lock()
+	defer t.restoreMu.Unlock()
+
+	if t.restoreState != restoredUnverified {
+		return nil
+	}
+
+	chaincodeParams, err := t.Extractor.GetChaincodeParams(stub)
+	if err != nil {
+		return errors.Wrap(err, "cannot extract chaincode params")
+	}
+
+	enclaveId, err := t.Enclave.GetEnclaveId()
+	if err != nil {
+		return errors.Wrap(err, "cannot get enclave id")
+	}
+
+	credentials, err := t.Ercc.QueryEnclaveCredentials(stub, chaincodeParams.ChannelId, chaincodeParams.ChaincodeId, enclaveId)
+	if err != nil {
+		return errors.Wrap(err, "cannot query enclave credentials")
+	}
+
+	var reason string
+	var attestedData *protos.AttestedData
+	if credentials == nil {
+		reason = "not registered"
+	} else if attestedData, err = utils.UnmarshalAttestedData(credentials.SerializedAttestedData); err != nil {
+		reason = fmt.Sprintf("invalid credentials: %s", err)
+	} else if utils.GetEnclaveId(attestedData) != enclaveId {
+		reason = "enclave id does not match credentials"
+	} else if !ccParamsMatch(attestedData.CcParams, chaincodeParams) {
+		reason = "registered for a different chaincode definition"
+	}
+
+	if reason != "" {
+		logger.Warningf("discarding restored enclave %s: %s", enclaveId, reason)
+		if err := t.Enclave.(SealedEnclave).Discard(); err != nil {
+			return errors.Wrapf(err, "cannot discard restored enclave %s", enclaveId)
+		}
+		t.restoreState = notRestored
+		return nil
+	}
+
+	logger.Infof("restored enclave %s matches registration at ercc", enclaveId)
+	t.restoreState = restoredVerified
+	t.info = &enclaveInfo{
+		ccParams:        attestedData.CcParams,
+		hostParams:      attestedData.HostParams,
+		attestationType: attestationType(credentials),
+	}
+	return nil
+}
+
+func (t *EnclaveChaincode) initEnclave(stub shim.ChaincodeStubInterface) (resp pb.Response) {
+	// reason is the failure reason reported in the audit record; empty on success
+	var reason string
+	record := newAuditRecord(stub, "__initEnclave")
+	defer func() { resp, _ = t.audit(record, resp, reason) }()
+
 	// extract all enclave inputs from invocation params
 	initMsg, err := t.Extractor.GetInitEnclaveMessage(stub)
 	if err != nil {
 		errMsg := fmt.Sprintf("getting initEnclave msg failed: %s", err.Error())
 		logger.Errorf(errMsg)
+		reason = ReasonBadRequest
 		return shim.Error(errMsg)
 	}
 
@@ -65,10 +192,13 @@ func (t *EnclaveChaincode) initEnclave(stub shim.ChaincodeStubInterface) pb.Resp
 	if err != nil {
 		errMsg := fmt.Sprintf("getting chaincode params failed: %s", err.Error())
 		logger.Errorf(errMsg)
+		reason = ReasonBadRequest
 		return shim.Error(errMsg)
 	}
+	record.CCParams = chaincodeParams
 	serializedChaincodeParams, err := protoutil.Marshal(chaincodeParams)
 	if err != nil {
+		reason = ReasonBadRequest
 		return shim.Error(err.Error())
 	}
 
@@ -76,21 +206,36 @@ func (t *EnclaveChaincode) initEnclave(stub shim.ChaincodeStubInterface) pb.Resp
 	if err != nil {
 		errMsg := fmt.Sprintf("getting host params failed: %s", err.Error())
 		logger.Errorf(errMsg)
+		reason = ReasonBadRequest
 		return shim.Error(errMsg)
 	}
 
 	serializedHostParams, err := protoutil.Marshal(hostParams)
 	if err != nil {
+		reason = ReasonBadRequest
 		return shim.Error(err.Error())
 	}
 
+	t.restoreMu.Lock()
+	restored := t.restoreState == restoredVerified
+	t.restoreMu.Unlock()
+	if restored {
+		errMsg := "enclave restored from sealed state is already registered"
+		logger.Errorf(errMsg)
+		reason = ReasonEnclaveState
+		return shim.Error(errMsg)
+	}
+
 	// main enclave initialization function
 	credentialsBytes, err := t.Enclave.Init(serializedChaincodeParams, serializedHostParams, initMsg.AttestationParams)
 	if err != nil {
 		errMsg := fmt.Sprintf("Enclave Init function failed: %s", err.Error())
 		logger.Errorf(errMsg)
+		reason = ReasonEnclaveError
 		return shim.Error(errMsg)
 	}
+	t.setEnclaveInfo(chaincodeParams, hostParams, unmarshalCredentials(credentialsBytes))
+	record.EnclaveId, _ = t.Enclave.GetEnclaveId()
 
 	// return credentials
 	return shim.Success([]byte(base64.StdEncoding.EncodeToString(credentialsBytes)))
@@ -99,19 +244,49 @@ func (t *EnclaveChaincode) initEnclave(stub shim.ChaincodeStubInterface) pb.Resp
 func (t *EnclaveChaincode) invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	var errMsg string
 
+	// reason is the failure reason reported in the metrics; empty on success
+	var reason string
+	m := t.getMetrics()
+	defer func(start time.Time) { m.observeRequest("__invoke", start, reason) }(time.Now())
+
 	serializedChaincodeRequest, err := t.Extractor.GetSerializedChaincodeRequest(stub)
 	if err != nil {
 		errMsg = fmt.Sprintf("cannot get chaincode request message from input: %s", err.Error())
 		logger.Errorf(errMsg)
+		reason = ReasonBadRequest
 		return shim.Error(errMsg)
 	}
+	m.RequestSize.Observe(float64(len(serializedChaincodeRequest)))
 
+	t.counters.begin()
 	signedChaincodeResponseMessage, errInvoke := t.Enclave.ChaincodeInvoke(stub, serializedChaincodeRequest)
+	t.counters.end(errInvoke)
 	if errInvoke != nil {
 		errMsg = fmt.Sprintf("t.Enclave.Invoke failed: %s", errInvoke)
 		logger.Errorf(errMsg)
+
+		// there is no enclave response to forward if the enclave is not ready, the enclave failed, or the response
+		// did not fit into the buffer
+		var enclaveErr *EnclaveError
+		var stateErr *StateError
+		var keyTransportErr *KeyTransportError
+		if IsBufferTooSmallError(errInvoke) {
+			reason = ReasonBufferTooSmall
+			return shim.Error(fmt.Sprintf("enclave response too large, consider increasing the max response buffer size: %s", errInvoke))
+		} else if errors.As(errInvoke, &enclaveErr) {
+			reason = ReasonEnclaveError
+			return shim.Error(errMsg)
+		} else if errors.As(errInvoke, &stateErr) {
+			reason = ReasonEnclaveState
+			return shim.Error(errMsg)
+		} else if errors.As(errInvoke, &keyTransportErr) {
+			reason = ReasonStaleKey
+			return pb.Response{Status: endorsement.StaleKeyStatus, Message: errMsg}
+		}
 		// likely a chaincode error, so we still want response go back ...
+		reason = ReasonChaincodeError
 	}
+	m.ResponseSize.Observe(float64(len(signedChaincodeResponseMessage)))
 
 	signedChaincodeResponseMessageB64 := []byte(base64.StdEncoding.EncodeToString(signedChaincodeResponseMessage))
 	logger.Debugf("base64-encoded response message: '%s'", signedChaincodeResponseMessageB64)
@@ -134,50 +309,113 @@ func (t *EnclaveChaincode) invoke(stub shim.ChaincodeStubInterface) pb.Response
 	return response
 }
 
-func (t *EnclaveChaincode) endorse(stub shim.ChaincodeStubInterface) pb.Response {
+// getEnclaveId returns the id of the enclave hosted by this peer; clients use it to map peers to the enclaves
+// registered at ercc
+func (t *EnclaveChaincode) getEnclaveId(stub shim.ChaincodeStubInterface) pb.Response {
+	enclaveId, err := t.Enclave.GetEnclaveId()
+	if err != nil {
+		errMsg := fmt.Sprintf("cannot get enclave id: %s", err.Error())
+		logger.Errorf(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	return shim.Success([]byte(enclaveId))
+}
+
+// getStatus returns the JSON-encoded Status of the enclave chaincode, e.g., for health checks
+func (t *EnclaveChaincode) getStatus(stub shim.ChaincodeStubInterface) pb.Response {
+	status, err := json.Marshal(t.status())
+	if err != nil {
+		errMsg := fmt.Sprintf("cannot marshal status: %s", err.Error())
+		logger.Errorf(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	return shim.Success(status)
+}
+
+func (t *EnclaveChaincode) endorse(stub shim.ChaincodeStubInterface) (resp pb.Response) {
+	// reason is the failure reason reported in the metrics and the audit record; empty on success
+	var reason string
+	record := newAuditRecord(stub, "__endorse")
+	m := t.getMetrics()
+	defer func(start time.Time) {
+		resp, reason = t.audit(record, resp, reason)
+		m.observeRequest("__endorse", start, reason)
+	}(time.Now())
 
 	chaincodeParams, err := t.Extractor.GetChaincodeParams(stub)
 	if err != nil {
 		errMsg := fmt.Sprintf("cannot extract chaincode params: %s", err.Error())
 		logger.Errorf(errMsg)
+		reason = ReasonBadRequest
 		return shim.Error(errMsg)
 	}
+	record.CCParams = chaincodeParams
 
 	signedResponseMsg, responseMsg, err := t.Extractor.GetChaincodeResponseMessages(stub)
 	if err != nil {
 		errMsg := fmt.Sprintf("cannot extract chaincode response message: %s", err.Error())
 		logger.Errorf(errMsg)
+		reason = ReasonBadRequest
 		return shim.Error(errMsg)
 	}
+	record.setResponse(responseMsg)
 
 	logger.Infof("try to get credentials from ERCC for channel: %s ccId: %s EnclaveId: %s ", chaincodeParams.ChannelId, chaincodeParams.ChaincodeId, responseMsg.EnclaveId)
 
 	// get corresponding enclave credentials from ercc
 	credentials, err := t.Ercc.QueryEnclaveCredentials(stub, chaincodeParams.ChannelId, chaincodeParams.ChaincodeId, responseMsg.EnclaveId)
 	if err != nil {
+		reason = ReasonErcc
 		return shim.Error(err.Error())
 	}
 	if credentials == nil {
+		reason = ReasonNotRegistered
 		return shim.Error(fmt.Sprintf("no credentials found for enclaveId = %s", responseMsg.EnclaveId))
 	}
 
 	attestedData, err := utils.UnmarshalAttestedData(credentials.SerializedAttestedData)
 	if err != nil {
+		reason = ReasonErcc
 		return shim.Error(err.Error())
 	}
 
 	// check cc params match credentials
 	// check cc params chaincode def
 	if !ccParamsMatch(attestedData.CcParams, chaincodeParams) {
-		return shim.Error("ccParams don't match")
+		reason = ReasonCCParams
+		return pb.Response{Status: endorsement.StaleKeyStatus, Message: "ccParams don't match"}
 	}
 
-	// check cc param.MSPID matches MSPID of endorser (Post-MVP)
+	// check that the enclave is hosted by the org of this endorser
+	endorserMSPID, err := t.Extractor.GetEndorserMSPID(stub)
+	if err != nil {
+		errMsg := fmt.Sprintf("cannot get endorser MSP ID: %s", err.Error())
+		logger.Errorf(errMsg)
+		reason = ReasonBadRequest
+		return shim.Error(errMsg)
+	}
+	if !t.hostMSPIDAllowed(endorserMSPID, attestedData.HostParams.GetPeerMspId()) {
+		errMsg := fmt.Sprintf("endorser MSP ID (%s) does not match enclave host MSP ID (%s)", endorserMSPID, attestedData.HostParams.GetPeerMspId())
+		logger.Errorf(errMsg)
+		reason = ReasonHostMSP
+		return shim.Error(errMsg)
+	}
 
 	// validate enclave endorsement signature
 	logger.Debugf("Validating endorsement")
 	err = t.Validator.Validate(signedResponseMsg, attestedData)
 	if err != nil {
+		reason = ReasonSignature
+		return shim.Error(err.Error())
+	}
+
+	// bind this endorsement to the original proposal and prevent replays of the response
+	logger.Debugf("Checking original proposal")
+	err = t.Validator.CheckAndRecordProposal(stub, responseMsg.Proposal)
+	if err != nil {
+		reason = ReasonProposal
 		return shim.Error(err.Error())
 	}
 
@@ -185,13 +423,36 @@ func (t *EnclaveChaincode) endorse(stub shim.ChaincodeStubInterface) pb.Response
 	logger.Debugf("Replaying rwset")
 	err = t.Validator.ReplayReadWrites(stub, responseMsg.FpcRwSet)
 	if err != nil {
+		if endorsement.IsStaleReadError(err) {
+			// the client re-executes the transaction on this status, see endorsement.StaleReadStatus
+			reason = ReasonStaleRead
+			return pb.Response{Status: endorsement.StaleReadStatus, Message: err.Error()}
+		}
+		reason = ReasonReplay
 		return shim.Error(err.Error())
 	}
+	m.RwsetReads.Observe(float64(len(responseMsg.GetFpcRwSet().GetRwSet().GetReads())))
+	m.RwsetWrites.Observe(float64(len(responseMsg.GetFpcRwSet().GetRwSet().GetWrites())))
 
 	logger.Debugf("Endorsement successful")
 	return shim.Success([]byte("OK")) // make sure we have a non-empty return on success so we can distinguish success from failure in cli ...
 }
 
+func (t *EnclaveChaincode) hostMSPIDAllowed(endorserMSPID, hostMSPID string) bool {
+	if len(hostMSPID) == 0 {
+		return false
+	}
+	if hostMSPID == endorserMSPID {
+		return true
+	}
+	for _, mspId := range t.AllowedHostMSPIDs {
+		if hostMSPID == mspId {
+			return true
+		}
+	}
+	return false
+}
+
 func ccParamsMatch(expected, actual *protos.CCParameters) bool {
 	return expected.ChaincodeId == actual.ChaincodeId &&
 		expected.ChannelId == actual.ChannelId &&
@@ -2,6 +2,7 @@ package chaincode
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -10,8 +11,12 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/fakes"
 	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/metrics/metricsfakes"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
@@ -23,6 +28,13 @@ type enclaveStub interface {
 	Enclave
 }
 
+//counterfeiter:generate -o fakes/sealedenclave.go -fake-name SealedEnclaveStub . sealedEnclaveStub
+//lint:ignore U1000 This is just used to generate fake
+type sealedEnclaveStub interface {
+	Enclave
+	SealedEnclave
+}
+
 //counterfeiter:generate -o fakes/utils.go -fake-name Extractors . extractors
 //lint:ignore U1000 This is just used to generate fake
 type extractors interface {
@@ -145,6 +157,34 @@ func TestInvokeEnclave(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, expectedResp, p)
 
+	// response does not fit into the enclave response buffer
+	bufferErr := &BufferTooSmallError{Size: 1024, RequiredSize: 4096, MaxSize: 2048}
+	ec.ChaincodeInvokeReturns(nil, bufferErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("enclave response too large, consider increasing the max response buffer size: %s", bufferErr), r)
+	assert.Empty(t, r.Payload)
+
+	// enclave failure
+	enclaveErr := &EnclaveError{Op: "invoke", Code: 1}
+	ec.ChaincodeInvokeReturns(nil, enclaveErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("t.Enclave.Invoke failed: %s", enclaveErr), r)
+	assert.Empty(t, r.Payload)
+
+	// enclave not ready
+	stateErr := &StateError{Op: "invoke", State: EnclaveFailed, Reason: enclaveErr}
+	ec.ChaincodeInvokeReturns(nil, stateErr)
+	r = ecc.Invoke(stub)
+	expectError(t, "t.Enclave.Invoke failed: cannot invoke enclave in state failed: enclave invoke failed with error code 1 (0x1)", r)
+	assert.Empty(t, r.Payload)
+
+	// request encrypted with another chaincode encryption key
+	ec.ChaincodeInvokeReturns(nil, errors.Wrap(&KeyTransportError{}, "cannot extract keyTransportMessage"))
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, endorsement.StaleKeyStatus, r.Status)
+	assert.Equal(t, "t.Enclave.Invoke failed: cannot extract keyTransportMessage: decryption of key transport message failed", r.Message)
+	assert.Empty(t, r.Payload)
+
 	// no error
 	ex.GetSerializedChaincodeRequestReturns([]byte("someChaincodeRequest"), nil)
 	ec.ChaincodeInvokeReturns(expectedResp, nil)
@@ -153,11 +193,176 @@ func TestInvokeEnclave(t *testing.T) {
 	p, err = base64.StdEncoding.DecodeString(string(r.Payload))
 	assert.NoError(t, err)
 	assert.EqualValues(t, expectedResp, p)
-	s, scr := ec.ChaincodeInvokeArgsForCall(1)
+	s, scr := ec.ChaincodeInvokeArgsForCall(5)
 	assert.Equal(t, stub, s)
 	assert.Equal(t, []byte("someChaincodeRequest"), scr)
 }
 
+func TestGetEnclaveId(t *testing.T) {
+	stub := &fakes.ChaincodeStub{}
+	stub.GetFunctionAndParametersReturns("__getEnclaveId", nil)
+	ec, _, _, _ := newFakes()
+	ecc := newECC(ec, nil, nil, nil)
+
+	// enclave not initialized
+	ec.GetEnclaveIdReturns("", &StateError{Op: "query", State: EnclaveUninitialized})
+	r := ecc.Invoke(stub)
+	expectError(t, "cannot get enclave id: cannot query enclave in state uninitialized", r)
+
+	ec.GetEnclaveIdReturns("someEnclaveId", nil)
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	assert.EqualValues(t, []byte("someEnclaveId"), r.Payload)
+}
+
+func TestRestoreEnclave(t *testing.T) {
+	stub := &fakes.ChaincodeStub{}
+	stub.GetFunctionAndParametersReturns("__getEnclaveId", nil)
+	ec := &fakes.SealedEnclaveStub{}
+	ex := &fakes.Extractors{}
+	ercc := &fakes.ErccStub{}
+	ecc := &EnclaveChaincode{Enclave: ec, Extractor: ex, Ercc: ercc}
+	expectedErr := fmt.Errorf("some error")
+	expectedCCParams := &protos.CCParameters{ChaincodeId: "SomeChaincodeId", ChannelId: "SomeChannel"}
+	enclaveVk := []byte("someEnclaveVk")
+	enclaveId := utils.GetEnclaveId(&protos.AttestedData{EnclaveVk: enclaveVk})
+	ec.GetEnclaveIdReturns(enclaveId, nil)
+	ex.GetChaincodeParamsReturns(expectedCCParams, nil)
+
+	// enclaves that cannot be restored are ignored
+	assert.NoError(t, newECC(&fakes.EnclaveStub{}, nil, nil, nil).RestoreEnclave())
+
+	// error restoring
+	ec.RestoreReturns(false, expectedErr)
+	assert.EqualError(t, ecc.RestoreEnclave(), expectedErr.Error())
+
+	// no sealed state, no verification
+	ec.RestoreReturns(false, nil)
+	assert.NoError(t, ecc.RestoreEnclave())
+	r := ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	assert.Equal(t, 0, ercc.QueryEnclaveCredentialsCallCount())
+
+	// restored, but ercc cannot be queried; verification is repeated with the next invocation
+	ec.RestoreReturns(true, nil)
+	assert.NoError(t, ecc.RestoreEnclave())
+	ercc.QueryEnclaveCredentialsReturns(nil, expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("cannot verify restored enclave: cannot query enclave credentials: %s", expectedErr), r)
+
+	// restored enclave is not registered and discarded
+	ercc.QueryEnclaveCredentialsReturns(nil, nil)
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	assert.Equal(t, 1, ec.DiscardCallCount())
+	_, _, _, queriedEnclaveId := ercc.QueryEnclaveCredentialsArgsForCall(1)
+	assert.Equal(t, enclaveId, queriedEnclaveId)
+
+	// restored enclave is registered for a different chaincode definition and discarded
+	assert.NoError(t, ecc.RestoreEnclave())
+	serializedAttestedData, _ := anypb.New(&protos.AttestedData{
+		EnclaveVk: enclaveVk,
+		CcParams:  &protos.CCParameters{ChaincodeId: "SomeChaincodeId", ChannelId: "SomeChannel", Sequence: 2},
+	})
+	ercc.QueryEnclaveCredentialsReturns(&protos.Credentials{SerializedAttestedData: serializedAttestedData}, nil)
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	assert.Equal(t, 2, ec.DiscardCallCount())
+
+	// error discarding
+	assert.NoError(t, ecc.RestoreEnclave())
+	ec.DiscardReturns(expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("cannot verify restored enclave: cannot discard restored enclave %s: %s", enclaveId, expectedErr), r)
+	ec.DiscardReturns(nil)
+
+	// restored enclave matches its registration
+	serializedAttestedData, _ = anypb.New(&protos.AttestedData{EnclaveVk: enclaveVk, CcParams: expectedCCParams})
+	ercc.QueryEnclaveCredentialsReturns(&protos.Credentials{SerializedAttestedData: serializedAttestedData}, nil)
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	assert.Equal(t, 3, ec.DiscardCallCount())
+	queryCount := ercc.QueryEnclaveCredentialsCallCount()
+
+	// verified only once
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	assert.Equal(t, queryCount, ercc.QueryEnclaveCredentialsCallCount())
+
+	// a registered restored enclave cannot be initialized again
+	stub.GetFunctionAndParametersReturns("__initEnclave", nil)
+	ex.GetInitEnclaveMessageReturns(&protos.InitEnclaveMessage{}, nil)
+	ex.GetHostParamsReturns(&protos.HostParameters{}, nil)
+	r = ecc.Invoke(stub)
+	expectError(t, "enclave restored from sealed state is already registered", r)
+	assert.Equal(t, 0, ec.InitCallCount())
+}
+
+// reportingEnclaveStub is an enclave stub that reports its status
+type reportingEnclaveStub struct {
+	*fakes.EnclaveStub
+	state EnclaveState
+}
+
+func (e *reportingEnclaveStub) State() EnclaveState { return e.state }
+func (e *reportingEnclaveStub) Flavor() string      { return FlavorSGX }
+func (e *reportingEnclaveStub) MaxConcurrency() int { return 8 }
+
+func TestStatus(t *testing.T) {
+	stub := &fakes.ChaincodeStub{}
+	ec, _, ex, _ := newFakes()
+	ecc := newECC(ec, nil, ex, nil)
+	getStatus := func() *Status {
+		stub.GetFunctionAndParametersReturns("__status", nil)
+		r := ecc.Invoke(stub)
+		assert.EqualValues(t, shim.OK, r.Status)
+		status := &Status{}
+		assert.NoError(t, json.Unmarshal(r.Payload, status))
+		return status
+	}
+
+	// enclave not initialized
+	ec.GetEnclaveIdReturns("", fmt.Errorf("enclave not yet initialized"))
+	assert.Equal(t, &Status{State: "uninitialized", Flavor: "unknown"}, getStatus())
+
+	// initialize enclave
+	expectedCCParams := &protos.CCParameters{ChaincodeId: "SomeChaincodeId", ChannelId: "SomeChannel", Version: "1.0", Sequence: 1}
+	expectedHostParams := &protos.HostParameters{PeerMspId: "SomeMSP", PeerEndpoint: "peer0:7051"}
+	ex.GetInitEnclaveMessageReturns(&protos.InitEnclaveMessage{}, nil)
+	ex.GetChaincodeParamsReturns(expectedCCParams, nil)
+	ex.GetHostParamsReturns(expectedHostParams, nil)
+	ec.InitReturns(utils.MarshalOrPanic(&protos.Credentials{Attestation: []byte(`{"attestation_type":"simulated","attestation":"MA=="}`)}), nil)
+	stub.GetFunctionAndParametersReturns("__initEnclave", nil)
+	assert.EqualValues(t, shim.OK, ecc.Invoke(stub).Status)
+	ec.GetEnclaveIdReturns("someEnclaveId", nil)
+
+	// invocations
+	ex.GetSerializedChaincodeRequestReturns([]byte("someChaincodeRequest"), nil)
+	stub.GetFunctionAndParametersReturns("__invoke", nil)
+	ec.ChaincodeInvokeReturns([]byte("someResponse"), nil)
+	ecc.Invoke(stub)
+	ecc.Invoke(stub)
+	ec.ChaincodeInvokeReturns(nil, fmt.Errorf("some error"))
+	ecc.Invoke(stub)
+
+	status := getStatus()
+	assert.Equal(t, "ready", status.State)
+	assert.Equal(t, "someEnclaveId", status.EnclaveId)
+	assert.Equal(t, "unknown", status.Flavor)
+	assert.Equal(t, "simulated", status.AttestationType)
+	assert.Empty(t, status.Restored)
+	assert.True(t, proto.Equal(expectedCCParams, status.CCParams))
+	assert.True(t, proto.Equal(expectedHostParams, status.HostParams))
+	assert.Equal(t, StatusCounters{Invocations: 3, Failures: 1}, status.Counters)
+
+	// enclave reports its state and flavor
+	ecc.Enclave = &reportingEnclaveStub{EnclaveStub: ec, state: EnclaveFailed}
+	status = getStatus()
+	assert.Equal(t, "failed", status.State)
+	assert.Equal(t, FlavorSGX, status.Flavor)
+	assert.Equal(t, 8, status.Counters.MaxConcurrency)
+}
+
 func TestEndorse(t *testing.T) {
 	stub := &fakes.ChaincodeStub{}
 	stub.GetFunctionAndParametersReturns("__endorse", nil)
@@ -225,12 +430,14 @@ func TestEndorse(t *testing.T) {
 	ex.GetChaincodeResponseMessagesReturns(expectedSignedResp, expectedResp, nil)
 	ercc.QueryEnclaveCredentialsReturns(expectedCred, nil)
 	r = ecc.Invoke(stub)
-	expectError(t, "ccParams don't match", r)
+	assert.EqualValues(t, endorsement.StaleKeyStatus, r.Status)
+	assert.Equal(t, "ccParams don't match", r.Message)
 
-	// validate error
+	// error getting endorser mspid
 	serializedAttestedData, _ = anypb.New(
 		&protos.AttestedData{
-			CcParams: expectedCCParams,
+			CcParams:   expectedCCParams,
+			HostParams: &protos.HostParameters{PeerMspId: "someMSP"},
 		})
 	expectedCred = &protos.Credentials{
 		SerializedAttestedData: serializedAttestedData,
@@ -238,15 +445,48 @@ func TestEndorse(t *testing.T) {
 	ex.GetChaincodeParamsReturns(expectedCCParams, nil)
 	ex.GetChaincodeResponseMessagesReturns(expectedSignedResp, expectedResp, nil)
 	ercc.QueryEnclaveCredentialsReturns(expectedCred, nil)
+	ex.GetEndorserMSPIDReturns("", expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, fmt.Sprintf("cannot get endorser MSP ID: %s", expectedErr), r)
+
+	// endorser mspid does not match enclave host mspid
+	ex.GetEndorserMSPIDReturns("someOtherMSP", nil)
+	r = ecc.Invoke(stub)
+	expectError(t, "endorser MSP ID (someOtherMSP) does not match enclave host MSP ID (someMSP)", r)
+	assert.Equal(t, 0, val.ValidateCallCount())
+
+	// enclave host mspid is in the allowed set
+	ecc.AllowedHostMSPIDs = []string{"yetAnotherMSP", "someMSP"}
+	val.ValidateReturns(expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, expectedErr.Error(), r)
+	ecc.AllowedHostMSPIDs = nil
+
+	// validate error
+	ex.GetEndorserMSPIDReturns("someMSP", nil)
+	ex.GetChaincodeParamsReturns(expectedCCParams, nil)
+	ex.GetChaincodeResponseMessagesReturns(expectedSignedResp, expectedResp, nil)
+	ercc.QueryEnclaveCredentialsReturns(expectedCred, nil)
 	val.ValidateReturns(expectedErr)
 	r = ecc.Invoke(stub)
 	expectError(t, expectedErr.Error(), r)
 
+	// error when checking original proposal
+	ex.GetChaincodeParamsReturns(expectedCCParams, nil)
+	ex.GetChaincodeResponseMessagesReturns(expectedSignedResp, expectedResp, nil)
+	ercc.QueryEnclaveCredentialsReturns(expectedCred, nil)
+	val.ValidateReturns(nil)
+	val.CheckAndRecordProposalReturns(expectedErr)
+	r = ecc.Invoke(stub)
+	expectError(t, expectedErr.Error(), r)
+	assert.Equal(t, 0, val.ReplayReadWritesCallCount())
+
 	// error when checking rwset
 	ex.GetChaincodeParamsReturns(expectedCCParams, nil)
 	ex.GetChaincodeResponseMessagesReturns(expectedSignedResp, expectedResp, nil)
 	ercc.QueryEnclaveCredentialsReturns(expectedCred, nil)
 	val.ValidateReturns(nil)
+	val.CheckAndRecordProposalReturns(nil)
 	val.ReplayReadWritesReturns(expectedErr)
 	r = ecc.Invoke(stub)
 	expectError(t, expectedErr.Error(), r)
@@ -262,6 +502,73 @@ func TestEndorse(t *testing.T) {
 	assert.EqualValues(t, []byte("OK"), r.Payload)
 }
 
+func newFakeMetrics() (*Metrics, *metricsfakes.Counter, *metricsfakes.Histogram) {
+	failures := &metricsfakes.Counter{}
+	failures.WithReturns(failures)
+	histogram := &metricsfakes.Histogram{}
+	histogram.WithReturns(histogram)
+	return &Metrics{
+		RequestDuration:     histogram,
+		RequestFailures:     failures,
+		RequestSize:         histogram,
+		ResponseSize:        histogram,
+		RwsetReads:          histogram,
+		RwsetWrites:         histogram,
+		EnclaveWaitDuration: histogram,
+	}, failures, histogram
+}
+
+func TestMetrics(t *testing.T) {
+	stub := &fakes.ChaincodeStub{}
+	ec, val, ex, ercc := newFakes()
+	ecc := newECC(ec, val, ex, ercc)
+	m, failures, histogram := newFakeMetrics()
+	ecc.Metrics = m
+
+	// successful invoke
+	stub.GetFunctionAndParametersReturns("__invoke", nil)
+	ex.GetSerializedChaincodeRequestReturns([]byte("someChaincodeRequest"), nil)
+	ec.ChaincodeInvokeReturns([]byte("someResponse"), nil)
+	r := ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	assert.Equal(t, 0, failures.AddCallCount())
+	assert.Equal(t, []string{"function", "__invoke", "status", "success"}, histogram.WithArgsForCall(histogram.WithCallCount()-1))
+	assert.Equal(t, float64(len("someChaincodeRequest")), histogram.ObserveArgsForCall(0))
+	assert.Equal(t, float64(len("someResponse")), histogram.ObserveArgsForCall(1))
+
+	// enclave not ready
+	ec.ChaincodeInvokeReturns(nil, &StateError{Op: "invoke", State: EnclaveUninitialized})
+	ecc.Invoke(stub)
+	assert.Equal(t, 1, failures.AddCallCount())
+	assert.Equal(t, []string{"function", "__invoke", "reason", ReasonEnclaveState}, failures.WithArgsForCall(0))
+	assert.Equal(t, []string{"function", "__invoke", "status", "failure"}, histogram.WithArgsForCall(histogram.WithCallCount()-1))
+
+	// stale read in endorse
+	stub.GetFunctionAndParametersReturns("__endorse", nil)
+	expectedCCParams := &protos.CCParameters{ChaincodeId: "someCCID", ChannelId: "someChannel"}
+	serializedAttestedData, _ := anypb.New(&protos.AttestedData{
+		CcParams:   expectedCCParams,
+		HostParams: &protos.HostParameters{PeerMspId: "someMSP"},
+	})
+	ex.GetChaincodeParamsReturns(expectedCCParams, nil)
+	ex.GetChaincodeResponseMessagesReturns(&protos.SignedChaincodeResponseMessage{}, &protos.ChaincodeResponseMessage{EnclaveId: "someEnclaveId"}, nil)
+	ex.GetEndorserMSPIDReturns("someMSP", nil)
+	ercc.QueryEnclaveCredentialsReturns(&protos.Credentials{SerializedAttestedData: serializedAttestedData}, nil)
+	val.ReplayReadWritesReturns(&endorsement.StaleReadError{Key: "someKey"})
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, endorsement.StaleReadStatus, r.Status)
+	assert.Equal(t, "stale read of key someKey: value hash mismatch", r.Message)
+	assert.Equal(t, 2, failures.AddCallCount())
+	assert.Equal(t, []string{"function", "__endorse", "reason", ReasonStaleRead}, failures.WithArgsForCall(1))
+
+	// successful endorse
+	val.ReplayReadWritesReturns(nil)
+	r = ecc.Invoke(stub)
+	assert.EqualValues(t, shim.OK, r.Status)
+	assert.Equal(t, 2, failures.AddCallCount())
+	assert.Equal(t, []string{"function", "__endorse", "status", "success"}, histogram.WithArgsForCall(histogram.WithCallCount()-1))
+}
+
 func expectError(t *testing.T, errorMsg string, r peer.Response) {
 	assert.EqualValues(t, shim.ERROR, r.Status)
 	assert.EqualValues(t, errorMsg, r.Message)
@@ -8,6 +8,8 @@ SPDX-License-Identifier: Apache-2.0
 package chaincode
 
 import (
+	"fmt"
+
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 )
 
@@ -39,3 +41,70 @@ type Enclave interface {
 	// chaincodeRequestMessage and chaincodeResponseMessage are serialized protobuf
 	ChaincodeInvoke(stub shim.ChaincodeStubInterface, chaincodeRequestMessage []byte) (chaincodeResponseMessage []byte, err error)
 }
+
+// EnclaveState is the lifecycle state of an enclave
+type EnclaveState int
+
+const (
+	// EnclaveUninitialized means the enclave has not been created yet
+	EnclaveUninitialized EnclaveState = iota
+	// EnclaveInitializing means the enclave is being created
+	EnclaveInitializing
+	// EnclaveReady means the enclave is created and can process invocations
+	EnclaveReady
+	// EnclaveFailed means the enclave creation or teardown failed; the enclave can be initialized again
+	EnclaveFailed
+	// EnclaveDestroyed means the enclave has been torn down; the enclave can be initialized again
+	EnclaveDestroyed
+)
+
+func (s EnclaveState) String() string {
+	switch s {
+	case EnclaveUninitialized:
+		return "uninitialized"
+	case EnclaveInitializing:
+		return "initializing"
+	case EnclaveReady:
+		return "ready"
+	case EnclaveFailed:
+		return "failed"
+	case EnclaveDestroyed:
+		return "destroyed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// SealedEnclave is implemented by enclaves that seal their state to local storage, so that the enclave can be
+// restored after a chaincode restart
+type SealedEnclave interface {
+	// Restore restores the enclave from its sealed state; it returns false if there is no sealed state
+	Restore() (restored bool, err error)
+
+	// Discard tears down the enclave and deletes its sealed state
+	Discard() error
+}
+
+// Build flavors of an enclave, as reported by EnclaveStatusReporter
+const (
+	// FlavorSGX is the C++ chaincode running in an SGX enclave
+	FlavorSGX = "sgx"
+	// FlavorMock is the mock enclave (mock_ecc build tag)
+	FlavorMock = "mock"
+	// FlavorGo is a Go chaincode (ecc_go)
+	FlavorGo = "ecc_go"
+	// FlavorSKVS is a Go chaincode using the single key-value store (ecc_go with SKVS)
+	FlavorSKVS = "skvs"
+)
+
+// EnclaveStatusReporter is implemented by enclaves that report their state and build flavor, see __status
+type EnclaveStatusReporter interface {
+	// State returns the lifecycle state of the enclave
+	State() EnclaveState
+
+	// Flavor returns the build flavor of the enclave, e.g., FlavorSGX
+	Flavor() string
+
+	// MaxConcurrency returns the maximum number of concurrent enclave invocations, or 0 if unlimited
+	MaxConcurrency() int
+}
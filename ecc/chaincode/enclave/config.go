@@ -0,0 +1,166 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ConfigFileEnv points to an (optional) JSON file with the enclave stub configuration
+	ConfigFileEnv = "FPC_ENCLAVE_CONFIG"
+
+	MaxConcurrencyEnv        = "FPC_ENCLAVE_MAX_CONCURRENCY"
+	CredentialsBufferSizeEnv = "FPC_ENCLAVE_CREDENTIALS_BUFFER_SIZE"
+	ResponseBufferSizeEnv    = "FPC_ENCLAVE_RESPONSE_BUFFER_SIZE"
+	MaxResponseBufferSizeEnv = "FPC_ENCLAVE_MAX_RESPONSE_BUFFER_SIZE"
+
+	DefaultMaxConcurrency        = 8
+	DefaultCredentialsBufferSize = 16 * 1024
+	DefaultResponseBufferSize    = 100 * 1024
+	// note that the response buffer is (partially) copied into the enclave heap,
+	// see HeapMaxSize in ecc_enclave/enclave/enclave.config.xml
+	DefaultMaxResponseBufferSize = 1024 * 1024
+)
+
+// Config defines the resource limits of the enclave stub
+type Config struct {
+	// MaxConcurrency is the maximum number of concurrent enclave invocations
+	MaxConcurrency int `json:"max_concurrency"`
+	// CredentialsBufferSize is the size of the buffer for the enclave credentials
+	CredentialsBufferSize int `json:"credentials_buffer_size"`
+	// ResponseBufferSize is the initial size of the buffer for the enclave response of an invocation
+	ResponseBufferSize int `json:"response_buffer_size"`
+	// MaxResponseBufferSize is the upper bound the response buffer grows to if a response does not fit
+	MaxResponseBufferSize int `json:"max_response_buffer_size"`
+}
+
+// DefaultConfig returns the default enclave stub configuration
+func DefaultConfig() *Config {
+	return &Config{
+		MaxConcurrency:        DefaultMaxConcurrency,
+		CredentialsBufferSize: DefaultCredentialsBufferSize,
+		ResponseBufferSize:    DefaultResponseBufferSize,
+		MaxResponseBufferSize: DefaultMaxResponseBufferSize,
+	}
+}
+
+// LoadConfig returns the enclave stub configuration. Starting from the defaults, the values are read from the JSON
+// file referenced by FPC_ENCLAVE_CONFIG (if set), and then from the individual environment variables, which take
+// precedence over the config file.
+func LoadConfig() (*Config, error) {
+	config := DefaultConfig()
+
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read %s", path)
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, errors.Wrapf(err, "invalid enclave config %s", path)
+		}
+	}
+
+	if err := intFromEnv(MaxConcurrencyEnv, &config.MaxConcurrency); err != nil {
+		return nil, err
+	}
+	if err := intFromEnv(CredentialsBufferSizeEnv, &config.CredentialsBufferSize); err != nil {
+		return nil, err
+	}
+	if err := intFromEnv(ResponseBufferSizeEnv, &config.ResponseBufferSize); err != nil {
+		return nil, err
+	}
+	if err := intFromEnv(MaxResponseBufferSizeEnv, &config.MaxResponseBufferSize); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Validate checks that all limits are positive and the initial response buffer does not exceed the maximum
+func (c *Config) Validate() error {
+	if c.MaxConcurrency <= 0 {
+		return fmt.Errorf("max concurrency must be positive")
+	}
+	if c.CredentialsBufferSize <= 0 {
+		return fmt.Errorf("credentials buffer size must be positive")
+	}
+	if c.ResponseBufferSize <= 0 {
+		return fmt.Errorf("response buffer size must be positive")
+	}
+	if c.MaxResponseBufferSize < c.ResponseBufferSize {
+		return fmt.Errorf("max response buffer size (%d) must not be smaller than response buffer size (%d)", c.MaxResponseBufferSize, c.ResponseBufferSize)
+	}
+	return nil
+}
+
+// nextResponseBufferSize returns the size of the response buffer for the next attempt after a response of
+// requiredSize bytes did not fit into a buffer of currentSize bytes. If the enclave does not report the required size,
+// the buffer is doubled. The returned size never exceeds the max response buffer size.
+func (c *Config) nextResponseBufferSize(currentSize, requiredSize int) (int, error) {
+	if currentSize >= c.MaxResponseBufferSize || requiredSize > c.MaxResponseBufferSize {
+		return 0, &chaincode.BufferTooSmallError{Size: currentSize, RequiredSize: requiredSize, MaxSize: c.MaxResponseBufferSize}
+	}
+
+	next := currentSize * 2
+	if requiredSize > next {
+		next = requiredSize
+	}
+	if next > c.MaxResponseBufferSize {
+		next = c.MaxResponseBufferSize
+	}
+	return next, nil
+}
+
+// invokeWithResponseBuffer calls invoke with a response buffer of the initial response buffer size and, as long as the
+// response does not fit, retries with a larger buffer. Along with a BufferTooSmallError, invoke returns the buffer size
+// required by the enclave, e.g., for a chaincode response that exceeds the buffer, or 0 if unknown.
+func (c *Config) invokeWithResponseBuffer(invoke func(bufferSize int) ([]byte, int, error)) ([]byte, error) {
+	bufferSize := c.ResponseBufferSize
+	for {
+		response, requiredSize, err := invoke(bufferSize)
+		if err == nil {
+			return response, nil
+		}
+		if !chaincode.IsBufferTooSmallError(err) {
+			return nil, err
+		}
+
+		bufferSize, err = c.nextResponseBufferSize(bufferSize, requiredSize)
+		if err != nil {
+			return nil, err
+		}
+		logger.Debugf("enclave response does not fit into buffer, retry with %d bytes", bufferSize)
+
+		// note that the previous attempt may already have called the shim; repeating the invocation is safe as
+		// Fabric simulates the transaction, that is, reads return committed state and repeated writes overwrite
+		// the previous ones in the rwset
+	}
+}
+
+func intFromEnv(key string, value *int) error {
+	s := os.Getenv(key)
+	if s == "" {
+		return nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.Wrapf(err, "invalid value for %s", key)
+	}
+	*value = i
+	return nil
+}
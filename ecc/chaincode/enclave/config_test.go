@@ -0,0 +1,118 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	// defaults
+	config, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), config)
+
+	// config file
+	path := filepath.Join(t.TempDir(), "enclave.json")
+	err = os.WriteFile(path, []byte(`{"max_concurrency": 2, "response_buffer_size": 2048, "max_response_buffer_size": 8192}`), 0644)
+	assert.NoError(t, err)
+	t.Setenv(ConfigFileEnv, path)
+	config, err = LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{MaxConcurrency: 2, CredentialsBufferSize: DefaultCredentialsBufferSize, ResponseBufferSize: 2048, MaxResponseBufferSize: 8192}, config)
+
+	// env overrides config file
+	t.Setenv(MaxConcurrencyEnv, "4")
+	t.Setenv(CredentialsBufferSizeEnv, "1024")
+	config, err = LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{MaxConcurrency: 4, CredentialsBufferSize: 1024, ResponseBufferSize: 2048, MaxResponseBufferSize: 8192}, config)
+
+	// invalid env value
+	t.Setenv(ResponseBufferSizeEnv, "many")
+	_, err = LoadConfig()
+	assert.EqualError(t, err, "invalid value for FPC_ENCLAVE_RESPONSE_BUFFER_SIZE: strconv.Atoi: parsing \"many\": invalid syntax")
+
+	// response buffer larger than max
+	t.Setenv(ResponseBufferSizeEnv, "16384")
+	_, err = LoadConfig()
+	assert.EqualError(t, err, "max response buffer size (8192) must not be smaller than response buffer size (16384)")
+
+	// invalid config file
+	err = os.WriteFile(path, []byte(`not json`), 0644)
+	assert.NoError(t, err)
+	_, err = LoadConfig()
+	assert.Contains(t, err.Error(), "invalid enclave config")
+}
+
+func TestNextResponseBufferSize(t *testing.T) {
+	config := &Config{ResponseBufferSize: 1024, MaxResponseBufferSize: 5000}
+
+	// double if required size is unknown or smaller
+	next, err := config.nextResponseBufferSize(1024, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2048, next)
+
+	// use required size if larger
+	next, err = config.nextResponseBufferSize(1024, 3000)
+	assert.NoError(t, err)
+	assert.Equal(t, 3000, next)
+
+	// never exceed max
+	next, err = config.nextResponseBufferSize(4096, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 5000, next)
+
+	// max reached
+	_, err = config.nextResponseBufferSize(5000, 0)
+	assert.True(t, chaincode.IsBufferTooSmallError(err))
+
+	// required size exceeds max
+	_, err = config.nextResponseBufferSize(1024, 6000)
+	assert.EqualError(t, err, "enclave response requires 6000 bytes but max buffer size is 5000 bytes")
+}
+
+func TestInvokeWithResponseBuffer(t *testing.T) {
+	config := &Config{ResponseBufferSize: 1024, MaxResponseBufferSize: 8192}
+
+	// an enclave whose chaincode response requires a larger buffer reports the required size
+	chaincodeResponseSize := 3000
+	var bufferSizes []int
+	invoke := func(bufferSize int) ([]byte, int, error) {
+		bufferSizes = append(bufferSizes, bufferSize)
+		if bufferSize < chaincodeResponseSize {
+			return nil, chaincodeResponseSize, &chaincode.BufferTooSmallError{Size: bufferSize, RequiredSize: chaincodeResponseSize}
+		}
+		return make([]byte, chaincodeResponseSize), 0, nil
+	}
+
+	response, err := config.invokeWithResponseBuffer(invoke)
+	assert.NoError(t, err)
+	assert.Len(t, response, chaincodeResponseSize)
+	assert.Equal(t, []int{1024, 3000}, bufferSizes)
+
+	// chaincode response exceeds the max response buffer size
+	chaincodeResponseSize = 10000
+	bufferSizes = nil
+	_, err = config.invokeWithResponseBuffer(invoke)
+	assert.EqualError(t, err, "enclave response requires 10000 bytes but max buffer size is 8192 bytes")
+	assert.Equal(t, []int{1024}, bufferSizes)
+
+	// other errors are not retried
+	bufferSizes = nil
+	_, err = config.invokeWithResponseBuffer(func(bufferSize int) ([]byte, int, error) {
+		bufferSizes = append(bufferSizes, bufferSize)
+		return nil, 0, &chaincode.EnclaveError{Op: "invoke", Code: 1}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []int{1024}, bufferSizes)
+}
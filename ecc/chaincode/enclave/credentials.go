@@ -0,0 +1,33 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// enclaveIdFromCredentials returns the enclave id of the given serialized credentials, see utils.GetEnclaveId
+func enclaveIdFromCredentials(serializedCredentials []byte) (string, error) {
+	credentials := &protos.Credentials{}
+	if err := proto.Unmarshal(serializedCredentials, credentials); err != nil {
+		return "", errors.Wrap(err, "invalid credentials")
+	}
+
+	attestedData := &protos.AttestedData{}
+	if err := credentials.GetSerializedAttestedData().UnmarshalTo(attestedData); err != nil {
+		return "", errors.Wrap(err, "invalid attested data")
+	}
+
+	if len(attestedData.GetEnclaveVk()) == 0 {
+		return "", errors.New("no enclave verification key")
+	}
+
+	return utils.GetEnclaveId(attestedData), nil
+}
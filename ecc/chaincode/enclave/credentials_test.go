@@ -0,0 +1,39 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestEnclaveIdFromCredentials(t *testing.T) {
+	attestedData := &protos.AttestedData{EnclaveVk: []byte("someEnclaveVk")}
+	serializedAttestedData, err := anypb.New(attestedData)
+	assert.NoError(t, err)
+	credentials, err := proto.Marshal(&protos.Credentials{SerializedAttestedData: serializedAttestedData})
+	assert.NoError(t, err)
+
+	enclaveId, err := enclaveIdFromCredentials(credentials)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.GetEnclaveId(attestedData), enclaveId)
+
+	// no attested data
+	credentials, err = proto.Marshal(&protos.Credentials{})
+	assert.NoError(t, err)
+	_, err = enclaveIdFromCredentials(credentials)
+	assert.Error(t, err)
+
+	// garbage
+	_, err = enclaveIdFromCredentials([]byte("garbage"))
+	assert.Error(t, err)
+}
@@ -13,11 +13,14 @@ package enclave
 import (
 	"context"
 	"fmt"
+	"time"
 	"unsafe"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -28,37 +31,97 @@ import (
 //
 import "C"
 
-const enclaveLibFile = "enclave/lib/enclave.signed.so"
+const (
+	enclaveLibFile = "enclave/lib/enclave.signed.so"
+
+	// initial size of the buffer for the sealed enclave state; the buffer is enlarged if the enclave requires more
+	sealedStateBufferSize = 16 * 1024
+	// size of the buffer for the (hex-encoded and null-terminated) enclave id
+	enclaveIdBufferSize = 65
+)
 
 var logger = flogging.MustGetLogger("enclave")
 
 // EnclaveStub translates invocations into an enclave using cgo
 type EnclaveStub struct {
-	eid           C.enclave_id_t
-	sem           *semaphore.Weighted
-	isInitialized bool
+	lifecycle
+	eid       C.enclave_id_t
+	enclaveId string
+	sem       *semaphore.Weighted
+	config    *Config
+	// store persists the sealed enclave state; nil if the state is not persisted
+	store chaincode.SealedStateStore
+	// metrics records the time waiting for an enclave slot; nil if metrics are disabled
+	metrics *chaincode.Metrics
 }
 
+// NewEnclaveStub returns an enclave stub configured via LoadConfig; if the configuration is invalid,
+// the default configuration is used.
 func NewEnclaveStub() *EnclaveStub {
-	return &EnclaveStub{sem: semaphore.NewWeighted(8)}
+	config, err := LoadConfig()
+	if err != nil {
+		logger.Errorf("invalid enclave config, using defaults: %s", err)
+		config = DefaultConfig()
+	}
+	return NewEnclaveStubWithConfig(config)
+}
+
+// NewEnclaveStubWithConfig returns an enclave stub with the given configuration
+func NewEnclaveStubWithConfig(config *Config) *EnclaveStub {
+	logger.Debugf("enclave config: %+v", *config)
+	return &EnclaveStub{
+		sem:    semaphore.NewWeighted(int64(config.MaxConcurrency)),
+		config: config,
+		store:  chaincode.SealedStateStoreFromEnv(),
+	}
 }
 
+// Init creates the enclave and returns its credentials. Init is possible if the enclave is uninitialized, or if a
+// previous enclave creation failed or the enclave was destroyed.
 func (e *EnclaveStub) Init(chaincodeParams, hostParams, attestationParams []byte) ([]byte, error) {
-	// Estimate of the buffer length that is necessary for the credentials. It should be conservative.
-	const credentialsBufferMaxLen = 16 * 1024
+	if err := e.beginInit(); err != nil {
+		return nil, err
+	}
 
-	if e.isInitialized {
-		return nil, fmt.Errorf("enclave already initialized")
+	credentials, err := e.createEnclave(chaincodeParams, hostParams, attestationParams)
+	if err == nil {
+		if err = e.sealState(); err != nil {
+			err = errors.Wrap(err, "cannot persist enclave state")
+			if ret := C.sgxcc_destroy_enclave(e.eid); ret != 0 {
+				logger.Errorf("cannot destroy enclave with eid=%d: %d", e.eid, int(ret))
+			}
+		}
+	}
+	e.endInit(err)
+	if err != nil {
+		return nil, err
 	}
 
+	return credentials, nil
+}
+
+func (e *EnclaveStub) createEnclave(chaincodeParams, hostParams, attestationParams []byte) ([]byte, error) {
+	// Estimate of the buffer length that is necessary for the credentials. It should be conservative.
+	credentialsBufferMaxLen := e.config.CredentialsBufferSize
+
 	var eid C.enclave_id_t
 
+	// prepare inputs
+	enclaveLibFilePtr := C.CString(enclaveLibFile)
+	defer C.free(unsafe.Pointer(enclaveLibFilePtr))
+	attestationParamsPtr := C.CBytes(attestationParams)
+	defer C.free(attestationParamsPtr)
+	chaincodeParamsPtr := C.CBytes(chaincodeParams)
+	defer C.free(chaincodeParamsPtr)
+	hostParamsPtr := C.CBytes(hostParams)
+	defer C.free(hostParamsPtr)
+
 	// prepare output buffer for credentials
-	credentialsBuffer := C.malloc(credentialsBufferMaxLen)
+	credentialsBuffer := C.malloc(C.size_t(credentialsBufferMaxLen))
 	defer C.free(credentialsBuffer)
 	credentialsSize := C.uint32_t(0)
 
-	err := e.sem.Acquire(context.Background(), 1)
+	err := e.acquireSlot()
 	if err != nil {
 		return nil, err
 	}
@@ -66,30 +129,185 @@ func (e *EnclaveStub) Init(chaincodeParams, hostParams, attestationParams []byte
 	// call the enclave
 	ret := C.sgxcc_create_enclave(
 		&eid,
-		C.CString(enclaveLibFile),
-		(*C.uint8_t)(C.CBytes(attestationParams)),
+		enclaveLibFilePtr,
+		(*C.uint8_t)(attestationParamsPtr),
 		C.uint32_t(len(attestationParams)),
-		(*C.uint8_t)(C.CBytes(chaincodeParams)),
+		(*C.uint8_t)(chaincodeParamsPtr),
 		C.uint32_t(len(chaincodeParams)),
-		(*C.uint8_t)(C.CBytes(hostParams)),
+		(*C.uint8_t)(hostParamsPtr),
 		C.uint32_t(len(hostParams)),
 		(*C.uint8_t)(credentialsBuffer),
 		C.uint32_t(credentialsBufferMaxLen),
 		&credentialsSize)
+	e.sem.Release(1)
 
 	if ret != 0 {
-		msg := fmt.Sprintf("can not create enclave (%s): Reason: %v", enclaveLibFile, ret)
-		logger.Error(msg)
-		return nil, fmt.Errorf(msg)
+		err := &chaincode.EnclaveError{Op: "create", Code: int(ret)}
+		logger.Errorf("can not create enclave (%s): %s", enclaveLibFile, err)
+		return nil, errors.Wrapf(err, "can not create enclave (%s)", enclaveLibFile)
 	}
-	e.eid = eid
-	e.sem.Release(1)
-	logger.Infof("Enclave created with eid=%d", e.eid)
+	credentials := C.GoBytes(credentialsBuffer, C.int(credentialsSize))
 
-	e.isInitialized = true
+	// the enclave id is derived from the enclave verification key in the credentials
+	enclaveId, err := enclaveIdFromCredentials(credentials)
+	if err != nil {
+		if ret := C.sgxcc_destroy_enclave(eid); ret != 0 {
+			logger.Errorf("cannot destroy enclave with eid=%d: %d", eid, int(ret))
+		}
+		return nil, errors.Wrap(err, "enclave returned invalid credentials")
+	}
+
+	e.eid = eid
+	e.enclaveId = enclaveId
+	logger.Infof("Enclave created with eid=%d enclaveId=%s", e.eid, e.enclaveId)
 
 	// return credential bytes from sgx call
-	return C.GoBytes(credentialsBuffer, C.int(credentialsSize)), nil
+	return credentials, nil
+}
+
+// sealState persists the enclave state sealed by the enclave (using the MRENCLAVE policy), if a store is configured
+func (e *EnclaveStub) sealState() error {
+	if e.store == nil {
+		return nil
+	}
+
+	sealedState, requiredLen, err := e.sealEnclaveState(sealedStateBufferSize)
+	if err == nil && sealedState == nil {
+		sealedState, _, err = e.sealEnclaveState(requiredLen)
+	}
+	if err != nil {
+		return err
+	}
+	if sealedState == nil {
+		return fmt.Errorf("sealed enclave state does not fit into %d bytes", requiredLen)
+	}
+
+	return e.store.Store(sealedState)
+}
+
+// sealEnclaveState returns the sealed enclave state; if the state does not fit into a buffer of sealedStateMaxLen
+// bytes, no state but the buffer size required by the enclave is returned.
+func (e *EnclaveStub) sealEnclaveState(sealedStateMaxLen int) ([]byte, int, error) {
+	sealedStateBuffer := C.malloc(C.size_t(sealedStateMaxLen))
+	defer C.free(sealedStateBuffer)
+	sealedStateSize := C.uint32_t(0)
+
+	ret := C.sgxcc_seal_enclave_state(e.eid, (*C.uint8_t)(sealedStateBuffer), C.uint32_t(sealedStateMaxLen), &sealedStateSize)
+	if ret == C.FPC_ERROR_BUFFER_TOO_SMALL {
+		return nil, int(sealedStateSize), nil
+	}
+	if ret != 0 {
+		return nil, 0, &chaincode.EnclaveError{Op: "seal", Code: int(ret)}
+	}
+
+	return C.GoBytes(sealedStateBuffer, C.int(sealedStateSize)), 0, nil
+}
+
+// Restore creates the enclave from the sealed enclave state, if a store is configured and contains a sealed state
+func (e *EnclaveStub) Restore() (bool, error) {
+	if e.store == nil {
+		return false, nil
+	}
+
+	sealedState, err := e.store.Load()
+	if err != nil {
+		return false, err
+	}
+	if sealedState == nil {
+		return false, nil
+	}
+
+	if err := e.beginInit(); err != nil {
+		return false, err
+	}
+	err = e.restoreEnclave(sealedState)
+	e.endInit(err)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (e *EnclaveStub) restoreEnclave(sealedState []byte) error {
+	var eid C.enclave_id_t
+
+	enclaveLibFilePtr := C.CString(enclaveLibFile)
+	defer C.free(unsafe.Pointer(enclaveLibFilePtr))
+	sealedStatePtr := C.CBytes(sealedState)
+	defer C.free(sealedStatePtr)
+
+	enclaveIdBuffer := (*C.char)(C.malloc(C.size_t(enclaveIdBufferSize)))
+	defer C.free(unsafe.Pointer(enclaveIdBuffer))
+
+	ret := C.sgxcc_restore_enclave(
+		&eid,
+		enclaveLibFilePtr,
+		(*C.uint8_t)(sealedStatePtr),
+		C.uint32_t(len(sealedState)),
+		enclaveIdBuffer,
+		C.uint32_t(enclaveIdBufferSize))
+	if ret != 0 {
+		err := &chaincode.EnclaveError{Op: "restore", Code: int(ret)}
+		logger.Errorf("can not restore enclave (%s): %s", enclaveLibFile, err)
+		return errors.Wrapf(err, "can not restore enclave (%s)", enclaveLibFile)
+	}
+
+	e.eid = eid
+	e.enclaveId = C.GoString(enclaveIdBuffer)
+	logger.Infof("Enclave restored with eid=%d enclaveId=%s", e.eid, e.enclaveId)
+	return nil
+}
+
+// Discard tears down the enclave, if it is ready, and deletes the sealed enclave state
+func (e *EnclaveStub) Discard() error {
+	if e.State() == chaincode.EnclaveReady {
+		if err := e.Destroy(); err != nil {
+			return err
+		}
+	}
+	if e.store == nil {
+		return nil
+	}
+	return e.store.Delete()
+}
+
+// Destroy tears down the enclave once all ongoing invocations have completed. Afterwards, the enclave can be
+// initialized again.
+func (e *EnclaveStub) Destroy() error {
+	return e.destroy(func() error {
+		ret := C.sgxcc_destroy_enclave(e.eid)
+		if ret != 0 {
+			return &chaincode.EnclaveError{Op: "destroy", Code: int(ret)}
+		}
+		logger.Infof("Enclave with eid=%d destroyed", e.eid)
+		return nil
+	})
+}
+
+// SetMetrics enables recording the time enclave calls wait for a free enclave slot
+func (e *EnclaveStub) SetMetrics(metrics *chaincode.Metrics) {
+	e.metrics = metrics
+}
+
+// acquireSlot waits for one of the MaxConcurrency enclave slots; the caller must release it with e.sem.Release
+func (e *EnclaveStub) acquireSlot() error {
+	start := time.Now()
+	err := e.sem.Acquire(context.Background(), 1)
+	if e.metrics != nil {
+		e.metrics.EnclaveWaitDuration.Observe(time.Since(start).Seconds())
+	}
+	return err
+}
+
+// Flavor returns chaincode.FlavorSGX
+func (e *EnclaveStub) Flavor() string {
+	return chaincode.FlavorSGX
+}
+
+// MaxConcurrency returns the configured maximum number of concurrent enclave invocations
+func (e *EnclaveStub) MaxConcurrency() int {
+	return e.config.MaxConcurrency
 }
 
 func (e *EnclaveStub) GenerateCCKeys() ([]byte, error) {
@@ -104,18 +322,23 @@ func (e *EnclaveStub) ImportCCKeys() ([]byte, error) {
 	panic("implement me")
 }
 
+// GetEnclaveId returns the id of the enclave, i.e., the hash of the enclave verification key, see utils.GetEnclaveId
 func (e *EnclaveStub) GetEnclaveId() (string, error) {
-	panic("implement me")
+	if err := e.acquire("query"); err != nil {
+		return "", err
+	}
+	defer e.release()
+
+	return e.enclaveId, nil
 }
 
-// ChaincodeInvoke calls the enclave for transaction processing
+// ChaincodeInvoke calls the enclave for transaction processing. If the enclave response does not fit into the
+// response buffer, the invocation is repeated with a larger buffer up to the configured max response buffer size.
 func (e *EnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, crmProtoBytes []byte) ([]byte, error) {
-	// Estimate of the buffer length where the enclave will write the response.
-	const scresmProtoBytesMaxLen = 1024 * 100 // Let's be really conservative ...
-
-	if !e.isInitialized {
-		return nil, fmt.Errorf("enclave not yet initialized")
+	if err := e.acquire("invoke"); err != nil {
+		return nil, err
 	}
+	defer e.release()
 
 	// register our stub for callbacks
 	index := registry.register(&Stubs{stub})
@@ -134,31 +357,45 @@ func (e *EnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, crmProto
 	signedProposalPtr := C.CBytes(signedProposalBytes)
 	defer C.free(unsafe.Pointer(signedProposalPtr))
 
+	crmProtoBytesPtr := C.CBytes(crmProtoBytes)
+	defer C.free(unsafe.Pointer(crmProtoBytesPtr))
+
+	return e.config.invokeWithResponseBuffer(func(scresmProtoBytesMaxLen int) ([]byte, int, error) {
+		return e.invoke(signedProposalPtr, len(signedProposalBytes), crmProtoBytesPtr, len(crmProtoBytes), scresmProtoBytesMaxLen, ctx)
+	})
+}
+
+// invoke calls the enclave with a response buffer of scresmProtoBytesMaxLen bytes. If the buffer is too small,
+// a BufferTooSmallError along with the buffer size required by the enclave is returned.
+func (e *EnclaveStub) invoke(signedProposalPtr unsafe.Pointer, signedProposalLen int, crmProtoBytesPtr unsafe.Pointer, crmProtoBytesLen int, scresmProtoBytesMaxLen int, ctx unsafe.Pointer) ([]byte, int, error) {
 	// prep response
 	scresmProtoBytesLenOut := C.uint32_t(0) // We pass maximal length separately; set to zero so we can detect valid responses
-	scresmProtoBytesPtr := C.malloc(scresmProtoBytesMaxLen)
+	scresmProtoBytesPtr := C.malloc(C.size_t(scresmProtoBytesMaxLen))
 	defer C.free(scresmProtoBytesPtr)
 
-	crmProtoBytesPtr := C.CBytes(crmProtoBytes)
-	defer C.free(unsafe.Pointer(crmProtoBytesPtr))
-
-	err = e.sem.Acquire(context.Background(), 1)
+	err := e.acquireSlot()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// invoke enclave
 	invokeRet := C.sgxcc_invoke(e.eid,
 		(*C.uint8_t)(signedProposalPtr),
-		(C.uint32_t)(len(signedProposalBytes)),
+		(C.uint32_t)(signedProposalLen),
 		(*C.uint8_t)(crmProtoBytesPtr),
-		(C.uint32_t)(len(crmProtoBytes)),
+		(C.uint32_t)(crmProtoBytesLen),
 		(*C.uint8_t)(scresmProtoBytesPtr), (C.uint32_t)(scresmProtoBytesMaxLen), &scresmProtoBytesLenOut,
 		ctx)
 	e.sem.Release(1)
+	if invokeRet == C.FPC_ERROR_BUFFER_TOO_SMALL {
+		return nil, int(scresmProtoBytesLenOut), &chaincode.BufferTooSmallError{Size: scresmProtoBytesMaxLen, RequiredSize: int(scresmProtoBytesLenOut), MaxSize: e.config.MaxResponseBufferSize}
+	}
+	if invokeRet == C.FPC_ERROR_KEY_TRANSPORT {
+		return nil, 0, &chaincode.KeyTransportError{}
+	}
 	if invokeRet != 0 {
-		return nil, fmt.Errorf("invoke failed. Reason: %d", int(invokeRet))
+		return nil, 0, &chaincode.EnclaveError{Op: "invoke", Code: int(invokeRet)}
 	}
 
-	return C.GoBytes(scresmProtoBytesPtr, C.int(scresmProtoBytesLenOut)), nil
+	return C.GoBytes(scresmProtoBytesPtr, C.int(scresmProtoBytesLenOut)), 0, nil
 }
@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
+)
+
+// lifecycle tracks the state of an enclave. State transitions are serialized with enclave invocations; that is, an
+// enclave is only torn down once all ongoing invocations have completed.
+//
+// The states and transitions are:
+//
+//	uninitialized, failed, destroyed --(init)--> initializing --> ready | failed
+//	ready --(destroy)--> destroyed | failed
+type lifecycle struct {
+	mu    sync.RWMutex
+	state chaincode.EnclaveState
+	// reason is the cause of the failed state
+	reason error
+}
+
+// State returns the current enclave state
+func (l *lifecycle) State() chaincode.EnclaveState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.state
+}
+
+// beginInit moves the enclave to the initializing state; the caller must complete the initialization with endInit.
+// Concurrent initializations are rejected.
+func (l *lifecycle) beginInit() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch l.state {
+	case chaincode.EnclaveUninitialized, chaincode.EnclaveFailed, chaincode.EnclaveDestroyed:
+		l.state = chaincode.EnclaveInitializing
+		l.reason = nil
+		return nil
+	default:
+		return &chaincode.StateError{Op: "init", State: l.state}
+	}
+}
+
+// endInit moves the enclave to the ready state, or to the failed state if err is not nil
+func (l *lifecycle) endInit(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err != nil {
+		l.state = chaincode.EnclaveFailed
+		l.reason = err
+		return
+	}
+	l.state = chaincode.EnclaveReady
+}
+
+// acquire returns an error if the enclave is not ready; otherwise, the enclave cannot be torn down until the caller
+// calls release.
+func (l *lifecycle) acquire(op string) error {
+	l.mu.RLock()
+	if l.state != chaincode.EnclaveReady {
+		err := &chaincode.StateError{Op: op, State: l.state, Reason: l.reason}
+		l.mu.RUnlock()
+		return err
+	}
+	return nil
+}
+
+func (l *lifecycle) release() {
+	l.mu.RUnlock()
+}
+
+// destroy tears down a ready enclave using destroyFunc, after all ongoing invocations have completed
+func (l *lifecycle) destroy(destroyFunc func() error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.state != chaincode.EnclaveReady {
+		return &chaincode.StateError{Op: "destroy", State: l.state, Reason: l.reason}
+	}
+
+	if err := destroyFunc(); err != nil {
+		l.state = chaincode.EnclaveFailed
+		l.reason = err
+		return err
+	}
+	l.state = chaincode.EnclaveDestroyed
+	return nil
+}
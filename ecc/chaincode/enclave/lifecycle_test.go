@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	l := &lifecycle{}
+	assert.Equal(t, chaincode.EnclaveUninitialized, l.State())
+
+	// not ready
+	assert.EqualError(t, l.acquire("invoke"), "cannot invoke enclave in state uninitialized")
+	assert.EqualError(t, l.destroy(func() error { return nil }), "cannot destroy enclave in state uninitialized")
+
+	// failed init
+	assert.NoError(t, l.beginInit())
+	assert.Equal(t, chaincode.EnclaveInitializing, l.State())
+	assert.EqualError(t, l.beginInit(), "cannot init enclave in state initializing")
+	l.endInit(fmt.Errorf("some error"))
+	assert.Equal(t, chaincode.EnclaveFailed, l.State())
+	assert.EqualError(t, l.acquire("invoke"), "cannot invoke enclave in state failed: some error")
+
+	// re-init after failure
+	assert.NoError(t, l.beginInit())
+	l.endInit(nil)
+	assert.Equal(t, chaincode.EnclaveReady, l.State())
+	assert.EqualError(t, l.beginInit(), "cannot init enclave in state ready")
+	assert.NoError(t, l.acquire("invoke"))
+	l.release()
+
+	// failed destroy
+	assert.EqualError(t, l.destroy(func() error { return fmt.Errorf("some error") }), "some error")
+	assert.Equal(t, chaincode.EnclaveFailed, l.State())
+
+	// destroy and re-init
+	assert.NoError(t, l.beginInit())
+	l.endInit(nil)
+	assert.NoError(t, l.destroy(func() error { return nil }))
+	assert.Equal(t, chaincode.EnclaveDestroyed, l.State())
+	assert.EqualError(t, l.acquire("invoke"), "cannot invoke enclave in state destroyed")
+	assert.NoError(t, l.beginInit())
+	l.endInit(nil)
+	assert.Equal(t, chaincode.EnclaveReady, l.State())
+}
+
+func TestLifecycleDestroyWaitsForInvocations(t *testing.T) {
+	l := &lifecycle{}
+	assert.NoError(t, l.beginInit())
+	l.endInit(nil)
+
+	assert.NoError(t, l.acquire("invoke"))
+
+	destroyed := make(chan struct{})
+	go func() {
+		assert.NoError(t, l.destroy(func() error { return nil }))
+		close(destroyed)
+	}()
+
+	select {
+	case <-destroyed:
+		t.Fatal("enclave destroyed during invocation")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	l.release()
+	<-destroyed
+	assert.Equal(t, chaincode.EnclaveDestroyed, l.State())
+}
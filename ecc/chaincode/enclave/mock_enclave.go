@@ -18,6 +18,7 @@ import (
 	"strings"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
@@ -29,7 +30,9 @@ import (
 
 var logger = flogging.MustGetLogger("mock_enclave")
 
+// MockEnclaveStub mimics the enclave stub, including its lifecycle, without SGX
 type MockEnclaveStub struct {
+	lifecycle
 	csp          crypto.CSP
 	privateKey   []byte
 	publicKey    []byte
@@ -44,7 +47,20 @@ func NewEnclaveStub() *MockEnclaveStub {
 }
 
 func (m *MockEnclaveStub) Init(serializedChaincodeParams, serializedHostParamsBytes, serializedAttestationParams []byte) ([]byte, error) {
+	if err := m.beginInit(); err != nil {
+		return nil, err
+	}
+
+	credentials, err := m.createEnclave(serializedChaincodeParams, serializedHostParamsBytes, serializedAttestationParams)
+	m.endInit(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
 
+func (m *MockEnclaveStub) createEnclave(serializedChaincodeParams, serializedHostParamsBytes, serializedAttestationParams []byte) ([]byte, error) {
 	hostParams := &protos.HostParameters{}
 	err := proto.Unmarshal(serializedHostParamsBytes, hostParams)
 	if err != nil {
@@ -92,21 +108,48 @@ func (m *MockEnclaveStub) Init(serializedChaincodeParams, serializedHostParamsBy
 	return proto.Marshal(credentials)
 }
 
-func (m MockEnclaveStub) GenerateCCKeys() ([]byte, error) {
+// Destroy discards the enclave keys. Afterwards, the enclave can be initialized again.
+func (m *MockEnclaveStub) Destroy() error {
+	return m.destroy(func() error {
+		m.privateKey = nil
+		m.publicKey = nil
+		m.ccPrivateKey = nil
+		m.enclaveId = ""
+		logger.Debug("Destroy")
+		return nil
+	})
+}
+
+// SetMetrics is a no-op as the mock enclave does not limit concurrent invocations
+func (m *MockEnclaveStub) SetMetrics(metrics *chaincode.Metrics) {}
+
+// Flavor returns chaincode.FlavorMock
+func (m *MockEnclaveStub) Flavor() string {
+	return chaincode.FlavorMock
+}
+
+// MaxConcurrency returns 0 as the mock enclave does not limit concurrent invocations
+func (m *MockEnclaveStub) MaxConcurrency() int {
+	return 0
+}
+
+func (m *MockEnclaveStub) GenerateCCKeys() ([]byte, error) {
 	panic("implement me")
 	// -> *protos.SignedCCKeyRegistrationMessage
 }
 
-func (m MockEnclaveStub) ExportCCKeys(credentials []byte) ([]byte, error) {
+func (m *MockEnclaveStub) ExportCCKeys(credentials []byte) ([]byte, error) {
 	panic("implement me")
 	// credentials *protos.Credentials -> *protos.SignedExportMessage,
 }
 
-func (m MockEnclaveStub) ImportCCKeys() ([]byte, error) {
+func (m *MockEnclaveStub) ImportCCKeys() ([]byte, error) {
 	panic("implement me")
 	// -> *protos.SignedCCKeyRegistrationMessage
 }
 
+// GetEnclaveId returns the hash of the enclave public key. Unlike the enclave stub, the mock does not check the
+// lifecycle state, i.e., before Init and after Destroy it returns the hash of the empty key.
 func (m *MockEnclaveStub) GetEnclaveId() (string, error) {
 	hash := sha256.Sum256(m.publicKey)
 	return strings.ToUpper(hex.EncodeToString(hash[:])), nil
@@ -115,6 +158,11 @@ func (m *MockEnclaveStub) GetEnclaveId() (string, error) {
 func (m *MockEnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, chaincodeRequestMessageBytes []byte) ([]byte, error) {
 	logger.Debug("ChaincodeInvoke")
 
+	if err := m.acquire("invoke"); err != nil {
+		return nil, err
+	}
+	defer m.release()
+
 	signedProposal, err := stub.GetSignedProposal()
 	if err != nil {
 		shim.Error(err.Error())
@@ -138,7 +186,7 @@ func (m *MockEnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, chai
 	// decrypt key transport message with chaincode decryption key
 	keyTransportMessageBytes, err := m.csp.PkDecryptMessage(m.ccPrivateKey, chaincodeRequestMessage.GetEncryptedKeyTransportMessage())
 	if err != nil {
-		return nil, errors.Wrap(err, "decryption of key transport message failed")
+		return nil, &chaincode.KeyTransportError{Err: err}
 	}
 
 	keyTransportMessage := &protos.KeyTransportMessage{}
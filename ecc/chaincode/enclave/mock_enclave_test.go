@@ -0,0 +1,51 @@
+//go:build mock_ecc
+// +build mock_ecc
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/fakes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockEnclaveLifecycle(t *testing.T) {
+	m := NewEnclaveStub()
+	stub := &fakes.ChaincodeStub{}
+
+	_, err := m.ChaincodeInvoke(stub, nil)
+	assert.EqualError(t, err, "cannot invoke enclave in state uninitialized")
+	// the mock returns an enclave id in any state
+	uninitializedEnclaveId, err := m.GetEnclaveId()
+	assert.NoError(t, err)
+
+	credentials, err := m.Init(nil, nil, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, credentials)
+	assert.Equal(t, chaincode.EnclaveReady, m.State())
+	enclaveId, _ := m.GetEnclaveId()
+	assert.NotEqual(t, uninitializedEnclaveId, enclaveId)
+
+	_, err = m.Init(nil, nil, nil)
+	assert.EqualError(t, err, "cannot init enclave in state ready")
+
+	assert.NoError(t, m.Destroy())
+	assert.Equal(t, chaincode.EnclaveDestroyed, m.State())
+	_, err = m.ChaincodeInvoke(stub, nil)
+	assert.EqualError(t, err, "cannot invoke enclave in state destroyed")
+
+	// re-init creates a new enclave identity
+	_, err = m.Init(nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, chaincode.EnclaveReady, m.State())
+	newEnclaveId, _ := m.GetEnclaveId()
+	assert.NotEqual(t, enclaveId, newEnclaveId)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// BufferTooSmallError is returned by an Enclave if a response does not fit into the largest allowed buffer
+type BufferTooSmallError struct {
+	// Size is the size of the buffer used in the last attempt
+	Size int
+	// RequiredSize is the buffer size reported by the enclave; 0 if unknown
+	RequiredSize int
+	// MaxSize is the configured max buffer size
+	MaxSize int
+}
+
+func (e *BufferTooSmallError) Error() string {
+	if e.RequiredSize > 0 {
+		return fmt.Sprintf("enclave response requires %d bytes but max buffer size is %d bytes", e.RequiredSize, e.MaxSize)
+	}
+	return fmt.Sprintf("enclave response does not fit into %d bytes (max buffer size %d bytes)", e.Size, e.MaxSize)
+}
+
+// EnclaveError is returned by an Enclave if an enclave call fails with an error code
+type EnclaveError struct {
+	// Op is the enclave operation, e.g., "create" or "invoke"
+	Op string
+	// Code is the error code returned by the enclave or the SGX runtime
+	Code int
+}
+
+func (e *EnclaveError) Error() string {
+	return fmt.Sprintf("enclave %s failed with error code %d (0x%x)", e.Op, e.Code, e.Code)
+}
+
+// KeyTransportError is returned by an Enclave if it cannot decrypt the key transport message of a request, i.e., the
+// request was encrypted with another chaincode encryption key
+type KeyTransportError struct {
+	// Err is the cause of the failure, if known
+	Err error
+}
+
+func (e *KeyTransportError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("decryption of key transport message failed: %s", e.Err)
+	}
+	return "decryption of key transport message failed"
+}
+
+func (e *KeyTransportError) Unwrap() error {
+	return e.Err
+}
+
+// IsBufferTooSmallError returns true if err is (or wraps) a BufferTooSmallError
+func IsBufferTooSmallError(err error) bool {
+	var e *BufferTooSmallError
+	return errors.As(err, &e)
+}
+
+// StateError is returned by an Enclave if an operation is not possible in the current enclave state
+type StateError struct {
+	// Op is the requested operation, e.g., "init" or "invoke"
+	Op string
+	// State is the enclave state at the time of the request
+	State EnclaveState
+	// Reason is the cause of the failed state, if any
+	Reason error
+}
+
+func (e *StateError) Error() string {
+	if e.Reason != nil {
+		return fmt.Sprintf("cannot %s enclave in state %s: %s", e.Op, e.State, e.Reason)
+	}
+	return fmt.Sprintf("cannot %s enclave in state %s", e.Op, e.State)
+}
@@ -0,0 +1,648 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+type SealedEnclaveStub struct {
+	ChaincodeInvokeStub        func(shim.ChaincodeStubInterface, []byte) ([]byte, error)
+	chaincodeInvokeMutex       sync.RWMutex
+	chaincodeInvokeArgsForCall []struct {
+		arg1 shim.ChaincodeStubInterface
+		arg2 []byte
+	}
+	chaincodeInvokeReturns struct {
+		result1 []byte
+		result2 error
+	}
+	chaincodeInvokeReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	DiscardStub        func() error
+	discardMutex       sync.RWMutex
+	discardArgsForCall []struct {
+	}
+	discardReturns struct {
+		result1 error
+	}
+	discardReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ExportCCKeysStub        func([]byte) ([]byte, error)
+	exportCCKeysMutex       sync.RWMutex
+	exportCCKeysArgsForCall []struct {
+		arg1 []byte
+	}
+	exportCCKeysReturns struct {
+		result1 []byte
+		result2 error
+	}
+	exportCCKeysReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	GenerateCCKeysStub        func() ([]byte, error)
+	generateCCKeysMutex       sync.RWMutex
+	generateCCKeysArgsForCall []struct {
+	}
+	generateCCKeysReturns struct {
+		result1 []byte
+		result2 error
+	}
+	generateCCKeysReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	GetEnclaveIdStub        func() (string, error)
+	getEnclaveIdMutex       sync.RWMutex
+	getEnclaveIdArgsForCall []struct {
+	}
+	getEnclaveIdReturns struct {
+		result1 string
+		result2 error
+	}
+	getEnclaveIdReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	ImportCCKeysStub        func() ([]byte, error)
+	importCCKeysMutex       sync.RWMutex
+	importCCKeysArgsForCall []struct {
+	}
+	importCCKeysReturns struct {
+		result1 []byte
+		result2 error
+	}
+	importCCKeysReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	InitStub        func([]byte, []byte, []byte) ([]byte, error)
+	initMutex       sync.RWMutex
+	initArgsForCall []struct {
+		arg1 []byte
+		arg2 []byte
+		arg3 []byte
+	}
+	initReturns struct {
+		result1 []byte
+		result2 error
+	}
+	initReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	RestoreStub        func() (bool, error)
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+	}
+	restoreReturns struct {
+		result1 bool
+		result2 error
+	}
+	restoreReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *SealedEnclaveStub) ChaincodeInvoke(arg1 shim.ChaincodeStubInterface, arg2 []byte) ([]byte, error) {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.chaincodeInvokeMutex.Lock()
+	ret, specificReturn := fake.chaincodeInvokeReturnsOnCall[len(fake.chaincodeInvokeArgsForCall)]
+	fake.chaincodeInvokeArgsForCall = append(fake.chaincodeInvokeArgsForCall, struct {
+		arg1 shim.ChaincodeStubInterface
+		arg2 []byte
+	}{arg1, arg2Copy})
+	stub := fake.ChaincodeInvokeStub
+	fakeReturns := fake.chaincodeInvokeReturns
+	fake.recordInvocation("ChaincodeInvoke", []interface{}{arg1, arg2Copy})
+	fake.chaincodeInvokeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SealedEnclaveStub) ChaincodeInvokeCallCount() int {
+	fake.chaincodeInvokeMutex.RLock()
+	defer fake.chaincodeInvokeMutex.RUnlock()
+	return len(fake.chaincodeInvokeArgsForCall)
+}
+
+func (fake *SealedEnclaveStub) ChaincodeInvokeCalls(stub func(shim.ChaincodeStubInterface, []byte) ([]byte, error)) {
+	fake.chaincodeInvokeMutex.Lock()
+	defer fake.chaincodeInvokeMutex.Unlock()
+	fake.ChaincodeInvokeStub = stub
+}
+
+func (fake *SealedEnclaveStub) ChaincodeInvokeArgsForCall(i int) (shim.ChaincodeStubInterface, []byte) {
+	fake.chaincodeInvokeMutex.RLock()
+	defer fake.chaincodeInvokeMutex.RUnlock()
+	argsForCall := fake.chaincodeInvokeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *SealedEnclaveStub) ChaincodeInvokeReturns(result1 []byte, result2 error) {
+	fake.chaincodeInvokeMutex.Lock()
+	defer fake.chaincodeInvokeMutex.Unlock()
+	fake.ChaincodeInvokeStub = nil
+	fake.chaincodeInvokeReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) ChaincodeInvokeReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.chaincodeInvokeMutex.Lock()
+	defer fake.chaincodeInvokeMutex.Unlock()
+	fake.ChaincodeInvokeStub = nil
+	if fake.chaincodeInvokeReturnsOnCall == nil {
+		fake.chaincodeInvokeReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.chaincodeInvokeReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) Discard() error {
+	fake.discardMutex.Lock()
+	ret, specificReturn := fake.discardReturnsOnCall[len(fake.discardArgsForCall)]
+	fake.discardArgsForCall = append(fake.discardArgsForCall, struct {
+	}{})
+	stub := fake.DiscardStub
+	fakeReturns := fake.discardReturns
+	fake.recordInvocation("Discard", []interface{}{})
+	fake.discardMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SealedEnclaveStub) DiscardCallCount() int {
+	fake.discardMutex.RLock()
+	defer fake.discardMutex.RUnlock()
+	return len(fake.discardArgsForCall)
+}
+
+func (fake *SealedEnclaveStub) DiscardCalls(stub func() error) {
+	fake.discardMutex.Lock()
+	defer fake.discardMutex.Unlock()
+	fake.DiscardStub = stub
+}
+
+func (fake *SealedEnclaveStub) DiscardReturns(result1 error) {
+	fake.discardMutex.Lock()
+	defer fake.discardMutex.Unlock()
+	fake.DiscardStub = nil
+	fake.discardReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SealedEnclaveStub) DiscardReturnsOnCall(i int, result1 error) {
+	fake.discardMutex.Lock()
+	defer fake.discardMutex.Unlock()
+	fake.DiscardStub = nil
+	if fake.discardReturnsOnCall == nil {
+		fake.discardReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.discardReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SealedEnclaveStub) ExportCCKeys(arg1 []byte) ([]byte, error) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.exportCCKeysMutex.Lock()
+	ret, specificReturn := fake.exportCCKeysReturnsOnCall[len(fake.exportCCKeysArgsForCall)]
+	fake.exportCCKeysArgsForCall = append(fake.exportCCKeysArgsForCall, struct {
+		arg1 []byte
+	}{arg1Copy})
+	stub := fake.ExportCCKeysStub
+	fakeReturns := fake.exportCCKeysReturns
+	fake.recordInvocation("ExportCCKeys", []interface{}{arg1Copy})
+	fake.exportCCKeysMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SealedEnclaveStub) ExportCCKeysCallCount() int {
+	fake.exportCCKeysMutex.RLock()
+	defer fake.exportCCKeysMutex.RUnlock()
+	return len(fake.exportCCKeysArgsForCall)
+}
+
+func (fake *SealedEnclaveStub) ExportCCKeysCalls(stub func([]byte) ([]byte, error)) {
+	fake.exportCCKeysMutex.Lock()
+	defer fake.exportCCKeysMutex.Unlock()
+	fake.ExportCCKeysStub = stub
+}
+
+func (fake *SealedEnclaveStub) ExportCCKeysArgsForCall(i int) []byte {
+	fake.exportCCKeysMutex.RLock()
+	defer fake.exportCCKeysMutex.RUnlock()
+	argsForCall := fake.exportCCKeysArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *SealedEnclaveStub) ExportCCKeysReturns(result1 []byte, result2 error) {
+	fake.exportCCKeysMutex.Lock()
+	defer fake.exportCCKeysMutex.Unlock()
+	fake.ExportCCKeysStub = nil
+	fake.exportCCKeysReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) ExportCCKeysReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.exportCCKeysMutex.Lock()
+	defer fake.exportCCKeysMutex.Unlock()
+	fake.ExportCCKeysStub = nil
+	if fake.exportCCKeysReturnsOnCall == nil {
+		fake.exportCCKeysReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.exportCCKeysReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) GenerateCCKeys() ([]byte, error) {
+	fake.generateCCKeysMutex.Lock()
+	ret, specificReturn := fake.generateCCKeysReturnsOnCall[len(fake.generateCCKeysArgsForCall)]
+	fake.generateCCKeysArgsForCall = append(fake.generateCCKeysArgsForCall, struct {
+	}{})
+	stub := fake.GenerateCCKeysStub
+	fakeReturns := fake.generateCCKeysReturns
+	fake.recordInvocation("GenerateCCKeys", []interface{}{})
+	fake.generateCCKeysMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SealedEnclaveStub) GenerateCCKeysCallCount() int {
+	fake.generateCCKeysMutex.RLock()
+	defer fake.generateCCKeysMutex.RUnlock()
+	return len(fake.generateCCKeysArgsForCall)
+}
+
+func (fake *SealedEnclaveStub) GenerateCCKeysCalls(stub func() ([]byte, error)) {
+	fake.generateCCKeysMutex.Lock()
+	defer fake.generateCCKeysMutex.Unlock()
+	fake.GenerateCCKeysStub = stub
+}
+
+func (fake *SealedEnclaveStub) GenerateCCKeysReturns(result1 []byte, result2 error) {
+	fake.generateCCKeysMutex.Lock()
+	defer fake.generateCCKeysMutex.Unlock()
+	fake.GenerateCCKeysStub = nil
+	fake.generateCCKeysReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) GenerateCCKeysReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.generateCCKeysMutex.Lock()
+	defer fake.generateCCKeysMutex.Unlock()
+	fake.GenerateCCKeysStub = nil
+	if fake.generateCCKeysReturnsOnCall == nil {
+		fake.generateCCKeysReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.generateCCKeysReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) GetEnclaveId() (string, error) {
+	fake.getEnclaveIdMutex.Lock()
+	ret, specificReturn := fake.getEnclaveIdReturnsOnCall[len(fake.getEnclaveIdArgsForCall)]
+	fake.getEnclaveIdArgsForCall = append(fake.getEnclaveIdArgsForCall, struct {
+	}{})
+	stub := fake.GetEnclaveIdStub
+	fakeReturns := fake.getEnclaveIdReturns
+	fake.recordInvocation("GetEnclaveId", []interface{}{})
+	fake.getEnclaveIdMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SealedEnclaveStub) GetEnclaveIdCallCount() int {
+	fake.getEnclaveIdMutex.RLock()
+	defer fake.getEnclaveIdMutex.RUnlock()
+	return len(fake.getEnclaveIdArgsForCall)
+}
+
+func (fake *SealedEnclaveStub) GetEnclaveIdCalls(stub func() (string, error)) {
+	fake.getEnclaveIdMutex.Lock()
+	defer fake.getEnclaveIdMutex.Unlock()
+	fake.GetEnclaveIdStub = stub
+}
+
+func (fake *SealedEnclaveStub) GetEnclaveIdReturns(result1 string, result2 error) {
+	fake.getEnclaveIdMutex.Lock()
+	defer fake.getEnclaveIdMutex.Unlock()
+	fake.GetEnclaveIdStub = nil
+	fake.getEnclaveIdReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) GetEnclaveIdReturnsOnCall(i int, result1 string, result2 error) {
+	fake.getEnclaveIdMutex.Lock()
+	defer fake.getEnclaveIdMutex.Unlock()
+	fake.GetEnclaveIdStub = nil
+	if fake.getEnclaveIdReturnsOnCall == nil {
+		fake.getEnclaveIdReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.getEnclaveIdReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) ImportCCKeys() ([]byte, error) {
+	fake.importCCKeysMutex.Lock()
+	ret, specificReturn := fake.importCCKeysReturnsOnCall[len(fake.importCCKeysArgsForCall)]
+	fake.importCCKeysArgsForCall = append(fake.importCCKeysArgsForCall, struct {
+	}{})
+	stub := fake.ImportCCKeysStub
+	fakeReturns := fake.importCCKeysReturns
+	fake.recordInvocation("ImportCCKeys", []interface{}{})
+	fake.importCCKeysMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SealedEnclaveStub) ImportCCKeysCallCount() int {
+	fake.importCCKeysMutex.RLock()
+	defer fake.importCCKeysMutex.RUnlock()
+	return len(fake.importCCKeysArgsForCall)
+}
+
+func (fake *SealedEnclaveStub) ImportCCKeysCalls(stub func() ([]byte, error)) {
+	fake.importCCKeysMutex.Lock()
+	defer fake.importCCKeysMutex.Unlock()
+	fake.ImportCCKeysStub = stub
+}
+
+func (fake *SealedEnclaveStub) ImportCCKeysReturns(result1 []byte, result2 error) {
+	fake.importCCKeysMutex.Lock()
+	defer fake.importCCKeysMutex.Unlock()
+	fake.ImportCCKeysStub = nil
+	fake.importCCKeysReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) ImportCCKeysReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.importCCKeysMutex.Lock()
+	defer fake.importCCKeysMutex.Unlock()
+	fake.ImportCCKeysStub = nil
+	if fake.importCCKeysReturnsOnCall == nil {
+		fake.importCCKeysReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.importCCKeysReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) Init(arg1 []byte, arg2 []byte, arg3 []byte) ([]byte, error) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.initMutex.Lock()
+	ret, specificReturn := fake.initReturnsOnCall[len(fake.initArgsForCall)]
+	fake.initArgsForCall = append(fake.initArgsForCall, struct {
+		arg1 []byte
+		arg2 []byte
+		arg3 []byte
+	}{arg1Copy, arg2Copy, arg3Copy})
+	stub := fake.InitStub
+	fakeReturns := fake.initReturns
+	fake.recordInvocation("Init", []interface{}{arg1Copy, arg2Copy, arg3Copy})
+	fake.initMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SealedEnclaveStub) InitCallCount() int {
+	fake.initMutex.RLock()
+	defer fake.initMutex.RUnlock()
+	return len(fake.initArgsForCall)
+}
+
+func (fake *SealedEnclaveStub) InitCalls(stub func([]byte, []byte, []byte) ([]byte, error)) {
+	fake.initMutex.Lock()
+	defer fake.initMutex.Unlock()
+	fake.InitStub = stub
+}
+
+func (fake *SealedEnclaveStub) InitArgsForCall(i int) ([]byte, []byte, []byte) {
+	fake.initMutex.RLock()
+	defer fake.initMutex.RUnlock()
+	argsForCall := fake.initArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *SealedEnclaveStub) InitReturns(result1 []byte, result2 error) {
+	fake.initMutex.Lock()
+	defer fake.initMutex.Unlock()
+	fake.InitStub = nil
+	fake.initReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) InitReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.initMutex.Lock()
+	defer fake.initMutex.Unlock()
+	fake.InitStub = nil
+	if fake.initReturnsOnCall == nil {
+		fake.initReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.initReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) Restore() (bool, error) {
+	fake.restoreMutex.Lock()
+	ret, specificReturn := fake.restoreReturnsOnCall[len(fake.restoreArgsForCall)]
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+	}{})
+	stub := fake.RestoreStub
+	fakeReturns := fake.restoreReturns
+	fake.recordInvocation("Restore", []interface{}{})
+	fake.restoreMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *SealedEnclaveStub) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *SealedEnclaveStub) RestoreCalls(stub func() (bool, error)) {
+	fake.restoreMutex.Lock()
+	defer fake.restoreMutex.Unlock()
+	fake.RestoreStub = stub
+}
+
+func (fake *SealedEnclaveStub) RestoreReturns(result1 bool, result2 error) {
+	fake.restoreMutex.Lock()
+	defer fake.restoreMutex.Unlock()
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) RestoreReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.restoreMutex.Lock()
+	defer fake.restoreMutex.Unlock()
+	fake.RestoreStub = nil
+	if fake.restoreReturnsOnCall == nil {
+		fake.restoreReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.restoreReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *SealedEnclaveStub) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.chaincodeInvokeMutex.RLock()
+	defer fake.chaincodeInvokeMutex.RUnlock()
+	fake.discardMutex.RLock()
+	defer fake.discardMutex.RUnlock()
+	fake.exportCCKeysMutex.RLock()
+	defer fake.exportCCKeysMutex.RUnlock()
+	fake.generateCCKeysMutex.RLock()
+	defer fake.generateCCKeysMutex.RUnlock()
+	fake.getEnclaveIdMutex.RLock()
+	defer fake.getEnclaveIdMutex.RUnlock()
+	fake.importCCKeysMutex.RLock()
+	defer fake.importCCKeysMutex.RUnlock()
+	fake.initMutex.RLock()
+	defer fake.initMutex.RUnlock()
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *SealedEnclaveStub) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
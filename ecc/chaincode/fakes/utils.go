@@ -37,6 +37,19 @@ type Extractors struct {
 		result2 *protos.ChaincodeResponseMessage
 		result3 error
 	}
+	GetEndorserMSPIDStub        func(shim.ChaincodeStubInterface) (string, error)
+	getEndorserMSPIDMutex       sync.RWMutex
+	getEndorserMSPIDArgsForCall []struct {
+		arg1 shim.ChaincodeStubInterface
+	}
+	getEndorserMSPIDReturns struct {
+		result1 string
+		result2 error
+	}
+	getEndorserMSPIDReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	GetHostParamsStub        func(shim.ChaincodeStubInterface) (*protos.HostParameters, error)
 	getHostParamsMutex       sync.RWMutex
 	getHostParamsArgsForCall []struct {
@@ -211,6 +224,70 @@ func (fake *Extractors) GetChaincodeResponseMessagesReturnsOnCall(i int, result1
 	}{result1, result2, result3}
 }
 
+func (fake *Extractors) GetEndorserMSPID(arg1 shim.ChaincodeStubInterface) (string, error) {
+	fake.getEndorserMSPIDMutex.Lock()
+	ret, specificReturn := fake.getEndorserMSPIDReturnsOnCall[len(fake.getEndorserMSPIDArgsForCall)]
+	fake.getEndorserMSPIDArgsForCall = append(fake.getEndorserMSPIDArgsForCall, struct {
+		arg1 shim.ChaincodeStubInterface
+	}{arg1})
+	stub := fake.GetEndorserMSPIDStub
+	fakeReturns := fake.getEndorserMSPIDReturns
+	fake.recordInvocation("GetEndorserMSPID", []interface{}{arg1})
+	fake.getEndorserMSPIDMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Extractors) GetEndorserMSPIDCallCount() int {
+	fake.getEndorserMSPIDMutex.RLock()
+	defer fake.getEndorserMSPIDMutex.RUnlock()
+	return len(fake.getEndorserMSPIDArgsForCall)
+}
+
+func (fake *Extractors) GetEndorserMSPIDCalls(stub func(shim.ChaincodeStubInterface) (string, error)) {
+	fake.getEndorserMSPIDMutex.Lock()
+	defer fake.getEndorserMSPIDMutex.Unlock()
+	fake.GetEndorserMSPIDStub = stub
+}
+
+func (fake *Extractors) GetEndorserMSPIDArgsForCall(i int) shim.ChaincodeStubInterface {
+	fake.getEndorserMSPIDMutex.RLock()
+	defer fake.getEndorserMSPIDMutex.RUnlock()
+	argsForCall := fake.getEndorserMSPIDArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Extractors) GetEndorserMSPIDReturns(result1 string, result2 error) {
+	fake.getEndorserMSPIDMutex.Lock()
+	defer fake.getEndorserMSPIDMutex.Unlock()
+	fake.GetEndorserMSPIDStub = nil
+	fake.getEndorserMSPIDReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Extractors) GetEndorserMSPIDReturnsOnCall(i int, result1 string, result2 error) {
+	fake.getEndorserMSPIDMutex.Lock()
+	defer fake.getEndorserMSPIDMutex.Unlock()
+	fake.GetEndorserMSPIDStub = nil
+	if fake.getEndorserMSPIDReturnsOnCall == nil {
+		fake.getEndorserMSPIDReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.getEndorserMSPIDReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *Extractors) GetHostParams(arg1 shim.ChaincodeStubInterface) (*protos.HostParameters, error) {
 	fake.getHostParamsMutex.Lock()
 	ret, specificReturn := fake.getHostParamsReturnsOnCall[len(fake.getHostParamsArgsForCall)]
@@ -410,6 +487,8 @@ func (fake *Extractors) Invocations() map[string][][]interface{} {
 	defer fake.getChaincodeParamsMutex.RUnlock()
 	fake.getChaincodeResponseMessagesMutex.RLock()
 	defer fake.getChaincodeResponseMessagesMutex.RUnlock()
+	fake.getEndorserMSPIDMutex.RLock()
+	defer fake.getEndorserMSPIDMutex.RUnlock()
 	fake.getHostParamsMutex.RLock()
 	defer fake.getHostParamsMutex.RUnlock()
 	fake.getInitEnclaveMessageMutex.RLock()
@@ -6,9 +6,22 @@ import (
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-protos-go/peer"
 )
 
 type Validator struct {
+	CheckAndRecordProposalStub        func(shim.ChaincodeStubInterface, *peer.SignedProposal) error
+	checkAndRecordProposalMutex       sync.RWMutex
+	checkAndRecordProposalArgsForCall []struct {
+		arg1 shim.ChaincodeStubInterface
+		arg2 *peer.SignedProposal
+	}
+	checkAndRecordProposalReturns struct {
+		result1 error
+	}
+	checkAndRecordProposalReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ReplayReadWritesStub        func(shim.ChaincodeStubInterface, *protos.FPCKVSet) error
 	replayReadWritesMutex       sync.RWMutex
 	replayReadWritesArgsForCall []struct {
@@ -37,6 +50,68 @@ type Validator struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *Validator) CheckAndRecordProposal(arg1 shim.ChaincodeStubInterface, arg2 *peer.SignedProposal) error {
+	fake.checkAndRecordProposalMutex.Lock()
+	ret, specificReturn := fake.checkAndRecordProposalReturnsOnCall[len(fake.checkAndRecordProposalArgsForCall)]
+	fake.checkAndRecordProposalArgsForCall = append(fake.checkAndRecordProposalArgsForCall, struct {
+		arg1 shim.ChaincodeStubInterface
+		arg2 *peer.SignedProposal
+	}{arg1, arg2})
+	stub := fake.CheckAndRecordProposalStub
+	fakeReturns := fake.checkAndRecordProposalReturns
+	fake.recordInvocation("CheckAndRecordProposal", []interface{}{arg1, arg2})
+	fake.checkAndRecordProposalMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Validator) CheckAndRecordProposalCallCount() int {
+	fake.checkAndRecordProposalMutex.RLock()
+	defer fake.checkAndRecordProposalMutex.RUnlock()
+	return len(fake.checkAndRecordProposalArgsForCall)
+}
+
+func (fake *Validator) CheckAndRecordProposalCalls(stub func(shim.ChaincodeStubInterface, *peer.SignedProposal) error) {
+	fake.checkAndRecordProposalMutex.Lock()
+	defer fake.checkAndRecordProposalMutex.Unlock()
+	fake.CheckAndRecordProposalStub = stub
+}
+
+func (fake *Validator) CheckAndRecordProposalArgsForCall(i int) (shim.ChaincodeStubInterface, *peer.SignedProposal) {
+	fake.checkAndRecordProposalMutex.RLock()
+	defer fake.checkAndRecordProposalMutex.RUnlock()
+	argsForCall := fake.checkAndRecordProposalArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Validator) CheckAndRecordProposalReturns(result1 error) {
+	fake.checkAndRecordProposalMutex.Lock()
+	defer fake.checkAndRecordProposalMutex.Unlock()
+	fake.CheckAndRecordProposalStub = nil
+	fake.checkAndRecordProposalReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Validator) CheckAndRecordProposalReturnsOnCall(i int, result1 error) {
+	fake.checkAndRecordProposalMutex.Lock()
+	defer fake.checkAndRecordProposalMutex.Unlock()
+	fake.CheckAndRecordProposalStub = nil
+	if fake.checkAndRecordProposalReturnsOnCall == nil {
+		fake.checkAndRecordProposalReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.checkAndRecordProposalReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *Validator) ReplayReadWrites(arg1 shim.ChaincodeStubInterface, arg2 *protos.FPCKVSet) error {
 	fake.replayReadWritesMutex.Lock()
 	ret, specificReturn := fake.replayReadWritesReturnsOnCall[len(fake.replayReadWritesArgsForCall)]
@@ -164,6 +239,8 @@ func (fake *Validator) ValidateReturnsOnCall(i int, result1 error) {
 func (fake *Validator) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.checkAndRecordProposalMutex.RLock()
+	defer fake.checkAndRecordProposalMutex.RUnlock()
 	fake.replayReadWritesMutex.RLock()
 	defer fake.replayReadWritesMutex.RUnlock()
 	fake.validateMutex.RLock()
@@ -0,0 +1,136 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chaincode
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
+)
+
+var (
+	requestDuration = metrics.HistogramOpts{
+		Namespace:    "fpc",
+		Subsystem:    "ecc",
+		Name:         "request_duration",
+		Help:         "The time to process an __invoke or __endorse request, in seconds.",
+		LabelNames:   []string{"function", "status"},
+		StatsdFormat: "%{#fqname}.%{function}.%{status}",
+		Buckets:      []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}
+	requestFailures = metrics.CounterOpts{
+		Namespace:    "fpc",
+		Subsystem:    "ecc",
+		Name:         "request_failures",
+		Help:         "The number of failed __invoke or __endorse requests, by reason.",
+		LabelNames:   []string{"function", "reason"},
+		StatsdFormat: "%{#fqname}.%{function}.%{reason}",
+	}
+	requestSize = metrics.HistogramOpts{
+		Namespace:  "fpc",
+		Subsystem:  "ecc",
+		Name:       "request_size",
+		Help:       "The size of the chaincode request messages passed to the enclave, in bytes.",
+		LabelNames: []string{},
+		Buckets:    []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576},
+	}
+	responseSize = metrics.HistogramOpts{
+		Namespace:  "fpc",
+		Subsystem:  "ecc",
+		Name:       "response_size",
+		Help:       "The size of the chaincode response messages returned by the enclave, in bytes.",
+		LabelNames: []string{},
+		Buckets:    []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576},
+	}
+	rwsetReads = metrics.HistogramOpts{
+		Namespace:  "fpc",
+		Subsystem:  "ecc",
+		Name:       "rwset_reads",
+		Help:       "The number of reads in the rwset replayed by __endorse.",
+		LabelNames: []string{},
+		Buckets:    []float64{0, 1, 2, 5, 10, 20, 50, 100},
+	}
+	rwsetWrites = metrics.HistogramOpts{
+		Namespace:  "fpc",
+		Subsystem:  "ecc",
+		Name:       "rwset_writes",
+		Help:       "The number of writes in the rwset replayed by __endorse.",
+		LabelNames: []string{},
+		Buckets:    []float64{0, 1, 2, 5, 10, 20, 50, 100},
+	}
+	enclaveWaitDuration = metrics.HistogramOpts{
+		Namespace:  "fpc",
+		Subsystem:  "ecc",
+		Name:       "enclave_wait_duration",
+		Help:       "The time an enclave call waits for a free enclave slot, in seconds.",
+		LabelNames: []string{},
+		Buckets:    []float64{0.0001, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+	}
+)
+
+// Failure reasons reported by the request_failures metric
+const (
+	ReasonBadRequest     = "bad_request"
+	ReasonEnclaveError   = "enclave_error"
+	ReasonEnclaveState   = "enclave_state"
+	ReasonBufferTooSmall = "buffer_too_small"
+	ReasonChaincodeError = "chaincode_error"
+	ReasonStaleKey       = "stale_key"
+	ReasonErcc           = "ercc"
+	ReasonNotRegistered  = "not_registered"
+	ReasonCCParams       = "cc_params_mismatch"
+	ReasonHostMSP        = "host_msp_mismatch"
+	ReasonSignature      = "validation_failed"
+	ReasonProposal       = "proposal_check_failed"
+	ReasonStaleRead      = "stale_read"
+	ReasonReplay         = "replay_failed"
+)
+
+// Metrics are the metrics of the enclave chaincode
+type Metrics struct {
+	RequestDuration     metrics.Histogram
+	RequestFailures     metrics.Counter
+	RequestSize         metrics.Histogram
+	ResponseSize        metrics.Histogram
+	RwsetReads          metrics.Histogram
+	RwsetWrites         metrics.Histogram
+	EnclaveWaitDuration metrics.Histogram
+}
+
+// NewMetrics returns the enclave chaincode metrics created with the given provider
+func NewMetrics(p metrics.Provider) *Metrics {
+	return &Metrics{
+		RequestDuration:     p.NewHistogram(requestDuration),
+		RequestFailures:     p.NewCounter(requestFailures),
+		RequestSize:         p.NewHistogram(requestSize),
+		ResponseSize:        p.NewHistogram(responseSize),
+		RwsetReads:          p.NewHistogram(rwsetReads),
+		RwsetWrites:         p.NewHistogram(rwsetWrites),
+		EnclaveWaitDuration: p.NewHistogram(enclaveWaitDuration),
+	}
+}
+
+var disabledMetrics = NewMetrics(&disabled.Provider{})
+
+// getMetrics returns the metrics of the enclave chaincode; metrics are disabled if none are set
+func (t *EnclaveChaincode) getMetrics() *Metrics {
+	if t.Metrics == nil {
+		return disabledMetrics
+	}
+	return t.Metrics
+}
+
+// observeRequest records the duration and, if reason is not empty, the failure of a request
+func (m *Metrics) observeRequest(function string, start time.Time, reason string) {
+	status := "success"
+	if reason != "" {
+		status = "failure"
+		m.RequestFailures.With("function", function, "reason", reason).Add(1)
+	}
+	m.RequestDuration.With("function", function, "status", status).Observe(time.Since(start).Seconds())
+}
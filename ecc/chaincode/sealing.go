@@ -0,0 +1,81 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chaincode
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnclaveStateDirEnv defines the directory where the sealed enclave state is stored; if not set, the enclave
+	// state is not persisted
+	EnclaveStateDirEnv = "FPC_ENCLAVE_STATE_DIR"
+
+	sealedStateFileName = "enclave_state.sealed"
+)
+
+// SealedStateStore persists the sealed enclave state
+type SealedStateStore interface {
+	// Store replaces the stored sealed state
+	Store(sealedState []byte) error
+	// Load returns the stored sealed state, or nil if there is none
+	Load() ([]byte, error)
+	// Delete removes the stored sealed state
+	Delete() error
+}
+
+// FileStore stores the sealed enclave state in a local file
+type FileStore struct {
+	Path string
+}
+
+// SealedStateStoreFromEnv returns a FileStore in the directory defined by FPC_ENCLAVE_STATE_DIR, or nil if
+// FPC_ENCLAVE_STATE_DIR is not set
+func SealedStateStoreFromEnv() SealedStateStore {
+	dir := os.Getenv(EnclaveStateDirEnv)
+	if dir == "" {
+		return nil
+	}
+	return &FileStore{Path: filepath.Join(dir, sealedStateFileName)}
+}
+
+func (f *FileStore) Store(sealedState []byte) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return errors.Wrap(err, "cannot create enclave state directory")
+	}
+
+	// write to a temporary file first, so that a crash does not leave a partially written state behind
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, sealedState, 0600); err != nil {
+		return errors.Wrapf(err, "cannot write %s", tmp)
+	}
+	if err := os.Rename(tmp, f.Path); err != nil {
+		return errors.Wrapf(err, "cannot write %s", f.Path)
+	}
+	return nil
+}
+
+func (f *FileStore) Load() ([]byte, error) {
+	sealedState, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", f.Path)
+	}
+	return sealedState, nil
+}
+
+func (f *FileStore) Delete() error {
+	if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "cannot delete %s", f.Path)
+	}
+	return nil
+}
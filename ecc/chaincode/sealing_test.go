@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chaincode
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "state", sealedStateFileName)}
+
+	// nothing stored yet
+	sealedState, err := store.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, sealedState)
+	assert.NoError(t, store.Delete())
+
+	// store and replace
+	assert.NoError(t, store.Store([]byte("someSealedState")))
+	assert.NoError(t, store.Store([]byte("someOtherSealedState")))
+	sealedState, err = store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("someOtherSealedState"), sealedState)
+
+	// delete
+	assert.NoError(t, store.Delete())
+	sealedState, err = store.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, sealedState)
+}
+
+func TestSealedStateStoreFromEnv(t *testing.T) {
+	t.Setenv(EnclaveStateDirEnv, "")
+	assert.Nil(t, SealedStateStoreFromEnv())
+
+	dir := t.TempDir()
+	t.Setenv(EnclaveStateDirEnv, dir)
+	assert.Equal(t, &FileStore{Path: filepath.Join(dir, sealedStateFileName)}, SealedStateStoreFromEnv())
+}
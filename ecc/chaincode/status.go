@@ -0,0 +1,152 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"google.golang.org/protobuf/proto"
+)
+
+// Status describes the enclave chaincode hosted by this peer, as returned (JSON-encoded) by __status
+type Status struct {
+	// State is the lifecycle state of the enclave, see EnclaveState
+	State string `json:"state"`
+	// EnclaveId is the id of the enclave, if the enclave is ready
+	EnclaveId string `json:"enclave_id,omitempty"`
+	// Flavor is the build flavor of the enclave, e.g., FlavorSGX; "unknown" if the enclave does not report it
+	Flavor string `json:"flavor"`
+	// Restored reports whether the enclave was restored from its sealed state ("unverified" or "verified")
+	Restored string `json:"restored,omitempty"`
+	// AttestationType is the attestation type of the enclave credentials, e.g., "simulated"
+	AttestationType string `json:"attestation_type,omitempty"`
+	// CCParams and HostParams are the parameters the enclave was initialized with
+	CCParams   *protos.CCParameters   `json:"cc_params,omitempty"`
+	HostParams *protos.HostParameters `json:"host_params,omitempty"`
+	// Counters are the runtime counters of the enclave invocations since the chaincode started
+	Counters StatusCounters `json:"counters"`
+}
+
+// StatusCounters are the runtime counters of the enclave invocations (__invoke)
+type StatusCounters struct {
+	Invocations uint64 `json:"invocations"`
+	Failures    uint64 `json:"failures"`
+	// InFlight is the number of ongoing invocations, i.e., the concurrent enclave slots in use
+	InFlight int64 `json:"in_flight"`
+	// MaxConcurrency is the number of concurrent enclave slots; 0 if unlimited
+	MaxConcurrency int `json:"max_concurrency"`
+}
+
+// counters tracks the enclave invocations of an EnclaveChaincode
+type counters struct {
+	invocations atomic.Uint64
+	failures    atomic.Uint64
+	inFlight    atomic.Int64
+}
+
+func (c *counters) begin() {
+	c.invocations.Add(1)
+	c.inFlight.Add(1)
+}
+
+func (c *counters) end(err error) {
+	c.inFlight.Add(-1)
+	if err != nil {
+		c.failures.Add(1)
+	}
+}
+
+// enclaveInfo are the parameters and attestation type of the enclave, as known from its initialization or from its
+// registration at ercc (for restored enclaves)
+type enclaveInfo struct {
+	ccParams        *protos.CCParameters
+	hostParams      *protos.HostParameters
+	attestationType string
+}
+
+func (r restoreState) String() string {
+	switch r {
+	case restoredUnverified:
+		return "unverified"
+	case restoredVerified:
+		return "verified"
+	default:
+		return ""
+	}
+}
+
+// setEnclaveInfo records the enclave parameters and the attestation type from the enclave credentials
+func (t *EnclaveChaincode) setEnclaveInfo(ccParams *protos.CCParameters, hostParams *protos.HostParameters, credentials *protos.Credentials) {
+	t.restoreMu.Lock()
+	defer t.restoreMu.Unlock()
+	t.info = &enclaveInfo{
+		ccParams:        ccParams,
+		hostParams:      hostParams,
+		attestationType: attestationType(credentials),
+	}
+}
+
+// attestationType returns the attestation type from the attestation (or evidence) of the credentials
+func attestationType(credentials *protos.Credentials) string {
+	for _, data := range [][]byte{credentials.GetAttestation(), credentials.GetEvidence()} {
+		var attestation types.Attestation
+		if len(data) > 0 && json.Unmarshal(data, &attestation) == nil && attestation.Type != "" {
+			return attestation.Type
+		}
+	}
+	return ""
+}
+
+// status returns the status of the enclave chaincode
+func (t *EnclaveChaincode) status() *Status {
+	status := &Status{
+		Flavor: "unknown",
+		Counters: StatusCounters{
+			Invocations: t.counters.invocations.Load(),
+			Failures:    t.counters.failures.Load(),
+			InFlight:    t.counters.inFlight.Load(),
+		},
+	}
+
+	enclaveId, err := t.Enclave.GetEnclaveId()
+	if reporter, ok := t.Enclave.(EnclaveStatusReporter); ok {
+		status.State = reporter.State().String()
+		status.Flavor = reporter.Flavor()
+		status.Counters.MaxConcurrency = reporter.MaxConcurrency()
+	} else if err == nil {
+		status.State = EnclaveReady.String()
+	} else {
+		status.State = EnclaveUninitialized.String()
+	}
+	// the mock enclave returns an id in any state, thus, the id is only reported for a ready enclave
+	if err == nil && status.State == EnclaveReady.String() {
+		status.EnclaveId = enclaveId
+	}
+
+	t.restoreMu.Lock()
+	defer t.restoreMu.Unlock()
+	status.Restored = t.restoreState.String()
+	if t.info != nil {
+		status.AttestationType = t.info.attestationType
+		status.CCParams = t.info.ccParams
+		status.HostParams = t.info.hostParams
+	}
+
+	return status
+}
+
+// unmarshalCredentials returns the enclave credentials returned by Enclave.Init, or nil if they are invalid
+func unmarshalCredentials(credentialsBytes []byte) *protos.Credentials {
+	credentials := &protos.Credentials{}
+	if err := proto.Unmarshal(credentialsBytes, credentials); err != nil {
+		return nil
+	}
+	return credentials
+}
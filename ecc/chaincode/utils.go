@@ -10,6 +10,8 @@ package chaincode
 import (
 	"encoding/base64"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
@@ -24,6 +26,28 @@ type Extractors interface {
 	GetChaincodeResponseMessages(stub shim.ChaincodeStubInterface) (*protos.SignedChaincodeResponseMessage, *protos.ChaincodeResponseMessage, error)
 	GetChaincodeParams(stub shim.ChaincodeStubInterface) (*protos.CCParameters, error)
 	GetHostParams(stub shim.ChaincodeStubInterface) (*protos.HostParameters, error)
+	GetEndorserMSPID(stub shim.ChaincodeStubInterface) (string, error)
+}
+
+const (
+	// localMSPIDEnv is set by the peer (or the external builder) to the MSP ID of the peer running the chaincode, see
+	// shim.GetMSPID. In chaincode-as-a-service mode it must be set explicitly.
+	localMSPIDEnv = "CORE_PEER_LOCALMSPID"
+
+	// allowedHostMSPIDsEnv optionally defines a comma-separated list of MSP IDs whose enclaves may be endorsed by
+	// this peer in addition to enclaves hosted by its own org.
+	allowedHostMSPIDsEnv = "FPC_ALLOWED_HOST_MSPIDS"
+)
+
+// GetAllowedHostMSPIDsFromEnv returns the MSP IDs defined in FPC_ALLOWED_HOST_MSPIDS
+func GetAllowedHostMSPIDsFromEnv() []string {
+	var mspIds []string
+	for _, mspId := range strings.Split(os.Getenv(allowedHostMSPIDsEnv), ",") {
+		if mspId = strings.TrimSpace(mspId); len(mspId) > 0 {
+			mspIds = append(mspIds, mspId)
+		}
+	}
+	return mspIds
 }
 
 type ExtractorImpl struct {
@@ -139,3 +163,20 @@ func (s *ExtractorImpl) GetHostParams(stub shim.ChaincodeStubInterface) (*protos
 		Certificate:  nil, // todo
 	}, nil
 }
+
+// GetEndorserMSPID returns the MSP ID of the local MSP of the peer that runs this chaincode
+func (s *ExtractorImpl) GetEndorserMSPID(stub shim.ChaincodeStubInterface) (string, error) {
+	return GetLocalMSPID()
+}
+
+// GetLocalMSPID returns the MSP ID of the local MSP of the peer that runs this chaincode, as passed by the peer to
+// the chaincode process. The chaincode must not start without it, as __endorse would fail, thus, ECC checks it at
+// startup.
+func GetLocalMSPID() (string, error) {
+	mspid, err := shim.GetMSPID()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine the MSP ID of the peer running the chaincode: %s is not set; "+
+			"the peer sets it for the chaincodes it launches, in chaincode-as-a-service mode it must be set to the MSP ID of the peer connecting to the chaincode", localMSPIDEnv)
+	}
+	return mspid, nil
+}
@@ -206,3 +206,28 @@ func assertProtoEqual(t *testing.T, expected, actual proto.Message) bool {
 	}
 	return true
 }
+
+func TestGetEndorserMSPID(t *testing.T) {
+	ex := &ExtractorImpl{}
+	stub := &fakes.ChaincodeStub{}
+
+	t.Setenv(localMSPIDEnv, "")
+	mspid, err := ex.GetEndorserMSPID(stub)
+	assert.Empty(t, mspid)
+	assert.ErrorContains(t, err, "CORE_PEER_LOCALMSPID is not set")
+	_, err = GetLocalMSPID()
+	assert.ErrorContains(t, err, "CORE_PEER_LOCALMSPID is not set")
+
+	t.Setenv(localMSPIDEnv, Mspid)
+	mspid, err = ex.GetEndorserMSPID(stub)
+	assert.NoError(t, err)
+	assert.Equal(t, Mspid, mspid)
+}
+
+func TestGetAllowedHostMSPIDsFromEnv(t *testing.T) {
+	t.Setenv(allowedHostMSPIDsEnv, "")
+	assert.Empty(t, GetAllowedHostMSPIDsFromEnv())
+
+	t.Setenv(allowedHostMSPIDsEnv, "Org1MSP, Org2MSP,,")
+	assert.Equal(t, []string{"Org1MSP", "Org2MSP"}, GetAllowedHostMSPIDsFromEnv())
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/enclave"
 	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/ercc"
 	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
+	"github.com/hyperledger/fabric-private-chaincode/internal/metrics"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric/common/flogging"
 )
 
@@ -26,12 +28,50 @@ func main() {
 	// For more fine grained logging we could also use different log level for loggers.
 	// For example: FABRIC_LOGGING_SPEC=ecc=DEBUG:ecc_enclave=ERROR
 
+	// metrics are served if FPC_METRICS_ADDRESS is set
+	metricsProvider, err := metrics.ProviderFromEnv()
+	if err != nil {
+		logger.Panicf("cannot start metrics endpoint: %s", err)
+	}
+	eccMetrics := chaincode.NewMetrics(metricsProvider)
+
+	// audit records are written if FPC_AUDIT_LOG is set
+	auditSink, err := chaincode.AuditSinkFromEnv()
+	if err != nil {
+		logger.Panicf("cannot open audit log: %s", err)
+	}
+
+	// __endorse checks the MSP ID of this peer against the host of the enclave, thus, it must be known
+	if _, err := chaincode.GetLocalMSPID(); err != nil {
+		logger.Panicf("%s", err)
+	}
+
 	// create enclave chaincode
+	enclaveStub := enclave.NewEnclaveStub()
+	enclaveStub.SetMetrics(eccMetrics)
+	defer func() {
+		// tear down the enclave when the chaincode terminates
+		if enclaveStub.State() == chaincode.EnclaveReady {
+			if err := enclaveStub.Destroy(); err != nil {
+				logger.Errorf("cannot destroy enclave: %s", err)
+			}
+		}
+	}()
+
 	ecc := &chaincode.EnclaveChaincode{
-		Enclave:   enclave.NewEnclaveStub(),
+		Enclave:   enclaveStub,
 		Validator: endorsement.NewValidator(),
 		Extractor: &chaincode.ExtractorImpl{},
 		Ercc:      &ercc.StubImpl{},
+
+		AllowedHostMSPIDs: chaincode.GetAllowedHostMSPIDsFromEnv(),
+		Metrics:           eccMetrics,
+		Audit:             auditSink,
+	}
+
+	// restore the enclave after a chaincode restart, if FPC_ENCLAVE_STATE_DIR is set and contains a sealed state
+	if err := ecc.RestoreEnclave(); err != nil {
+		logger.Errorf("cannot restore enclave: %s", err)
 	}
 
 	ccid := os.Getenv("CHAINCODE_PKG_ID")
@@ -39,13 +79,19 @@ func main() {
 
 	if len(ccid) > 0 && len(addr) > 0 {
 		// start chaincode as a service
+		tlsProps, err := utils.GetChaincodeServerTLSProperties()
+		if err != nil {
+			logger.Panicf("invalid TLS configuration for fpc chaincode: %s", err)
+		}
+		if tlsProps.Disabled {
+			logger.Warningf("TLS is disabled, set %s, %s and %s to enable mutual TLS", utils.ChaincodeTLSKeyEnv, utils.ChaincodeTLSCertEnv, utils.ChaincodeClientCACertEnv)
+		}
+
 		server := &shim.ChaincodeServer{
-			CCID:    ccid,
-			Address: addr,
-			CC:      ecc,
-			TLSProps: shim.TLSProperties{
-				Disabled: true,
-			},
+			CCID:     ccid,
+			Address:  addr,
+			CC:       ecc,
+			TLSProps: tlsProps,
 		}
 
 		logger.Infof("starting fpc chaincode (%s)", ccid)
@@ -24,6 +24,9 @@
 
 #include <mbusafecrt.h> /* for memcpy_s etc */
 
+// error message returned for batch requests, which are supported by Go chaincodes only
+#define FPC_BATCH_UNSUPPORTED_MESSAGE "batch unsupported"
+
 int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
     uint32_t signed_proposal_proto_bytes_len,
     const uint8_t* cc_request_message_bytes,
@@ -43,9 +46,11 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
     t_shim_ctx_t ctx;
     int ret;
     int invoke_ret;
+    bool batch_unsupported = false;
     // estimate max response len (take into account other fields and b64 encoding)
     uint32_t response_len = signed_cc_response_message_bytes_len_in / 4 * 3 - 1024;
-    uint8_t response[signed_cc_response_message_bytes_len_in / 4 * 3];
+    // the response buffer is allocated on the heap, as the response buffer size is configurable
+    ByteArray response;
     uint32_t response_len_out = 0;
     ByteArray proto_response_bytes;
     ByteArray cc_response_message;
@@ -174,7 +179,15 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
                         cc_request_message.encrypted_key_transport_message->size);
             b = g_cc_data->decrypt_key_transport_message(
                 encrypted_key_transport_message, key_transport);
-            COND2LOGERR(!b, "cannot decrypt key transport message");
+            if (!b)
+            {
+                // report with a dedicated error code, so that clients can refresh the chaincode
+                // encryption key
+                LOG_ERROR("cannot decrypt key transport message");
+                pb_release(fpc_ChaincodeRequestMessage_fields, &cc_request_message);
+                *signed_cc_response_message_bytes_len_out = 0;
+                return FPC_ERROR_KEY_TRANSPORT;
+            }
         }
 
         // set stream for KeyTransportMessage
@@ -205,10 +218,15 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
             (const unsigned char*)clear_request.data(), clear_request.size());
         b = pb_decode(&istream, fpc_CleartextChaincodeRequest_fields, &cleartext_cc_request);
         COND2LOGERR(!b, PB_GET_ERROR(&istream));
-        COND2LOGERR(!cleartext_cc_request.has_input, "no input in cleartext request");
+
+        // batches are only supported by Go chaincodes; we answer them with an explicit error
+        // instead of invoking the chaincode with the (ignored) input
+        batch_unsupported = cleartext_cc_request.has_batch;
+        COND2LOGERR(!batch_unsupported && !cleartext_cc_request.has_input,
+            "no input in cleartext request");
 
         // prepare input arguments
-        for (int i = 0; i < cleartext_cc_request.input.args_count; i++)
+        for (int i = 0; !batch_unsupported && i < cleartext_cc_request.input.args_count; i++)
         {
             ctx.string_args.push_back(
                 std::string((const char*)cleartext_cc_request.input.args[i]->bytes,
@@ -218,9 +236,20 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
         // the dynamic memory in the message is released at the end
     }
 
+    CATCH(b, response.resize(signed_cc_response_message_bytes_len_in / 4 * 3));
+    COND2LOGERR(!b, "cannot allocate response buffer");
+
     try
     {
-        invoke_ret = invoke(response, response_len, &response_len_out, &ctx);
+        if (batch_unsupported)
+        {
+            LOG_ERROR("batch requests are not supported by this chaincode");
+            invoke_ret = -1;
+        }
+        else
+        {
+            invoke_ret = invoke(response.data(), response_len, &response_len_out, &ctx);
+        }
         // invoke_ret is not checked
 
         // TODO double check or rethink if it is appropriate for a chaincode
@@ -233,6 +262,23 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
         COND2LOGERR(true, e.what());
     }
 
+    // a chaincode whose response does not fit into the response buffer fails and reports the
+    // required size as actual_response_len (see shim.h); we tell the caller the size of the signed
+    // response buffer that leaves enough room for it, so the invocation can be retried
+    if (invoke_ret != 0 && response_len_out > response_len)
+    {
+        // inverse of response_len = signed_cc_response_message_bytes_len_in / 4 * 3 - 1024
+        uint64_t required_len = ((uint64_t)response_len_out + 1024 + 2) / 3 * 4;
+        LOG_ERROR("chaincode response buffer too small: %u bytes available, %u bytes required",
+            response_len, response_len_out);
+        pb_release(fpc_ChaincodeRequestMessage_fields, &cc_request_message);
+        pb_release(fpc_CleartextChaincodeRequest_fields, &cleartext_cc_request);
+        pb_release(fpc_KeyTransportMessage_fields, &key_transport_message);
+        *signed_cc_response_message_bytes_len_out =
+            required_len > UINT32_MAX ? UINT32_MAX : (uint32_t)required_len;
+        return FPC_ERROR_BUFFER_TOO_SMALL;
+    }
+
     // wrap invocation response in a peer.Response message
     {
         protos_Response protoResponse = {};
@@ -247,8 +293,8 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
             COND2LOGERR(protoResponse.payload == NULL, "cannot allocate response payload");
             protoResponse.payload->size = response_len_out;
 
-            ret = memcpy_s(protoResponse.payload->bytes, protoResponse.payload->size, response,
-                response_len_out);
+            ret = memcpy_s(protoResponse.payload->bytes, protoResponse.payload->size,
+                response.data(), response_len_out);
             COND2LOGERR(ret != 0, "cannot encode field");
         }
         else
@@ -256,6 +302,15 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
             // set error
             protoResponse.status = 500;
             // TODO set response.message with proper error message as reported by user
+            if (batch_unsupported)
+            {
+                protoResponse.message =
+                    (char*)pb_realloc(NULL, sizeof(FPC_BATCH_UNSUPPORTED_MESSAGE));
+                COND2LOGERR(protoResponse.message == NULL, "cannot allocate response message");
+                ret = memcpy_s(protoResponse.message, sizeof(FPC_BATCH_UNSUPPORTED_MESSAGE),
+                    FPC_BATCH_UNSUPPORTED_MESSAGE, sizeof(FPC_BATCH_UNSUPPORTED_MESSAGE));
+                COND2LOGERR(ret != 0, "cannot encode field");
+            }
         }
 
         // estimate response message size and resize buffer
@@ -400,6 +455,24 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
             signature.size());
         COND2LOGERR(ret != 0, "cannot encode field");
 
+        // check that the response fits into the output buffer; if not, tell the caller the
+        // required size so it can retry with a larger buffer
+        size_t signed_crm_estimated_size;
+        b = pb_get_encoded_size(
+            &signed_crm_estimated_size, fpc_SignedChaincodeResponseMessage_fields, &signed_crm);
+        COND2LOGERR(!b, "cannot estimate signed response message size");
+        if (signed_crm_estimated_size > signed_cc_response_message_bytes_len_in)
+        {
+            LOG_ERROR("response buffer too small: %u bytes available, %zu bytes required",
+                signed_cc_response_message_bytes_len_in, signed_crm_estimated_size);
+            pb_release(fpc_SignedChaincodeResponseMessage_fields, &signed_crm);
+            pb_release(fpc_ChaincodeRequestMessage_fields, &cc_request_message);
+            pb_release(fpc_CleartextChaincodeRequest_fields, &cleartext_cc_request);
+            pb_release(fpc_KeyTransportMessage_fields, &key_transport_message);
+            *signed_cc_response_message_bytes_len_out = signed_crm_estimated_size;
+            return FPC_ERROR_BUFFER_TOO_SMALL;
+        }
+
         // encode proto
         ostream = pb_ostream_from_buffer(
             signed_cc_response_message_bytes, signed_cc_response_message_bytes_len_in);
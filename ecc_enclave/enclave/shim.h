@@ -51,6 +51,10 @@ typedef std::vector<uint8_t> ByteArray;
 // Function which FPC chaincode has to implement
 // ==================================================
 // - invoke, called when a transaction query or invocation is executed
+//   The response is written to response and its size to actual_response_len. If the response
+//   does not fit into max_response_len bytes, invoke should set actual_response_len to the
+//   required size and return a non-zero value; the invocation is then retried with a larger
+//   response buffer, up to the max response buffer size configured for the peer.
 int invoke(uint8_t* response,
     uint32_t max_response_len,
     uint32_t* actual_response_len,
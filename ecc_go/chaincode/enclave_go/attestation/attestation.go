@@ -7,13 +7,45 @@ SPDX-License-Identifier: Apache-2.0
 package attestation
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/device"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
-func Issue(attestedData *anypb.Any) ([]byte, error) {
-	issuer := simulation.NewSimulationIssuer()
+const (
+	// IssuerEnv selects the attestation issuer, either SimulationIssuer or DeviceIssuer
+	IssuerEnv = "FPC_ATTESTATION_ISSUER"
+
+	// SimulationIssuer issues simulated attestations
+	SimulationIssuer = "simulated"
+	// DeviceIssuer issues attestations with quotes from the attestation device (see device.Path), e.g., when the
+	// chaincode runs with Gramine
+	DeviceIssuer = "device"
+)
+
+// defaultIssuer is used if IssuerEnv is not set; built with the WITH_ATTESTATION_DEVICE tag, it is DeviceIssuer
+var defaultIssuer = SimulationIssuer
+
+// attestationParams are the attestation parameters passed to Init, see sgx.AttestationParams in the client SDK
+type attestationParams struct {
+	AttestationType string `json:"attestation_type"`
+}
+
+// Issue returns the attestation of the attested data, created by the issuer selected by IssuerEnv
+func Issue(attestedData *anypb.Any, serializedAttestationParams []byte) ([]byte, error) {
+	issuer, err := newIssuer(serializedAttestationParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create attestation issuer")
+	}
+
 	att, err := issuer.Issue(attestedData.Value)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot get attestation")
@@ -21,3 +53,37 @@ func Issue(attestedData *anypb.Any) ([]byte, error) {
 
 	return att, nil
 }
+
+func newIssuer(serializedAttestationParams []byte) (*types.Issuer, error) {
+	issuer := os.Getenv(IssuerEnv)
+	if len(issuer) == 0 {
+		issuer = defaultIssuer
+	}
+
+	switch issuer {
+	case SimulationIssuer:
+		return simulation.NewSimulationIssuer(), nil
+	case DeviceIssuer:
+		// the attestation params tell whether the SPID of the platform is linkable, if EPID is used
+		epidType := epid.UnlinkableType
+		if params := parseAttestationParams(serializedAttestationParams); params.AttestationType == epid.LinkableType {
+			epidType = epid.LinkableType
+		}
+		return device.NewDeviceIssuer(device.Path(), epidType)
+	default:
+		return nil, fmt.Errorf("unknown attestation issuer '%s'", issuer)
+	}
+}
+
+// parseAttestationParams parses the attestation params, which are JSON, possibly base64-encoded; invalid params are
+// ignored
+func parseAttestationParams(serializedAttestationParams []byte) *attestationParams {
+	params := &attestationParams{}
+	if err := json.Unmarshal(serializedAttestationParams, params); err == nil {
+		return params
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(string(serializedAttestationParams)); err == nil {
+		_ = json.Unmarshal(decoded, params)
+	}
+	return params
+}
@@ -0,0 +1,13 @@
+//go:build WITH_ATTESTATION_DEVICE
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+func init() {
+	defaultIssuer = DeviceIssuer
+}
@@ -0,0 +1,57 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// invokeBatch invokes the chaincode for each input of the batch and returns a response with a serialized
+// CleartextChaincodeBatchResponse as payload together with the resulting rwset.
+//
+// Each invocation is executed with its own rwset, which is merged into the resulting rwset if the invocation succeeds.
+// Reads of keys written by a preceding successful invocation are served from its pending writes, thus, the
+// invocations observe the same state as if they were executed one after another. By default, the batch fails as a
+// whole if one invocation fails. If the batch is independent, failed invocations are reported in the batch response
+// and their writes are discarded.
+func (e *EnclaveStub) invokeBatch(stub shim.ChaincodeStubInterface, batch *protos.CleartextChaincodeBatchRequest) (pb.Response, *readWriteSet) {
+	rwset := NewReadWriteSet()
+
+	if len(batch.GetInputs()) == 0 {
+		return shim.Error("empty batch"), rwset
+	}
+
+	batchResponse := &protos.CleartextChaincodeBatchResponse{}
+	for i, input := range batch.GetInputs() {
+		invocationRWSet := newBatchReadWriteSet(rwset)
+
+		fpcStub := e.stubProvider(stub, input, invocationRWSet, e.ccKeys)
+		ccResponse := e.ccRef.Invoke(fpcStub)
+		batchResponse.Responses = append(batchResponse.Responses, &ccResponse)
+
+		if ccResponse.GetStatus() >= shim.ERRORTHRESHOLD {
+			if !batch.GetIndependent() {
+				logger.Debugf("batch invocation %d failed, aborting batch", i)
+				return shim.Error(fmt.Sprintf("batch invocation %d failed: %s", i, ccResponse.GetMessage())), NewReadWriteSet()
+			}
+			continue
+		}
+		rwset.merge(invocationRWSet)
+	}
+
+	payload, err := proto.Marshal(batchResponse)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("cannot marshal batch response: %s", err)), NewReadWriteSet()
+	}
+
+	return shim.Success(payload), rwset
+}
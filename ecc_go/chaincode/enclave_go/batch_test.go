@@ -0,0 +1,251 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/ecc_go/chaincode/enclave_go/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+
+//counterfeiter:generate -o fakes/chaincodestub.go -fake-name ChaincodeStub . chaincodeStub
+//lint:ignore U1000 This is just used to generate fake
+type chaincodeStub interface {
+	shim.ChaincodeStubInterface
+}
+
+// kvChaincode supports `put <key> <value>`, `get <key>` (returning the value as payload), `del <key>` and
+// `fail <key> <value>`, which writes the key and then fails
+type kvChaincode struct{}
+
+func (kvChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (kvChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	switch function {
+	case "put":
+		if err := stub.PutState(args[0], []byte(args[1])); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	case "get":
+		value, err := stub.GetState(args[0])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(value)
+	case "del":
+		if err := stub.DelState(args[0]); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	case "fail":
+		if err := stub.PutState(args[0], []byte(args[1])); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Error("failed on purpose")
+	}
+	return shim.Error(fmt.Sprintf("unknown function %s", function))
+}
+
+// plainState is a StateEncryptionFunctions that does not encrypt the state
+type plainState struct{}
+
+func (plainState) EncryptState(plaintext []byte) ([]byte, error) { return plaintext, nil }
+
+func (plainState) DecryptState(ciphertext []byte) ([]byte, error) { return ciphertext, nil }
+
+func newTestEnclaveStub() *EnclaveStub {
+	return &EnclaveStub{
+		ccRef: kvChaincode{},
+		stubProvider: func(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, _ StateEncryptionFunctions) shim.ChaincodeStubInterface {
+			return NewFpcStubInterface(stub, input, rwset, plainState{})
+		},
+	}
+}
+
+// newLedgerStub returns a Fabric stub serving the given ledger state
+func newLedgerStub(state map[string][]byte) *fakes.ChaincodeStub {
+	stub := &fakes.ChaincodeStub{}
+	stub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	return stub
+}
+
+func newBatch(independent bool, invocations ...[]string) *protos.CleartextChaincodeBatchRequest {
+	batch := &protos.CleartextChaincodeBatchRequest{Independent: independent}
+	for _, invocation := range invocations {
+		input := &pb.ChaincodeInput{}
+		for _, arg := range invocation {
+			input.Args = append(input.Args, []byte(arg))
+		}
+		batch.Inputs = append(batch.Inputs, input)
+	}
+	return batch
+}
+
+func batchResponses(t *testing.T, response pb.Response) []*pb.Response {
+	batchResponse := &protos.CleartextChaincodeBatchResponse{}
+	require.NoError(t, proto.Unmarshal(response.GetPayload(), batchResponse))
+	return batchResponse.GetResponses()
+}
+
+func writes(rwset *readWriteSet) map[string]string {
+	w := make(map[string]string)
+	for _, kvwrite := range rwset.ToFPCKVSet().GetRwSet().GetWrites() {
+		if kvwrite.GetIsDelete() {
+			w[kvwrite.GetKey()] = "<deleted>"
+			continue
+		}
+		w[kvwrite.GetKey()] = string(kvwrite.GetValue())
+	}
+	return w
+}
+
+func reads(rwset *readWriteSet) []string {
+	var r []string
+	for _, kvread := range rwset.ToFPCKVSet().GetRwSet().GetReads() {
+		r = append(r, kvread.GetKey())
+	}
+	return r
+}
+
+func TestInvokeBatchReadsPendingWrites(t *testing.T) {
+	e := newTestEnclaveStub()
+	stub := newLedgerStub(map[string][]byte{"a": []byte("ledger"), "b": []byte("ledger")})
+
+	response, rwset := e.invokeBatch(stub, newBatch(false,
+		[]string{"put", "a", "batch"},
+		[]string{"get", "a"},
+		[]string{"del", "b"},
+		[]string{"get", "b"},
+		[]string{"get", "c"},
+	))
+	require.EqualValues(t, shim.OK, response.GetStatus(), response.GetMessage())
+
+	responses := batchResponses(t, response)
+	require.Len(t, responses, 5)
+	assert.Equal(t, []byte("batch"), responses[1].GetPayload())
+	assert.Empty(t, responses[3].GetPayload())
+
+	// only the key not written by a preceding invocation is read from the ledger
+	assert.Equal(t, []string{"c"}, reads(rwset))
+	assert.Equal(t, 1, stub.GetStateCallCount())
+	assert.Equal(t, map[string]string{"a": "batch", "b": "<deleted>"}, writes(rwset))
+}
+
+func TestInvokeBatchDependentFailure(t *testing.T) {
+	e := newTestEnclaveStub()
+	stub := newLedgerStub(map[string][]byte{"a": []byte("ledger")})
+
+	response, rwset := e.invokeBatch(stub, newBatch(false,
+		[]string{"get", "a"},
+		[]string{"put", "b", "1"},
+		[]string{"fail", "c", "2"},
+		[]string{"put", "d", "3"},
+	))
+	assert.EqualValues(t, shim.ERROR, response.GetStatus())
+	assert.Contains(t, response.GetMessage(), "batch invocation 2 failed: failed on purpose")
+
+	// the batch is aborted as a whole, neither the reads nor the writes of the successful invocations are kept
+	fpcRwSet := rwset.ToFPCKVSet()
+	assert.Empty(t, fpcRwSet.GetRwSet().GetReads())
+	assert.Empty(t, fpcRwSet.GetRwSet().GetWrites())
+	assert.Empty(t, fpcRwSet.GetReadValueHashes())
+}
+
+func TestInvokeBatchIndependentFailure(t *testing.T) {
+	e := newTestEnclaveStub()
+	stub := newLedgerStub(map[string][]byte{})
+
+	response, rwset := e.invokeBatch(stub, newBatch(true,
+		[]string{"put", "a", "1"},
+		[]string{"fail", "b", "2"},
+		[]string{"get", "b"},
+		[]string{"put", "c", "3"},
+	))
+	require.EqualValues(t, shim.OK, response.GetStatus(), response.GetMessage())
+
+	responses := batchResponses(t, response)
+	require.Len(t, responses, 4)
+	assert.EqualValues(t, shim.OK, responses[0].GetStatus())
+	assert.EqualValues(t, shim.ERROR, responses[1].GetStatus())
+	assert.Equal(t, "failed on purpose", responses[1].GetMessage())
+	assert.EqualValues(t, shim.OK, responses[3].GetStatus())
+
+	// the write of the failed invocation is neither visible to later invocations nor part of the rwset
+	assert.Empty(t, responses[2].GetPayload())
+	assert.Equal(t, []string{"b"}, reads(rwset))
+	assert.Equal(t, map[string]string{"a": "1", "c": "3"}, writes(rwset))
+}
+
+func TestInvokeBatchEmpty(t *testing.T) {
+	e := newTestEnclaveStub()
+
+	response, rwset := e.invokeBatch(newLedgerStub(nil), newBatch(false))
+	assert.EqualValues(t, shim.ERROR, response.GetStatus())
+	assert.Equal(t, "empty batch", response.GetMessage())
+	assert.Empty(t, rwset.ToFPCKVSet().GetRwSet().GetWrites())
+}
+
+func TestBatchReadWriteSet(t *testing.T) {
+	pending := NewReadWriteSet()
+	pending.AddWrite("\x00asset\x00k1\x00", []byte("v1"))
+	pending.AddDelete("k2")
+
+	rwset := newBatchReadWriteSet(pending)
+
+	w, ok := rwset.PendingWrite("\x00asset\x00k1\x00")
+	require.True(t, ok)
+	assert.Equal(t, []byte("v1"), w.GetValue())
+	w, ok = rwset.PendingWrite("k2")
+	require.True(t, ok)
+	assert.True(t, w.GetIsDelete())
+	_, ok = rwset.PendingWrite("k3")
+	assert.False(t, ok)
+
+	assert.True(t, rwset.HasPendingWrites("\x00asset\x00"))
+	assert.False(t, rwset.HasPendingWrites("\x00other\x00"))
+
+	// a rwset outside of a batch has no pending writes
+	_, ok = NewReadWriteSet().PendingWrite("k2")
+	assert.False(t, ok)
+	assert.False(t, NewReadWriteSet().HasPendingWrites(""))
+}
+
+func TestReadWriteSetMerge(t *testing.T) {
+	rwset := NewReadWriteSet()
+	rwset.AddRead("k1", []byte("hash1"))
+	rwset.AddWrite("k1", []byte("v1"))
+	rwset.AddWrite("k2", []byte("v2"))
+
+	other := NewReadWriteSet()
+	other.AddRead("k1", []byte("other hash1"))
+	other.AddRead("k3", []byte("hash3"))
+	other.AddWrite("k1", []byte("other v1"))
+	other.AddDelete("k2")
+
+	rwset.merge(other)
+
+	// the first read of a key is kept, as it is the one depending on the ledger state
+	assert.Equal(t, []byte("hash1"), rwset.reads["k1"].hash)
+	assert.Equal(t, []byte("hash3"), rwset.reads["k3"].hash)
+	// later writes overwrite earlier ones
+	assert.Equal(t, map[string]string{"k1": "other v1", "k2": "<deleted>"}, writes(rwset))
+}
@@ -13,10 +13,13 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"sync"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
 	"github.com/hyperledger/fabric-private-chaincode/ecc_go/chaincode/enclave_go/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/bccsp"
@@ -31,14 +34,20 @@ import (
 var logger = flogging.MustGetLogger("enclave_go")
 
 type EnclaveStub struct {
-	csp                  crypto.CSP
-	ccRef                shim.Chaincode
+	csp   crypto.CSP
+	ccRef shim.Chaincode
+	// mu guards the enclave identity, chaincode keys and parameters. As with the lifecycle of the cgo enclave stub,
+	// invocations hold a read lock, so that Init, Restore and Discard wait until ongoing invocations have completed.
+	mu                   sync.RWMutex
 	identity             *EnclaveIdentity
 	ccKeys               *ChaincodeKeys
 	hostParams           *protos.HostParameters
 	chaincodeParams      *protos.CCParameters
 	fabricCryptoProvider bccsp.BCCSP
 	stubProvider         func(shim.ChaincodeStubInterface, *pb.ChaincodeInput, *readWriteSet, StateEncryptionFunctions) shim.ChaincodeStubInterface
+	sealedStateStore     chaincode.SealedStateStore
+	sealer               SealingProvider
+	flavor               string
 }
 
 func NewEnclaveStub(cc shim.Chaincode) *EnclaveStub {
@@ -47,19 +56,25 @@ func NewEnclaveStub(cc shim.Chaincode) *EnclaveStub {
 	}
 	cryptoProvider := factory.GetDefault()
 
-	return &EnclaveStub{
+	e := &EnclaveStub{
 		csp:                  crypto.GetDefaultCSP(),
 		ccRef:                cc,
 		fabricCryptoProvider: cryptoProvider,
+		flavor:               chaincode.FlavorGo,
 		stubProvider: func(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions) shim.ChaincodeStubInterface {
 			return NewFpcStubInterface(stub, input, rwset, sep)
 		},
 	}
+	e.sealingFromEnv()
+	return e
 }
 
 func (e *EnclaveStub) Init(serializedChaincodeParams, serializedHostParamsBytes, serializedAttestationParams []byte) ([]byte, error) {
 	logger.Debug("Init enclave")
 
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	var err error
 
 	// generate new enclave identity
@@ -92,7 +107,7 @@ func (e *EnclaveStub) Init(serializedChaincodeParams, serializedHostParamsBytes,
 		ChaincodeEk: e.ccKeys.GetPublicKey(),
 	})
 
-	att, err := attestation.Issue(serializedAttestedData)
+	att, err := attestation.Issue(serializedAttestedData, serializedAttestationParams)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create attestation")
 	}
@@ -104,25 +119,51 @@ func (e *EnclaveStub) Init(serializedChaincodeParams, serializedHostParamsBytes,
 
 	logger.Infof("Create credentials: %s", credentials)
 
+	if err := e.sealState(serializedChaincodeParams, serializedHostParamsBytes, serializedAttestationParams); err != nil {
+		return nil, errors.Wrap(err, "cannot persist enclave state")
+	}
+
 	return proto.Marshal(credentials)
 }
 
-func (e EnclaveStub) GenerateCCKeys() ([]byte, error) {
+func (e *EnclaveStub) GenerateCCKeys() ([]byte, error) {
 	panic("implement me")
 	// -> *protos.SignedCCKeyRegistrationMessage
 }
 
-func (e EnclaveStub) ExportCCKeys(credentials []byte) ([]byte, error) {
+func (e *EnclaveStub) ExportCCKeys(credentials []byte) ([]byte, error) {
 	panic("implement me")
 	// credentials *protos.Credentials -> *protos.SignedExportMessage,
 }
 
-func (e EnclaveStub) ImportCCKeys() ([]byte, error) {
+func (e *EnclaveStub) ImportCCKeys() ([]byte, error) {
 	panic("implement me")
 	// -> *protos.SignedCCKeyRegistrationMessage
 }
 
+// State returns chaincode.EnclaveReady once the enclave identity is created (or restored)
+func (e *EnclaveStub) State() chaincode.EnclaveState {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.identity == nil {
+		return chaincode.EnclaveUninitialized
+	}
+	return chaincode.EnclaveReady
+}
+
+// Flavor returns chaincode.FlavorGo, or chaincode.FlavorSKVS if the chaincode uses the single key-value store
+func (e *EnclaveStub) Flavor() string {
+	return e.flavor
+}
+
+// MaxConcurrency returns 0 as the Go enclave does not limit concurrent invocations
+func (e *EnclaveStub) MaxConcurrency() int {
+	return 0
+}
+
 func (e *EnclaveStub) GetEnclaveId() (string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	if e.identity == nil {
 		return "", fmt.Errorf("enclave not yet initliazed")
 	}
@@ -133,6 +174,12 @@ func (e *EnclaveStub) GetEnclaveId() (string, error) {
 func (e *EnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, chaincodeRequestMessageBytes []byte) ([]byte, error) {
 	logger.Debug("ChaincodeInvoke")
 
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.identity == nil {
+		return nil, &chaincode.StateError{Op: "invoke", State: chaincode.EnclaveUninitialized}
+	}
+
 	signedProposal, err := stub.GetSignedProposal()
 	if err != nil {
 		return nil, err
@@ -161,13 +208,26 @@ func (e *EnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, chaincod
 		return nil, errors.Wrap(err, "cannot decrypt chaincode request")
 	}
 
-	// create a new instance of a FPC RWSet that we pass to the stub and later return with the response
-	rwset := NewReadWriteSet()
+	var rwset *readWriteSet
+	var ccResponse pb.Response
+	if batch := cleartextChaincodeRequest.GetBatch(); batch != nil {
+		ccResponse, rwset = e.invokeBatch(stub, batch)
+	} else {
+		// create a new instance of a FPC RWSet that we pass to the stub and later return with the response
+		rwset = NewReadWriteSet()
+
+		// Invoke chaincode
+		// we wrap the stub with our FpcStubInterface
+		fpcStub := e.stubProvider(stub, cleartextChaincodeRequest.GetInput(), rwset, e.ccKeys)
+		ccResponse = e.ccRef.Invoke(fpcStub)
+	}
 
-	// Invoke chaincode
-	// we wrap the stub with our FpcStubInterface
-	fpcStub := e.stubProvider(stub, cleartextChaincodeRequest.GetInput(), rwset, e.ccKeys)
-	ccResponse := e.ccRef.Invoke(fpcStub)
+	// apply the FPC rwset to the Fabric stub, as __endorse does, so that the endorsements of a directly submitted
+	// __invoke carry the writes of the enclave (see vscc/plugin)
+	fpcRwSet := rwset.ToFPCKVSet()
+	if err := endorsement.NewValidator().ReplayReadWrites(stub, fpcRwSet); err != nil {
+		return nil, errors.Wrap(err, "cannot apply rwset")
+	}
 
 	// marshal chaincode response
 	ccResponseBytes, err := protoutil.Marshal(&ccResponse)
@@ -185,7 +245,7 @@ func (e *EnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, chaincod
 
 	response := &protos.ChaincodeResponseMessage{
 		EncryptedResponse:           encryptedResponse,
-		FpcRwSet:                    rwset.ToFPCKVSet(),
+		FpcRwSet:                    fpcRwSet,
 		EnclaveId:                   e.identity.GetEnclaveId(),
 		Proposal:                    signedProposal,
 		ChaincodeRequestMessageHash: chaincodeRequestMessageHash[:],
@@ -306,7 +366,7 @@ func (e *EnclaveStub) extractKeyTransportMessage(chaincodeRequestMessage *protos
 	// decrypt key transport message with chaincode decryption key
 	keyTransportMessageBytes, err := e.ccKeys.PkDecryptMessage(chaincodeRequestMessage.GetEncryptedKeyTransportMessage())
 	if err != nil {
-		return nil, errors.Wrap(err, "decryption of key transport message failed")
+		return nil, &chaincode.KeyTransportError{Err: err}
 	}
 
 	keyTransportMessage := &protos.KeyTransportMessage{}
@@ -45,6 +45,18 @@ func NewEnclaveIdentity(csp crypto.CSP) (*EnclaveIdentity, error) {
 	return e, nil
 }
 
+// RestoreEnclaveIdentity returns the enclave identity with the given keys, e.g., as restored from the sealed
+// enclave state
+func RestoreEnclaveIdentity(csp crypto.CSP, privateKey, publicKey []byte) *EnclaveIdentity {
+	pubHash := sha256.Sum256(publicKey)
+	return &EnclaveIdentity{
+		csp:        csp,
+		privateKey: privateKey,
+		publicKey:  publicKey,
+		enclaveId:  strings.ToUpper(hex.EncodeToString(pubHash[:])),
+	}
+}
+
 func (e *EnclaveIdentity) Sign(msg []byte) (signature []byte, err error) {
 	signature, err = e.csp.SignMessage(e.privateKey, msg)
 	return
@@ -96,6 +108,17 @@ func NewChaincodeKeys(csp crypto.CSP) (*ChaincodeKeys, error) {
 	return c, nil
 }
 
+// RestoreChaincodeKeys returns the chaincode keys with the given keys, e.g., as restored from the sealed
+// enclave state
+func RestoreChaincodeKeys(csp crypto.CSP, ccPrivateKey, ccPublicKey, stateKey []byte) *ChaincodeKeys {
+	return &ChaincodeKeys{
+		csp:          csp,
+		ccPrivateKey: ccPrivateKey,
+		ccPublicKey:  ccPublicKey,
+		stateKey:     stateKey,
+	}
+}
+
 func (c *ChaincodeKeys) GetPublicKey() []byte {
 	return c.ccPublicKey
 }
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package enclave_go
 
 import (
+	"strings"
 	"sync"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
@@ -18,6 +19,10 @@ type ReadWriteSet interface {
 	AddWrite(key string, value []byte)
 	AddDelete(key string)
 	ToFPCKVSet() *protos.FPCKVSet
+	// PendingWrite returns the write of key by a preceding invocation of the same batch, if any
+	PendingWrite(key string) (*kvrwset.KVWrite, bool)
+	// HasPendingWrites returns true if a preceding invocation of the same batch has written a key with the given prefix
+	HasPendingWrites(prefix string) bool
 }
 
 type read struct {
@@ -33,6 +38,9 @@ type readWriteSet struct {
 	mu     sync.Mutex
 	reads  map[string]read
 	writes map[string]write
+
+	// pending is the rwset of the preceding invocations of a batch, whose writes are not yet in the ledger state
+	pending *readWriteSet
 }
 
 func NewReadWriteSet() *readWriteSet {
@@ -42,6 +50,18 @@ func NewReadWriteSet() *readWriteSet {
 	}
 }
 
+// newBatchReadWriteSet returns a rwset for an invocation of a batch that observes the writes in pending
+func newBatchReadWriteSet(pending *readWriteSet) *readWriteSet {
+	rwset := NewReadWriteSet()
+	rwset.pending = pending
+	return rwset
+}
+
+// AddRead records a read of the given key together with the hash of the value read.
+// Read versions are not captured: the chaincode shim does not expose the ledger version of a key, hence, the version
+// of the read remains unset. Instead, `__endorse` compares the value hash to detect stale reads (see
+// endorsement.StaleReadStatus), and the reads it replays are versioned by the peer and checked by Fabric's MVCC
+// validation at commit time.
 func (rwset *readWriteSet) AddRead(key string, hash []byte) {
 	rwset.mu.Lock()
 	defer rwset.mu.Unlock()
@@ -77,6 +97,47 @@ func (rwset *readWriteSet) AddDelete(key string) {
 	}
 }
 
+func (rwset *readWriteSet) PendingWrite(key string) (*kvrwset.KVWrite, bool) {
+	if rwset.pending == nil {
+		return nil, false
+	}
+	rwset.pending.mu.Lock()
+	defer rwset.pending.mu.Unlock()
+	w, ok := rwset.pending.writes[key]
+	return w.kvwrite, ok
+}
+
+func (rwset *readWriteSet) HasPendingWrites(prefix string) bool {
+	if rwset.pending == nil {
+		return false
+	}
+	rwset.pending.mu.Lock()
+	defer rwset.pending.mu.Unlock()
+	for k := range rwset.pending.writes {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge adds the reads and writes of other to this rwset; writes of other overwrite writes to the same key
+func (rwset *readWriteSet) merge(other *readWriteSet) {
+	other.mu.Lock()
+	defer other.mu.Unlock()
+	rwset.mu.Lock()
+	defer rwset.mu.Unlock()
+
+	for k, r := range other.reads {
+		if _, exists := rwset.reads[k]; !exists {
+			rwset.reads[k] = r
+		}
+	}
+	for k, w := range other.writes {
+		rwset.writes[k] = w
+	}
+}
+
 func (rwset *readWriteSet) ToFPCKVSet() *protos.FPCKVSet {
 	rwset.mu.Lock()
 	defer rwset.mu.Unlock()
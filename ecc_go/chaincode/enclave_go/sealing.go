@@ -0,0 +1,244 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package enclave_go
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// SealingKeyFileEnv defines the key file used to seal the enclave state if FPC_ENCLAVE_STATE_DIR is set. The key file
+// must not be located in the enclave state directory, as the sealed state would be stored along with its key.
+const SealingKeyFileEnv = "FPC_ENCLAVE_SEALING_KEY_FILE"
+
+// SealingProvider seals the enclave state before it is written to local storage
+type SealingProvider interface {
+	Seal(plaintext []byte) (sealed []byte, err error)
+	Unseal(sealed []byte) (plaintext []byte, err error)
+}
+
+// KeyFileSealingProvider seals the enclave state with a symmetric key that is stored in a local key file.
+// Note that, unlike SGX sealing, this protects the enclave state only as long as the key file is not accessible
+// to an adversary.
+type KeyFileSealingProvider struct {
+	csp  crypto.CSP
+	path string
+}
+
+// NewKeyFileSealingProvider returns a sealing provider using the key in the file at path; if the file does not
+// exist, a new key is created with the first Seal
+func NewKeyFileSealingProvider(csp crypto.CSP, path string) *KeyFileSealingProvider {
+	return &KeyFileSealingProvider{csp: csp, path: path}
+}
+
+func (p *KeyFileSealingProvider) Seal(plaintext []byte) ([]byte, error) {
+	key, err := os.ReadFile(p.path)
+	if os.IsNotExist(err) {
+		if key, err = p.csp.NewSymmetricKey(); err != nil {
+			return nil, errors.Wrap(err, "cannot create sealing key")
+		}
+		if err := os.MkdirAll(filepath.Dir(p.path), 0700); err != nil {
+			return nil, errors.Wrap(err, "cannot create sealing key directory")
+		}
+		if err := os.WriteFile(p.path, key, 0600); err != nil {
+			return nil, errors.Wrapf(err, "cannot write %s", p.path)
+		}
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", p.path)
+	}
+
+	return p.csp.EncryptMessage(key, plaintext)
+}
+
+func (p *KeyFileSealingProvider) Unseal(sealed []byte) ([]byte, error) {
+	key, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", p.path)
+	}
+
+	return p.csp.DecryptMessage(key, sealed)
+}
+
+// SetSealing enables persisting the enclave state in the given store, sealed by the given sealing provider
+func (e *EnclaveStub) SetSealing(store chaincode.SealedStateStore, sealer SealingProvider) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sealedStateStore = store
+	e.sealer = sealer
+}
+
+// sealingFromEnv enables sealing with a KeyFileSealingProvider using the key file given by FPC_ENCLAVE_SEALING_KEY_FILE
+// if FPC_ENCLAVE_STATE_DIR is set. If the key file is not set, the sealer remains unset and CheckSealing fails.
+func (e *EnclaveStub) sealingFromEnv() {
+	store := chaincode.SealedStateStoreFromEnv()
+	if store == nil {
+		return
+	}
+
+	var sealer SealingProvider
+	if path := os.Getenv(SealingKeyFileEnv); path != "" {
+		sealer = NewKeyFileSealingProvider(e.csp, path)
+	}
+	e.SetSealing(store, sealer)
+}
+
+// CheckSealing returns an error if sealing is enabled without a sealing provider, or if the key file of a
+// KeyFileSealingProvider is located in the directory of the sealed state
+func (e *EnclaveStub) CheckSealing() error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.checkSealing()
+}
+
+func (e *EnclaveStub) checkSealing() error {
+	if e.sealedStateStore == nil {
+		return nil
+	}
+	if e.sealer == nil {
+		return fmt.Errorf("%s is set but no sealing key; set %s to a key file outside of the enclave state directory", chaincode.EnclaveStateDirEnv, SealingKeyFileEnv)
+	}
+
+	keyFile, ok := e.sealer.(*KeyFileSealingProvider)
+	if !ok {
+		return nil
+	}
+	fileStore, ok := e.sealedStateStore.(*chaincode.FileStore)
+	if !ok {
+		return nil
+	}
+	inStateDir, err := isInDir(keyFile.path, filepath.Dir(fileStore.Path))
+	if err != nil {
+		return err
+	}
+	if inStateDir {
+		return fmt.Errorf("sealing key file %s must not be located in the enclave state directory %s", keyFile.path, filepath.Dir(fileStore.Path))
+	}
+	return nil
+}
+
+// isInDir returns true if path is located in dir or one of its subdirectories
+func isInDir(path, dir string) (bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false, nil
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+// sealState persists the enclave state, if sealing is enabled
+func (e *EnclaveStub) sealState(serializedChaincodeParams, serializedHostParams, serializedAttestationParams []byte) error {
+	if e.sealedStateStore == nil {
+		return nil
+	}
+	if err := e.checkSealing(); err != nil {
+		return err
+	}
+
+	state := &protos.EnclaveState{
+		EnclaveSk:          e.identity.privateKey,
+		EnclaveVk:          e.identity.publicKey,
+		ChaincodeDk:        e.ccKeys.ccPrivateKey,
+		ChaincodeEk:        e.ccKeys.ccPublicKey,
+		StateEncryptionKey: e.ccKeys.stateKey,
+		CcParams:           serializedChaincodeParams,
+		HostParams:         serializedHostParams,
+		AttestationParams:  serializedAttestationParams,
+	}
+	serializedState, err := proto.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	sealedState, err := e.sealer.Seal(serializedState)
+	if err != nil {
+		return errors.Wrap(err, "cannot seal enclave state")
+	}
+
+	return e.sealedStateStore.Store(sealedState)
+}
+
+// Restore restores the enclave identity and chaincode keys from the sealed enclave state
+func (e *EnclaveStub) Restore() (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.sealedStateStore == nil {
+		return false, nil
+	}
+	if err := e.checkSealing(); err != nil {
+		return false, err
+	}
+
+	sealedState, err := e.sealedStateStore.Load()
+	if err != nil {
+		return false, err
+	}
+	if sealedState == nil {
+		return false, nil
+	}
+
+	serializedState, err := e.sealer.Unseal(sealedState)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot unseal enclave state")
+	}
+
+	state := &protos.EnclaveState{}
+	if err := proto.Unmarshal(serializedState, state); err != nil {
+		return false, errors.Wrap(err, "invalid enclave state")
+	}
+
+	hostParams := &protos.HostParameters{}
+	if err := proto.Unmarshal(state.GetHostParams(), hostParams); err != nil {
+		return false, errors.Wrap(err, "invalid host params in enclave state")
+	}
+
+	chaincodeParams := &protos.CCParameters{}
+	if err := proto.Unmarshal(state.GetCcParams(), chaincodeParams); err != nil {
+		return false, errors.Wrap(err, "invalid chaincode params in enclave state")
+	}
+
+	e.identity = RestoreEnclaveIdentity(e.csp, state.GetEnclaveSk(), state.GetEnclaveVk())
+	e.ccKeys = RestoreChaincodeKeys(e.csp, state.GetChaincodeDk(), state.GetChaincodeEk(), state.GetStateEncryptionKey())
+	e.hostParams = hostParams
+	e.chaincodeParams = chaincodeParams
+
+	logger.Infof("Restored enclave %s", e.identity.GetEnclaveId())
+	return true, nil
+}
+
+// Discard removes the enclave identity and chaincode keys, once all ongoing invocations have completed, and deletes the
+// sealed enclave state
+func (e *EnclaveStub) Discard() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.identity = nil
+	e.ccKeys = nil
+	e.hostParams = nil
+	e.chaincodeParams = nil
+
+	if e.sealedStateStore == nil {
+		return nil
+	}
+	return e.sealedStateStore.Delete()
+}
@@ -97,6 +97,15 @@ func (f *FpcStubInterface) GetState(key string) ([]byte, error) {
 }
 
 func (f *FpcStubInterface) GetPublicState(key string) ([]byte, error) {
+	// a key written by a preceding invocation of the same batch is read from the pending write; the read does not
+	// depend on the ledger state, thus, it is not added to the rwset
+	if w, ok := f.rwset.PendingWrite(key); ok {
+		if w.GetIsDelete() {
+			return nil, nil
+		}
+		return w.GetValue(), nil
+	}
+
 	value, err := f.stub.GetState(key)
 	if err != nil {
 		return nil, err
@@ -116,18 +125,14 @@ func (f *FpcStubInterface) PutState(key string, value []byte) error {
 }
 
 func (f *FpcStubInterface) PutPublicState(key string, value []byte) error {
+	// the write is applied to the Fabric stub once the invocation completes, see EnclaveStub.ChaincodeInvoke
 	f.rwset.AddWrite(key, value)
-
-	// note that since we are not using the fabric proposal response  we can skip the putState call
-	//return f.stub.PutState(key, value)
 	return nil
 }
 
 func (f *FpcStubInterface) DelState(key string) error {
+	// the delete is applied to the Fabric stub once the invocation completes, see EnclaveStub.ChaincodeInvoke
 	f.rwset.AddDelete(key)
-
-	// note that since we are not using the fabric proposal response  we can skip the delState call
-	//return f.stub.DelState(key)
 	return nil
 }
 
@@ -148,6 +153,10 @@ func (f *FpcStubInterface) GetStateByRangeWithPagination(startKey string, endKey
 }
 
 func (f *FpcStubInterface) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	if err := f.checkPendingWrites(objectType, keys); err != nil {
+		return nil, err
+	}
+
 	iterator, err := f.stub.GetStateByPartialCompositeKey(objectType, keys)
 	if err != nil {
 		return nil, err
@@ -157,6 +166,10 @@ func (f *FpcStubInterface) GetStateByPartialCompositeKey(objectType string, keys
 }
 
 func (f *FpcStubInterface) GetPublicStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	if err := f.checkPendingWrites(objectType, keys); err != nil {
+		return nil, err
+	}
+
 	iterator, err := f.stub.GetStateByPartialCompositeKey(objectType, keys)
 	if err != nil {
 		return nil, err
@@ -166,6 +179,19 @@ func (f *FpcStubInterface) GetPublicStateByPartialCompositeKey(objectType string
 	return newFpcIterator(iterator, f.rwset.AddRead, nil), nil
 }
 
+// checkPendingWrites returns an error if a preceding invocation of the same batch has written a key in the range of
+// the partial composite key query, as the query iterates over the ledger state only and would miss these writes
+func (f *FpcStubInterface) checkPendingWrites(objectType string, keys []string) error {
+	prefix, err := f.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return err
+	}
+	if f.rwset.HasPendingWrites(prefix) {
+		return fmt.Errorf("query over keys written by a preceding invocation of the batch is not supported")
+	}
+	return nil
+}
+
 func (f *FpcStubInterface) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
 	panic("not implemented") // TODO: Implement
 }
@@ -8,11 +8,13 @@ package enclave_go
 
 import (
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 )
 
 func NewSkvsStub(cc shim.Chaincode) *EnclaveStub {
 	enclaveStub := NewEnclaveStub(cc)
+	enclaveStub.flavor = chaincode.FlavorSKVS
 	enclaveStub.stubProvider = func(stub shim.ChaincodeStubInterface, input *pb.ChaincodeInput, rwset *readWriteSet, sep StateEncryptionFunctions) shim.ChaincodeStubInterface {
 		return NewSkvsStubInterface(stub, input, rwset, sep)
 	}
@@ -7,26 +7,52 @@ SPDX-License-Identifier: Apache-2.0
 package chaincode
 
 import (
+	"fmt"
+
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode"
 	"github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/ercc"
 	"github.com/hyperledger/fabric-private-chaincode/ecc_go/chaincode/enclave_go"
 	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 )
 
+var logger = flogging.MustGetLogger("ecc_go")
+
 type BuildOption func(*chaincode.EnclaveChaincode, shim.Chaincode)
 
 // NewPrivateChaincode creates a new chaincode! This is for go support only!!!
 func NewPrivateChaincode(cc shim.Chaincode, options ...BuildOption) *chaincode.EnclaveChaincode {
+	// __endorse checks the MSP ID of this peer against the host of the enclave, thus, it must be known
+	if _, err := chaincode.GetLocalMSPID(); err != nil {
+		panic(err.Error())
+	}
+
 	ecc := &chaincode.EnclaveChaincode{
 		Enclave:   enclave_go.NewEnclaveStub(cc),
 		Validator: endorsement.NewValidator(),
 		Extractor: &chaincode.ExtractorImpl{},
 		Ercc:      &ercc.StubImpl{},
+
+		AllowedHostMSPIDs: chaincode.GetAllowedHostMSPIDsFromEnv(),
 	}
 	for _, o := range options {
 		o(ecc, cc)
 	}
+
+	// refuse to start if the sealed enclave state would not be protected
+	if enclaveStub, ok := ecc.Enclave.(*enclave_go.EnclaveStub); ok {
+		if err := enclaveStub.CheckSealing(); err != nil {
+			panic(fmt.Sprintf("invalid enclave sealing configuration: %s", err))
+		}
+	}
+
+	// restore the enclave after a chaincode restart, if its state was sealed (see WithSealing)
+	if err := ecc.RestoreEnclave(); err != nil {
+		logger.Errorf("cannot restore enclave: %s", err)
+	}
+
 	return ecc
 }
 
@@ -35,3 +61,32 @@ func WithSKVS() BuildOption {
 		ecc.Enclave = enclave_go.NewSkvsStub(cc)
 	}
 }
+
+// WithSealing persists the enclave state in the given store, sealed by the given sealing provider, so that the
+// enclave can be restored after a chaincode restart. By default, if FPC_ENCLAVE_STATE_DIR is set, the enclave state
+// is stored in this directory and sealed with the key file given by FPC_ENCLAVE_SEALING_KEY_FILE (see
+// enclave_go.KeyFileSealingProvider), which must be located outside of this directory.
+// Note that this option must be passed after WithSKVS.
+func WithSealing(store chaincode.SealedStateStore, sealer enclave_go.SealingProvider) BuildOption {
+	return func(ecc *chaincode.EnclaveChaincode, cc shim.Chaincode) {
+		if enclaveStub, ok := ecc.Enclave.(*enclave_go.EnclaveStub); ok {
+			enclaveStub.SetSealing(store, sealer)
+		}
+	}
+}
+
+// WithMetrics records the enclave chaincode metrics with the given provider, e.g., a Prometheus provider returned by
+// metrics.ProviderFromEnv in internal/metrics
+func WithMetrics(p metrics.Provider) BuildOption {
+	return func(ecc *chaincode.EnclaveChaincode, cc shim.Chaincode) {
+		ecc.Metrics = chaincode.NewMetrics(p)
+	}
+}
+
+// WithAudit writes an audit record for every __endorse and __initEnclave to the given sink, e.g., the sink returned
+// by chaincode.AuditSinkFromEnv
+func WithAudit(sink chaincode.AuditSink) BuildOption {
+	return func(ecc *chaincode.EnclaveChaincode, cc shim.Chaincode) {
+		ecc.Audit = sink
+	}
+}
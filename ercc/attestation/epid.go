@@ -0,0 +1,19 @@
+//go:build !WITH_PDO_CRYPTO
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
+)
+
+// without PDO crypto, EPID evidence is verified by the native Go verifier
+func init() {
+	registry.add(epid.NewEpidLinkableVerifier())
+	registry.add(epid.NewEpidUnlinkableVerifier())
+}
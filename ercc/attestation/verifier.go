@@ -7,11 +7,25 @@ SPDX-License-Identifier: Apache-2.0
 package attestation
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/pkg/errors"
 )
 
+const (
+	// ProductionModeEnv enables the production mode, in which simulated credentials are rejected
+	ProductionModeEnv = "FPC_ERCC_PRODUCTION"
+	// AttestationTypesEnv defines the comma-separated attestation types accepted by the enclave registry,
+	// e.g., "epid-linkable,dcap"; if not set, all available types are accepted (except simulated in production mode)
+	AttestationTypesEnv = "FPC_ERCC_ATTESTATION_TYPES"
+)
+
 var registry verifierRegistry
 
 type verifierRegistry struct {
@@ -29,6 +43,125 @@ func (vr *verifierRegistry) add(verifier *types.Verifier) {
 	vr.verifiers = append(vr.verifiers, verifier)
 }
 
+func (vr *verifierRegistry) get(attestationType string) *types.Verifier {
+	for _, v := range vr.verifiers {
+		if v.Type == attestationType {
+			return v
+		}
+	}
+	return nil
+}
+
 func GetAvailableVerifier() *attestation.CredentialVerifier {
 	return attestation.NewCredentialVerifier(registry.verifiers...)
 }
+
+// AvailableTypes returns the attestation types for which a verifier is built into the enclave registry
+func AvailableTypes() []string {
+	var available []string
+	for _, v := range registry.verifiers {
+		available = append(available, v.Type)
+	}
+	return available
+}
+
+// Config selects the attestation types accepted by the enclave registry
+type Config struct {
+	// Production rejects simulated credentials
+	Production bool
+	// Types are the accepted attestation types; if empty, all available types are accepted, except simulated in
+	// production mode
+	Types []string
+}
+
+// ConfigFromEnv returns the configuration defined by FPC_ERCC_PRODUCTION and FPC_ERCC_ATTESTATION_TYPES
+func ConfigFromEnv() (*Config, error) {
+	config := &Config{}
+
+	if production := os.Getenv(ProductionModeEnv); len(production) > 0 {
+		var err error
+		config.Production, err = strconv.ParseBool(production)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", ProductionModeEnv)
+		}
+	}
+
+	for _, t := range strings.Split(os.Getenv(AttestationTypesEnv), ",") {
+		if t = strings.TrimSpace(t); len(t) > 0 {
+			config.Types = append(config.Types, t)
+		}
+	}
+
+	return config, nil
+}
+
+// EnabledTypes returns the attestation types accepted with this configuration, or an error if the configuration
+// enables unavailable types or, in production mode, simulated attestation
+func (c *Config) EnabledTypes() ([]string, error) {
+	if len(c.Types) == 0 {
+		var enabled []string
+		for _, t := range AvailableTypes() {
+			if c.Production && t == simulation.SimulationType {
+				continue
+			}
+			enabled = append(enabled, t)
+		}
+		if len(enabled) == 0 {
+			return nil, fmt.Errorf("no attestation type available")
+		}
+		return enabled, nil
+	}
+
+	for _, t := range c.Types {
+		if c.Production && t == simulation.SimulationType {
+			return nil, fmt.Errorf("attestation type '%s' cannot be enabled in production mode", t)
+		}
+		if registry.get(t) == nil {
+			return nil, fmt.Errorf("attestation type '%s' not available, available types: %s", t, strings.Join(AvailableTypes(), ", "))
+		}
+	}
+	return c.Types, nil
+}
+
+// GetVerifier returns a verifier that accepts only credentials of the enabled attestation types satisfying the given
+// verification policy. Credentials of the other available types are rejected with an error explaining why.
+func GetVerifier(config *Config, policy *attestation.VerificationPolicy) (*attestation.CredentialVerifier, error) {
+	enabled, err := config.EnabledTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var verifiers []*types.Verifier
+	for _, v := range registry.verifiers {
+		if contains(enabled, v.Type) {
+			verifiers = append(verifiers, v)
+			continue
+		}
+
+		reason := fmt.Sprintf("attestation type '%s' is not enabled, enabled types: %s", v.Type, strings.Join(enabled, ", "))
+		if config.Production && v.Type == simulation.SimulationType {
+			reason = "simulated credentials are rejected in production mode"
+		}
+		verifiers = append(verifiers, newDisabledVerifier(v.Type, reason))
+	}
+
+	return attestation.NewCredentialVerifierWithPolicy(policy, verifiers...), nil
+}
+
+func newDisabledVerifier(attestationType, reason string) *types.Verifier {
+	return &types.Verifier{
+		Type: attestationType,
+		Verify: func(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (*types.Result, error) {
+			return nil, errors.New(reason)
+		},
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
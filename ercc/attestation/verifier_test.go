@@ -0,0 +1,84 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(ProductionModeEnv, "")
+	t.Setenv(AttestationTypesEnv, "")
+	config, err := ConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{}, config)
+
+	t.Setenv(ProductionModeEnv, "true")
+	t.Setenv(AttestationTypesEnv, " dcap, epid-linkable ,")
+	config, err = ConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{Production: true, Types: []string{"dcap", "epid-linkable"}}, config)
+
+	t.Setenv(ProductionModeEnv, "yes please")
+	_, err = ConfigFromEnv()
+	assert.ErrorContains(t, err, "invalid "+ProductionModeEnv)
+}
+
+func TestEnabledTypes(t *testing.T) {
+	available := AvailableTypes()
+	assert.Contains(t, available, simulation.SimulationType)
+	assert.Contains(t, available, "dcap")
+
+	enabled, err := (&Config{}).EnabledTypes()
+	assert.NoError(t, err)
+	assert.Equal(t, available, enabled)
+
+	enabled, err = (&Config{Production: true}).EnabledTypes()
+	assert.NoError(t, err)
+	assert.NotContains(t, enabled, simulation.SimulationType)
+	assert.Len(t, enabled, len(available)-1)
+
+	enabled, err = (&Config{Types: []string{"dcap"}}).EnabledTypes()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dcap"}, enabled)
+
+	_, err = (&Config{Production: true, Types: []string{"dcap", simulation.SimulationType}}).EnabledTypes()
+	assert.EqualError(t, err, "attestation type 'simulated' cannot be enabled in production mode")
+
+	_, err = (&Config{Types: []string{"tdx"}}).EnabledTypes()
+	assert.ErrorContains(t, err, "attestation type 'tdx' not available")
+}
+
+func TestGetVerifier(t *testing.T) {
+	simulated := &protos.Credentials{
+		SerializedAttestedData: &anypb.Any{Value: []byte("statement")},
+		Evidence:               []byte(`{"attestation_type": "simulated", "evidence": "MA=="}`),
+	}
+
+	v, err := GetVerifier(&Config{}, attestation.DefaultVerificationPolicy())
+	require.NoError(t, err)
+	assert.NoError(t, v.VerifyCredentials(simulated, "mrenclave", time.Now()))
+
+	v, err = GetVerifier(&Config{Production: true}, attestation.DefaultVerificationPolicy())
+	require.NoError(t, err)
+	assert.EqualError(t, v.VerifyCredentials(simulated, "mrenclave", time.Now()), "simulated credentials are rejected in production mode")
+
+	v, err = GetVerifier(&Config{Types: []string{"dcap"}}, attestation.DefaultVerificationPolicy())
+	require.NoError(t, err)
+	assert.EqualError(t, v.VerifyCredentials(simulated, "mrenclave", time.Now()), "attestation type 'simulated' is not enabled, enabled types: dcap")
+
+	_, err = GetVerifier(&Config{Production: true, Types: []string{simulation.SimulationType}}, attestation.DefaultVerificationPolicy())
+	assert.Error(t, err)
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/hyperledger/fabric-private-chaincode/ercc/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/ercc/registry"
+	fpcattestation "github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/metrics"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric/common/flogging"
 )
@@ -25,8 +27,35 @@ func main() {
 	// For more fine grained logging we could also use different log level for loggers.
 	// For example: FABRIC_LOGGING_SPEC=ecc=DEBUG:ecc_enclave=ERROR
 
+	// metrics are served if FPC_METRICS_ADDRESS is set
+	metricsProvider, err := metrics.ProviderFromEnv()
+	if err != nil {
+		logger.Panicf("cannot start metrics endpoint: %s", err)
+	}
+
+	// credentials are checked against the verification policy in FPC_ATTESTATION_POLICY, if set
+	policy, err := fpcattestation.VerificationPolicyFromEnv()
+	if err != nil {
+		logger.Panicf("cannot load attestation verification policy: %s", err)
+	}
+
+	// the accepted attestation types are selected by FPC_ERCC_PRODUCTION and FPC_ERCC_ATTESTATION_TYPES
+	config, err := attestation.ConfigFromEnv()
+	if err != nil {
+		logger.Panicf("invalid attestation configuration: %s", err)
+	}
+	verifier, err := attestation.GetVerifier(config, policy)
+	if err != nil {
+		logger.Panicf("invalid attestation configuration: %s", err)
+	}
+	attestationTypes, _ := config.EnabledTypes()
+	logger.Infof("accepting attestation types %v (production mode: %t)", attestationTypes, config.Production)
+
 	c := &registry.Contract{}
-	c.Verifier = attestation.GetAvailableVerifier()
+	c.Metrics = registry.NewMetrics(metricsProvider)
+	c.Verifier = verifier
+	c.Production = config.Production
+	c.AttestationTypes = attestationTypes
 	c.IEvaluator = &utils.IdentityEvaluator{}
 	c.BeforeTransaction = registry.MyBeforeTransaction
 
@@ -40,13 +69,19 @@ func main() {
 
 	if len(ccid) > 0 && len(addr) > 0 {
 		// start chaincode as a service
+		tlsProps, err := utils.GetChaincodeServerTLSProperties()
+		if err != nil {
+			logger.Panicf("invalid TLS configuration for enclave registry chaincode: %s", err)
+		}
+		if tlsProps.Disabled {
+			logger.Warningf("TLS is disabled, set %s, %s and %s to enable mutual TLS", utils.ChaincodeTLSKeyEnv, utils.ChaincodeTLSCertEnv, utils.ChaincodeClientCACertEnv)
+		}
+
 		server := &shim.ChaincodeServer{
-			CCID:    ccid,
-			Address: addr,
-			CC:      ercc,
-			TLSProps: shim.TLSProperties{
-				Disabled: true,
-			},
+			CCID:     ccid,
+			Address:  addr,
+			CC:       ercc,
+			TLSProps: tlsProps,
 		}
 
 		logger.Infof("starting enclave registry (%s)", ccid)
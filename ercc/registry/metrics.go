@@ -0,0 +1,44 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package registry
+
+import (
+	"github.com/hyperledger/fabric/common/metrics"
+)
+
+var enclaveRegistrations = metrics.CounterOpts{
+	Namespace:    "fpc",
+	Subsystem:    "ercc",
+	Name:         "enclave_registrations",
+	Help:         "The number of RegisterEnclave invocations, by status. Note that invocations are counted when simulated, not when committed.",
+	LabelNames:   []string{"status"},
+	StatsdFormat: "%{#fqname}.%{status}",
+}
+
+// Metrics are the metrics of the enclave registry
+type Metrics struct {
+	EnclaveRegistrations metrics.Counter
+}
+
+// NewMetrics returns the enclave registry metrics created with the given provider
+func NewMetrics(p metrics.Provider) *Metrics {
+	return &Metrics{
+		EnclaveRegistrations: p.NewCounter(enclaveRegistrations),
+	}
+}
+
+// observeRegistration records a successful or (if err is not nil) failed enclave registration
+func (m *Metrics) observeRegistration(err error) {
+	if m == nil {
+		return
+	}
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+	m.EnclaveRegistrations.With("status", status).Add(1)
+}
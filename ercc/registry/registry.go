@@ -28,6 +28,11 @@ type Contract struct {
 
 	Verifier   attestation.Verifier
 	IEvaluator utils.IdentityEvaluatorInterface
+	// Metrics are the metrics of the enclave registry; if nil, metrics are disabled
+	Metrics *Metrics
+	// Production and AttestationTypes describe the credentials accepted by Verifier, as reported by QueryStatus
+	Production       bool
+	AttestationTypes []string
 }
 
 func MyBeforeTransaction(ctx contractapi.TransactionContextInterface) error {
@@ -39,8 +44,9 @@ func MyBeforeTransaction(ctx contractapi.TransactionContextInterface) error {
 // QueryListEnclaveCredentials returns a set of credentials registered for a given chaincode id
 // Note: to get the endpoints of FPC endorsing peers do the following:
 // - discover all endorsing peers (and their endpoints) for the FPC chaincode using "normal" lifecycle
-// - query `getEnclaveId` at all the peers discovered
+// - query `__getEnclaveId` at all the peers discovered
 // - query `queryListEnclaveCredentials` with all received enclave_ids
+// (see also `LifecycleQueryEnclaves` in the FPC client SDK)
 // this gives you the endpoints and credentials including enclave_vk, and chaincode_ek
 //
 // Note that this implementation returns a set of (base64-encoded) protobuf-serialized `Credential` objects in order to send it to the receiver.
@@ -243,6 +249,12 @@ func (rs *Contract) QueryChaincodeEncryptionKey(ctx contractapi.TransactionConte
 
 // RegisterEnclave register a new FPC chaincode enclave instance
 func (rs *Contract) RegisterEnclave(ctx contractapi.TransactionContextInterface, credentialsBase64 string) error {
+	err := rs.registerEnclave(ctx, credentialsBase64)
+	rs.Metrics.observeRegistration(err)
+	return err
+}
+
+func (rs *Contract) registerEnclave(ctx contractapi.TransactionContextInterface, credentialsBase64 string) error {
 	logger.Debugf("RegisterEnclave")
 
 	credentials, err := utils.UnmarshalCredentials(credentialsBase64)
@@ -330,8 +342,14 @@ func checkAttestedData(ctx contractapi.TransactionContextInterface, v attestatio
 		return fmt.Errorf("sequence does not match chaincode definition")
 	}
 
+	// the verification policy is evaluated at the transaction timestamp, so all endorsers reach the same decision
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("cannot get transaction timestamp: %s", err)
+	}
+
 	// check that attestation evidence contains expectedMrEnclave as defined in chaincode definition
-	if err := v.VerifyCredentials(credentials, expectedMrEnclave); err != nil {
+	if err := v.VerifyCredentials(credentials, expectedMrEnclave, timestamp.AsTime()); err != nil {
 		return fmt.Errorf("evidence verification failed: %s", err)
 	}
 
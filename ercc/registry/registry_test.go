@@ -11,6 +11,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -21,9 +22,11 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+	"github.com/hyperledger/fabric/common/metrics/metricsfakes"
 	"github.com/hyperledger/fabric/protoutil"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -81,9 +84,13 @@ func TestRegisterEnclave(t *testing.T) {
 
 	id := &fakes.IdentityEvaluator{}
 
+	registrations := &metricsfakes.Counter{}
+	registrations.WithReturns(registrations)
+
 	ercc := registry.Contract{}
 	ercc.Verifier = verifier
 	ercc.IEvaluator = id
+	ercc.Metrics = &registry.Metrics{EnclaveRegistrations: registrations}
 
 	err := ercc.RegisterEnclave(transactionContext, "")
 	require.EqualError(t, err, "invalid credential bytes: credential input empty")
@@ -191,7 +198,7 @@ func TestRegisterEnclave(t *testing.T) {
 			Version:  mrenclave,
 			Sequence: 1,
 		})))
-	verifier.VerifyCredentialsReturns(fmt.Errorf("evidence invalid"))
+	chaincodeStub.GetTxTimestampReturns(nil, fmt.Errorf("no timestamp"))
 
 	serializedAttestedData, _ = anypb.New(
 		&protos.AttestedData{
@@ -208,8 +215,19 @@ func TestRegisterEnclave(t *testing.T) {
 		SerializedAttestedData: serializedAttestedData,
 	})
 	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
+	require.EqualError(t, err, "cannot get transaction timestamp: no timestamp")
+
+	txTimestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(txTimestamp), nil)
+	verifier.VerifyCredentialsReturns(fmt.Errorf("evidence invalid"))
+	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
 	require.EqualError(t, err, "evidence verification failed: evidence invalid")
 
+	// credentials are verified at the transaction timestamp
+	_, expectedMrenclave, verificationTime := verifier.VerifyCredentialsArgsForCall(verifier.VerifyCredentialsCallCount() - 1)
+	require.Equal(t, mrenclave, expectedMrenclave)
+	require.True(t, txTimestamp.Equal(verificationTime))
+
 	verifier.VerifyCredentialsReturns(nil)
 	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
 	require.EqualError(t, err, "host params are empty")
@@ -258,6 +276,12 @@ func TestRegisterEnclave(t *testing.T) {
 	chaincodeStub.PutStateReturns(nil)
 	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
 	require.NoError(t, err)
+
+	// every registration is counted
+	n := registrations.WithCallCount()
+	require.Equal(t, n, registrations.AddCallCount())
+	require.Equal(t, []string{"status", "failure"}, registrations.WithArgsForCall(0))
+	require.Equal(t, []string{"status", "success"}, registrations.WithArgsForCall(n-1))
 }
 
 func TestQueryListEnclaveCredentials(t *testing.T) {
@@ -347,3 +371,22 @@ func TestQueryEnclaveCredentials(t *testing.T) {
 	require.Empty(t, resp)
 	require.NoError(t, err)
 }
+
+func TestQueryStatus(t *testing.T) {
+	transactionContext := &fakes.TransactionContext{}
+
+	ercc := &registry.Contract{}
+	status, err := ercc.QueryStatus(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, &registry.Status{AttestationTypes: []string{}}, status)
+
+	ercc.Production = true
+	ercc.AttestationTypes = []string{"epid-linkable", "dcap"}
+	status, err = ercc.QueryStatus(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, &registry.Status{Production: true, AttestationTypes: []string{"epid-linkable", "dcap"}}, status)
+
+	// the contract, including its status, must be serializable by the contract API
+	_, err = contractapi.NewChaincode(ercc)
+	require.NoError(t, err)
+}
@@ -0,0 +1,32 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package registry
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Status describes the configuration of the enclave registry hosted by this peer, as returned by QueryStatus
+type Status struct {
+	// Production reports whether the enclave registry runs in production mode, i.e., rejects simulated credentials
+	Production bool `json:"production"`
+	// AttestationTypes are the attestation types of the credentials accepted by RegisterEnclave
+	AttestationTypes []string `json:"attestation_types"`
+}
+
+// QueryStatus returns the configuration of the enclave registry hosted by the queried peer. Note that each peer
+// reports its own configuration; the configurations of the peers of a channel may differ.
+func (rs *Contract) QueryStatus(ctx contractapi.TransactionContextInterface) (*Status, error) {
+	attestationTypes := rs.AttestationTypes
+	if attestationTypes == nil {
+		attestationTypes = []string{}
+	}
+	return &Status{
+		Production:       rs.Production,
+		AttestationTypes: attestationTypes,
+	}, nil
+}
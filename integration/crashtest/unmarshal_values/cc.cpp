@@ -39,7 +39,7 @@ int invoke(
     if (max_response_len < neededSize)
     {
         LOG_DEBUG("[+] Response buffer too small");
-        *actual_response_len = 0;
+        *actual_response_len = neededSize;
         return -1;
     }
 
@@ -60,6 +60,13 @@ echo_test() {
         try_out_r ${PEER_CMD} chaincode invoke -o ${ORDERER_ADDR} -C ${CHAN_ID} -n ${CC_ID} -c '{"Args": ["echo-'$i'"]}' --waitForEvent
         check_result "echo-$i"
      done
+
+    say "do echo exceeding the response buffer"
+    # the chaincode response does not fit into the default response buffer (see FPC_ENCLAVE_RESPONSE_BUFFER_SIZE),
+    # thus, the enclave reports the required size and the invocation is retried with a larger buffer
+    large_echo=$(head -c 100000 /dev/zero | tr '\0' 'x')
+    try_out_r ${PEER_CMD} chaincode invoke -o ${ORDERER_ADDR} -C ${CHAN_ID} -n ${CC_ID} -c '{"Args": ["'${large_echo}'"]}' --waitForEvent
+    check_result "${large_echo}"
 }
 
 # 1. prepare
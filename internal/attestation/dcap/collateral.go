@@ -0,0 +1,233 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Collateral is the data, issued by the Intel Provisioning Certification Service (PCS), that is needed to verify a
+// quote. It is bundled with the quote in the evidence so that verification does not need any online service.
+type Collateral struct {
+	// TcbInfo is the TCB info of the platform (FMSPC) as returned by the PCS, i.e., {"tcbInfo":{...},"signature":"..."}
+	TcbInfo string `json:"tcb_info"`
+	// QeIdentity is the identity of the quoting enclave as returned by the PCS, i.e., {"enclaveIdentity":{...},"signature":"..."}
+	QeIdentity string `json:"qe_identity"`
+	// TcbSigningChain is the PEM-encoded chain of the TCB signing certificate, which signs TcbInfo and QeIdentity
+	TcbSigningChain string `json:"tcb_signing_chain"`
+	// PckCrl is the PEM-encoded CRL of the PCK certificate issuer
+	PckCrl string `json:"pck_crl"`
+	// RootCaCrl is the PEM-encoded CRL of the Intel SGX root CA
+	RootCaCrl string `json:"root_ca_crl"`
+}
+
+// TcbInfo lists the TCB levels of a platform (TCB info version 3)
+type TcbInfo struct {
+	Id                      string     `json:"id"`
+	Version                 int        `json:"version"`
+	IssueDate               time.Time  `json:"issueDate"`
+	NextUpdate              time.Time  `json:"nextUpdate"`
+	Fmspc                   string     `json:"fmspc"`
+	PceId                   string     `json:"pceId"`
+	TcbType                 int        `json:"tcbType"`
+	TcbEvaluationDataNumber int        `json:"tcbEvaluationDataNumber"`
+	TcbLevels               []TcbLevel `json:"tcbLevels"`
+}
+
+type TcbComponent struct {
+	Svn int `json:"svn"`
+}
+
+type TcbLevel struct {
+	Tcb struct {
+		SgxTcbComponents []TcbComponent `json:"sgxtcbcomponents"`
+		PceSvn           int            `json:"pcesvn"`
+	} `json:"tcb"`
+	TcbDate     time.Time `json:"tcbDate"`
+	TcbStatus   string    `json:"tcbStatus"`
+	AdvisoryIDs []string  `json:"advisoryIDs,omitempty"`
+}
+
+// EnclaveIdentity is the identity of the quoting enclave (enclave identity version 2)
+type EnclaveIdentity struct {
+	Id                      string            `json:"id"`
+	Version                 int               `json:"version"`
+	IssueDate               time.Time         `json:"issueDate"`
+	NextUpdate              time.Time         `json:"nextUpdate"`
+	TcbEvaluationDataNumber int               `json:"tcbEvaluationDataNumber"`
+	MiscSelect              string            `json:"miscselect"`
+	MiscSelectMask          string            `json:"miscselectMask"`
+	Attributes              string            `json:"attributes"`
+	AttributesMask          string            `json:"attributesMask"`
+	MrSigner                string            `json:"mrsigner"`
+	IsvProdId               int               `json:"isvprodid"`
+	TcbLevels               []EnclaveTcbLevel `json:"tcbLevels"`
+}
+
+type EnclaveTcbLevel struct {
+	Tcb struct {
+		IsvSvn int `json:"isvsvn"`
+	} `json:"tcb"`
+	TcbDate     time.Time `json:"tcbDate"`
+	TcbStatus   string    `json:"tcbStatus"`
+	AdvisoryIDs []string  `json:"advisoryIDs,omitempty"`
+}
+
+// signedTcbInfo and signedQeIdentity keep the raw signed JSON, as the signature covers the exact bytes
+type signedTcbInfo struct {
+	TcbInfo   json.RawMessage `json:"tcbInfo"`
+	Signature string          `json:"signature"`
+}
+
+type signedQeIdentity struct {
+	EnclaveIdentity json.RawMessage `json:"enclaveIdentity"`
+	Signature       string          `json:"signature"`
+}
+
+// parseTcbInfo verifies the signature of the TCB info with the TCB signing certificate and returns the TCB info
+func parseTcbInfo(raw string, signer *x509.Certificate) (*TcbInfo, error) {
+	signed := &signedTcbInfo{}
+	if err := json.Unmarshal([]byte(raw), signed); err != nil {
+		return nil, errors.Wrap(err, "invalid TCB info")
+	}
+	if err := verifyCollateralSignature(signer, signed.TcbInfo, signed.Signature); err != nil {
+		return nil, errors.Wrap(err, "invalid TCB info signature")
+	}
+
+	tcbInfo := &TcbInfo{}
+	if err := json.Unmarshal(signed.TcbInfo, tcbInfo); err != nil {
+		return nil, errors.Wrap(err, "invalid TCB info")
+	}
+	if tcbInfo.Version != 3 {
+		return nil, fmt.Errorf("unsupported TCB info version %d", tcbInfo.Version)
+	}
+	return tcbInfo, nil
+}
+
+// parseQeIdentity verifies the signature of the QE identity with the TCB signing certificate and returns the QE identity
+func parseQeIdentity(raw string, signer *x509.Certificate) (*EnclaveIdentity, error) {
+	signed := &signedQeIdentity{}
+	if err := json.Unmarshal([]byte(raw), signed); err != nil {
+		return nil, errors.Wrap(err, "invalid QE identity")
+	}
+	if err := verifyCollateralSignature(signer, signed.EnclaveIdentity, signed.Signature); err != nil {
+		return nil, errors.Wrap(err, "invalid QE identity signature")
+	}
+
+	identity := &EnclaveIdentity{}
+	if err := json.Unmarshal(signed.EnclaveIdentity, identity); err != nil {
+		return nil, errors.Wrap(err, "invalid QE identity")
+	}
+	if identity.Version != 2 {
+		return nil, fmt.Errorf("unsupported QE identity version %d", identity.Version)
+	}
+	return identity, nil
+}
+
+// verifyCollateralSignature checks a hex-encoded raw (r || s) ECDSA signature of data by the certificate
+func verifyCollateralSignature(signer *x509.Certificate, data []byte, signatureHex string) error {
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return errors.Wrap(err, "invalid signature encoding")
+	}
+	pub, ok := signer.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported signing key")
+	}
+	return verifyECDSA(pub, data, signature)
+}
+
+// verifyECDSA checks a raw (r || s) ECDSA-SHA256 signature, as used in quotes and collateral
+func verifyECDSA(pub *ecdsa.PublicKey, data, signature []byte) error {
+	if len(signature) != ecdsaSignatureSize {
+		return fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	digest := sha256.Sum256(data)
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(pub, digest[:], r, s) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// parseCrl parses a PEM- or DER-encoded CRL
+func parseCrl(data []byte) (*x509.RevocationList, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return x509.ParseRevocationList(data)
+}
+
+// Collateral files in the collateral directory, as obtained from the PCS
+// (see https://api.portal.trustedservices.intel.com/content/documentation.html)
+const (
+	// tcbInfoFilePattern is the TCB info of a FMSPC (GET /sgx/certification/v4/tcb?fmspc=<FMSPC>)
+	tcbInfoFilePattern = "tcb_info_%s.json"
+	// qeIdentityFile is the QE identity (GET /sgx/certification/v4/qe/identity)
+	qeIdentityFile = "qe_identity.json"
+	// tcbSigningChainFile is the TCB signing chain (the TCB-Info-Issuer-Chain response header, URL-decoded)
+	tcbSigningChainFile = "tcb_signing_chain.pem"
+	// pckCrlFilePattern is the CRL (PEM or DER) of the processor or platform CA (GET /sgx/certification/v4/pckcrl?ca=<ca>)
+	pckCrlFilePattern = "pck_crl_%s.crl"
+	// rootCaCrlFile is the CRL (PEM or DER) of the root CA (https://certificates.trustedservices.intel.com/IntelSGXRootCA.der)
+	rootCaCrlFile = "root_ca_crl.crl"
+)
+
+// loadCollateral reads the collateral for a quote with the given PCK certificate chain from dir
+func loadCollateral(dir string, pckCertChain []*x509.Certificate) (*Collateral, error) {
+	pck, err := ParsePckExtensions(pckCertChain[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid PCK certificate")
+	}
+
+	// the PCK certificate is issued either by the "Intel SGX PCK Processor CA" or the "Intel SGX PCK Platform CA"
+	ca := "processor"
+	if strings.Contains(strings.ToLower(pckCertChain[0].Issuer.CommonName), "platform") {
+		ca = "platform"
+	}
+
+	collateral := &Collateral{}
+	files := []struct {
+		name string
+		dst  *string
+	}{
+		{fmt.Sprintf(tcbInfoFilePattern, strings.ToLower(pck.Fmspc)), &collateral.TcbInfo},
+		{qeIdentityFile, &collateral.QeIdentity},
+		{tcbSigningChainFile, &collateral.TcbSigningChain},
+		{fmt.Sprintf(pckCrlFilePattern, ca), &collateral.PckCrl},
+		{rootCaCrlFile, &collateral.RootCaCrl},
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, f.name))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read collateral")
+		}
+		*f.dst = string(data)
+	}
+
+	// the evidence is JSON, so DER-encoded CRLs are converted to PEM
+	for _, crl := range []*string{&collateral.PckCrl, &collateral.RootCaCrl} {
+		if block, _ := pem.Decode([]byte(*crl)); block == nil {
+			*crl = string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: []byte(*crl)}))
+		}
+	}
+	return collateral, nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+const DcapType = "dcap"
+
+// Evidence is the evidence of a DCAP attestation; that is, the quote and the collateral to verify it
+type Evidence struct {
+	Quote      []byte      `json:"quote"`
+	Collateral *Collateral `json:"collateral"`
+}
+
+// NewDcapConverter creates a new attestation converter for Intel SGX DCAP (ECDSA) attestation.
+// The attestation is a base64-encoded quote; the converter bundles it with the collateral from the collateral
+// directory ($DCAP_COLLATERAL_PATH), so that it can be verified offline.
+func NewDcapConverter() *types.Converter {
+	return &types.Converter{
+		Type: DcapType,
+		Converter: func(attestationBytes []byte) (evidenceBytes []byte, err error) {
+			path, err := collateralPath()
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot find collateral")
+			}
+			return convert(attestationBytes, path)
+		},
+	}
+}
+
+func convert(attestationBytes []byte, collateralPath string) ([]byte, error) {
+	quote, err := base64.StdEncoding.DecodeString(string(attestationBytes))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode quote")
+	}
+
+	q, err := ParseQuote(quote)
+	if err != nil {
+		return nil, err
+	}
+
+	collateral, err := loadCollateral(collateralPath, q.PckCertChain)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot convert dcap attestation")
+	}
+
+	return json.Marshal(&Evidence{Quote: quote, Collateral: collateral})
+}
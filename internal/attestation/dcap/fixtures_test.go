@@ -0,0 +1,303 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// the fixtures mimic the Intel PKI and collateral with test keys; they are valid at testNow
+
+var (
+	testNow        = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	testFmspc      = []byte{0x00, 0x90, 0x6e, 0xa1, 0x00, 0x00}
+	testPceId      = []byte{0x00, 0x00}
+	testQeMrSigner = [32]byte{0x8c, 0x4f, 0x57, 0x75}
+)
+
+type testKey struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+type testPKI struct {
+	root       testKey
+	pckCA      testKey
+	pck        testKey
+	tcbSigning testKey
+	// serials revoked by the root CA and the PCK CA
+	revokedByRoot  []*big.Int
+	revokedByPckCA []*big.Int
+}
+
+type testPlatform struct {
+	tcbComponents [tcbComponents]int
+	pceSvn        int
+}
+
+func newTestKey(t *testing.T, template *x509.Certificate, issuer *testKey) testKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.NotBefore = testNow.AddDate(-1, 0, 0)
+	template.NotAfter = testNow.AddDate(5, 0, 0)
+	parent, parentKey := template, key
+	if issuer != nil {
+		parent, parentKey = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return testKey{cert: cert, key: key}
+}
+
+func caTemplate(serial int64, cn string) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Intel Corporation"}},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+}
+
+func newTestPKI(t *testing.T, platform testPlatform) *testPKI {
+	p := &testPKI{}
+	p.root = newTestKey(t, caTemplate(1, "Intel SGX Root CA"), nil)
+	p.pckCA = newTestKey(t, caTemplate(2, "Intel SGX PCK Platform CA"), &p.root)
+	p.tcbSigning = newTestKey(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "Intel SGX TCB Signing"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}, &p.root)
+	p.pck = newTestKey(t, &x509.Certificate{
+		SerialNumber:    big.NewInt(4),
+		Subject:         pkix.Name{CommonName: "Intel SGX PCK Certificate"},
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{sgxExtensionsFixture(t, platform)},
+	}, &p.pckCA)
+	return p
+}
+
+func sgxExtensionsFixture(t *testing.T, platform testPlatform) pkix.Extension {
+	integer := func(v int) asn1.RawValue {
+		b, err := asn1.Marshal(v)
+		require.NoError(t, err)
+		return asn1.RawValue{FullBytes: b}
+	}
+	octets := func(v []byte) asn1.RawValue {
+		b, err := asn1.Marshal(v)
+		require.NoError(t, err)
+		return asn1.RawValue{FullBytes: b}
+	}
+	oid := func(base asn1.ObjectIdentifier, n int) asn1.ObjectIdentifier {
+		return append(append(asn1.ObjectIdentifier{}, base...), n)
+	}
+
+	var tcb []sgxExtension
+	for i, svn := range platform.tcbComponents {
+		tcb = append(tcb, sgxExtension{Id: oid(oidTcb, i+1), Value: integer(svn)})
+	}
+	tcb = append(tcb,
+		sgxExtension{Id: oidPceSvn, Value: integer(platform.pceSvn)},
+		sgxExtension{Id: oid(oidTcb, 18), Value: octets(make([]byte, 16))},
+	)
+	tcbBytes, err := asn1.Marshal(tcb)
+	require.NoError(t, err)
+
+	value, err := asn1.Marshal([]sgxExtension{
+		{Id: oid(oidSgxExtensions, 1), Value: octets(make([]byte, 16))},
+		{Id: oidTcb, Value: asn1.RawValue{FullBytes: tcbBytes}},
+		{Id: oidPceId, Value: octets(testPceId)},
+		{Id: oidFmspc, Value: octets(testFmspc)},
+	})
+	require.NoError(t, err)
+	return pkix.Extension{Id: oidSgxExtensions, Value: value}
+}
+
+func pemCert(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func (p *testPKI) crl(t *testing.T, issuer testKey, revokedSerials []*big.Int) []byte {
+	var entries []x509.RevocationListEntry
+	for _, serial := range revokedSerials {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: testNow.AddDate(0, -1, 0)})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                testNow.AddDate(0, 0, -1),
+		NextUpdate:                testNow.AddDate(0, 1, 0),
+		RevokedCertificateEntries: entries,
+	}, issuer.cert, issuer.key)
+	require.NoError(t, err)
+	return der
+}
+
+// signRaw returns the raw (r || s) ECDSA-SHA256 signature used in quotes and collateral
+func signRaw(t *testing.T, key *ecdsa.PrivateKey, data []byte) []byte {
+	digest := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	signature := make([]byte, ecdsaSignatureSize)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signature
+}
+
+func (p *testPKI) signCollateral(t *testing.T, field string, v interface{}) string {
+	body, err := json.Marshal(v)
+	require.NoError(t, err)
+	signed, err := json.Marshal(map[string]interface{}{
+		field:       json.RawMessage(body),
+		"signature": hex.EncodeToString(signRaw(t, p.tcbSigning.key, body)),
+	})
+	require.NoError(t, err)
+	return string(signed)
+}
+
+func newTcbInfo(levels ...TcbLevel) *TcbInfo {
+	return &TcbInfo{
+		Id:                      "SGX",
+		Version:                 3,
+		IssueDate:               testNow.AddDate(0, 0, -1),
+		NextUpdate:              testNow.AddDate(0, 1, 0),
+		Fmspc:                   "00906EA10000",
+		PceId:                   "0000",
+		TcbEvaluationDataNumber: 16,
+		TcbLevels:               levels,
+	}
+}
+
+func newTcbLevel(svn, pceSvn int, status string, advisoryIDs ...string) TcbLevel {
+	level := TcbLevel{TcbDate: testNow.AddDate(0, -6, 0), TcbStatus: status, AdvisoryIDs: advisoryIDs}
+	for i := 0; i < tcbComponents; i++ {
+		level.Tcb.SgxTcbComponents = append(level.Tcb.SgxTcbComponents, TcbComponent{Svn: svn})
+	}
+	level.Tcb.PceSvn = pceSvn
+	return level
+}
+
+func newQeIdentity(levels ...EnclaveTcbLevel) *EnclaveIdentity {
+	return &EnclaveIdentity{
+		Id:             "QE",
+		Version:        2,
+		IssueDate:      testNow.AddDate(0, 0, -1),
+		NextUpdate:     testNow.AddDate(0, 1, 0),
+		MiscSelect:     "00000000",
+		MiscSelectMask: "FFFFFFFF",
+		Attributes:     "11000000000000000000000000000000",
+		AttributesMask: "FBFFFFFFFFFFFFFF0000000000000000",
+		MrSigner:       hex.EncodeToString(testQeMrSigner[:]),
+		IsvProdId:      1,
+		TcbLevels:      levels,
+	}
+}
+
+func newQeTcbLevel(isvSvn int, status string) EnclaveTcbLevel {
+	level := EnclaveTcbLevel{TcbDate: testNow.AddDate(0, -6, 0), TcbStatus: status}
+	level.Tcb.IsvSvn = isvSvn
+	return level
+}
+
+func (p *testPKI) collateral(t *testing.T, tcbInfo *TcbInfo, qeIdentity *EnclaveIdentity) *Collateral {
+	return &Collateral{
+		TcbInfo:         p.signCollateral(t, "tcbInfo", tcbInfo),
+		QeIdentity:      p.signCollateral(t, "enclaveIdentity", qeIdentity),
+		TcbSigningChain: string(append(pemCert(p.tcbSigning.cert), pemCert(p.root.cert)...)),
+		PckCrl:          string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: p.crl(t, p.pckCA, p.revokedByPckCA)})),
+		RootCaCrl:       string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: p.crl(t, p.root, p.revokedByRoot)})),
+	}
+}
+
+func defaultCollateral(t *testing.T, p *testPKI) *Collateral {
+	return p.collateral(t,
+		newTcbInfo(newTcbLevel(5, 11, TcbUpToDate), newTcbLevel(3, 10, TcbOutOfDate, "INTEL-SA-00334")),
+		newQeIdentity(newQeTcbLevel(8, TcbUpToDate), newQeTcbLevel(6, TcbOutOfDate)),
+	)
+}
+
+// testEnclave is the enclave report in a test quote
+type testEnclave struct {
+	mrenclave [32]byte
+	statement []byte
+	debug     bool
+	qeIsvSvn  uint16
+}
+
+func reportBodyBytes(mrenclave, mrsigner [32]byte, attributes uint64, isvProdId, isvSvn uint16, reportData [64]byte) []byte {
+	b := make([]byte, reportBodySize)
+	binary.LittleEndian.PutUint64(b[48:56], attributes)
+	copy(b[64:96], mrenclave[:])
+	copy(b[128:160], mrsigner[:])
+	binary.LittleEndian.PutUint16(b[256:258], isvProdId)
+	binary.LittleEndian.PutUint16(b[258:260], isvSvn)
+	copy(b[320:384], reportData[:])
+	return b
+}
+
+func (p *testPKI) quote(t *testing.T, enclave testEnclave) []byte {
+	attestationKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	attestationKeyRaw := make([]byte, ecdsaPublicKeySize)
+	attestationKey.X.FillBytes(attestationKeyRaw[:32])
+	attestationKey.Y.FillBytes(attestationKeyRaw[32:])
+
+	header := make([]byte, quoteHeaderSize)
+	binary.LittleEndian.PutUint16(header[0:2], quoteVersion)
+	binary.LittleEndian.PutUint16(header[2:4], attestationKeyTypeECDSAP256)
+	binary.LittleEndian.PutUint16(header[8:10], enclave.qeIsvSvn)
+	binary.LittleEndian.PutUint16(header[10:12], 11)
+
+	attributes := uint64(0x5)
+	if enclave.debug {
+		attributes |= sgxFlagsDebug
+	}
+	var reportData [64]byte
+	statementHash := sha256.Sum256(enclave.statement)
+	copy(reportData[:], statementHash[:])
+	body := reportBodyBytes(enclave.mrenclave, [32]byte{}, attributes, 0, 0, reportData)
+
+	qeAuthData := make([]byte, 32)
+	var qeReportData [64]byte
+	keyHash := sha256.Sum256(append(append([]byte{}, attestationKeyRaw...), qeAuthData...))
+	copy(qeReportData[:], keyHash[:])
+	qeReport := reportBodyBytes([32]byte{}, testQeMrSigner, 0x11, 1, enclave.qeIsvSvn, qeReportData)
+
+	certData := append(append(pemCert(p.pck.cert), pemCert(p.pckCA.cert)...), pemCert(p.root.cert)...)
+
+	var signatureData []byte
+	signatureData = append(signatureData, signRaw(t, attestationKey, append(append([]byte{}, header...), body...))...)
+	signatureData = append(signatureData, attestationKeyRaw...)
+	signatureData = append(signatureData, qeReport...)
+	signatureData = append(signatureData, signRaw(t, p.pck.key, qeReport)...)
+	signatureData = binary.LittleEndian.AppendUint16(signatureData, uint16(len(qeAuthData)))
+	signatureData = append(signatureData, qeAuthData...)
+	signatureData = binary.LittleEndian.AppendUint16(signatureData, certDataTypePCKCertChain)
+	signatureData = binary.LittleEndian.AppendUint32(signatureData, uint32(len(certData)))
+	signatureData = append(signatureData, certData...)
+
+	quote := append(append([]byte{}, header...), body...)
+	quote = binary.LittleEndian.AppendUint32(quote, uint32(len(signatureData)))
+	return append(quote, signatureData...)
+}
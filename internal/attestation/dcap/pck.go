@@ -0,0 +1,113 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const tcbComponents = 16
+
+var (
+	oidSgxExtensions = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1}
+	oidTcb           = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 2}
+	oidPceSvn        = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 2, 17}
+	oidPceId         = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 3}
+	oidFmspc         = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 4}
+)
+
+// PckExtensions are the SGX extensions of a PCK certificate, which identify the platform and its TCB
+type PckExtensions struct {
+	// Fmspc and PceId are hex-encoded (upper case), as in the TCB info
+	Fmspc         string
+	PceId         string
+	TcbComponents [tcbComponents]int
+	PceSvn        int
+}
+
+// sgxExtension is an element of the (nested) sequences in the SGX extensions of a PCK certificate
+type sgxExtension struct {
+	Id    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// ParsePckExtensions returns the SGX extensions of a PCK certificate
+func ParsePckExtensions(cert *x509.Certificate) (*PckExtensions, error) {
+	var sgxExtensions []sgxExtension
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidSgxExtensions) {
+			if _, err := asn1.Unmarshal(ext.Value, &sgxExtensions); err != nil {
+				return nil, errors.Wrap(err, "invalid SGX extensions")
+			}
+		}
+	}
+	if sgxExtensions == nil {
+		return nil, fmt.Errorf("no SGX extensions found")
+	}
+
+	pck := &PckExtensions{PceSvn: -1}
+	for i := range pck.TcbComponents {
+		pck.TcbComponents[i] = -1
+	}
+	for _, ext := range sgxExtensions {
+		switch {
+		case ext.Id.Equal(oidFmspc):
+			pck.Fmspc = strings.ToUpper(hex.EncodeToString(ext.Value.Bytes))
+		case ext.Id.Equal(oidPceId):
+			pck.PceId = strings.ToUpper(hex.EncodeToString(ext.Value.Bytes))
+		case ext.Id.Equal(oidTcb):
+			if err := pck.parseTcb(ext.Value.FullBytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if pck.Fmspc == "" || pck.PceId == "" || pck.PceSvn < 0 {
+		return nil, fmt.Errorf("incomplete SGX extensions")
+	}
+	for i, svn := range pck.TcbComponents {
+		if svn < 0 {
+			return nil, fmt.Errorf("missing TCB component %d in SGX extensions", i+1)
+		}
+	}
+	return pck, nil
+}
+
+func (pck *PckExtensions) parseTcb(raw []byte) error {
+	var tcb []sgxExtension
+	if _, err := asn1.Unmarshal(raw, &tcb); err != nil {
+		return errors.Wrap(err, "invalid TCB in SGX extensions")
+	}
+
+	for _, ext := range tcb {
+		// the TCB components are 1.2.840.113741.1.13.1.2.1 to .16, followed by the PCESVN (.17) and CPUSVN (.18)
+		if len(ext.Id) != len(oidTcb)+1 || !ext.Id[:len(oidTcb)].Equal(oidTcb) {
+			continue
+		}
+		n := ext.Id[len(oidTcb)]
+		if n < 1 || n > tcbComponents && !ext.Id.Equal(oidPceSvn) {
+			continue
+		}
+
+		var svn int
+		if _, err := asn1.Unmarshal(ext.Value.FullBytes, &svn); err != nil {
+			return errors.Wrapf(err, "invalid TCB component %s in SGX extensions", ext.Id)
+		}
+		if ext.Id.Equal(oidPceSvn) {
+			pck.PceSvn = svn
+		} else {
+			pck.TcbComponents[n-1] = svn
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	quoteVersion                = 3
+	attestationKeyTypeECDSAP256 = 2
+	teeTypeSGX                  = 0
+	certDataTypePCKCertChain    = 5
+
+	quoteHeaderSize    = 48
+	reportBodySize     = 384
+	ecdsaSignatureSize = 64
+	ecdsaPublicKeySize = 64
+
+	// sgxFlagsDebug is the DEBUG flag in the attributes of an enclave report
+	sgxFlagsDebug = 0x2
+)
+
+// ReportBody is an SGX enclave report body (sgx_report_body_t)
+type ReportBody struct {
+	CpuSvn     [16]byte
+	MiscSelect uint32
+	Attributes [16]byte
+	MrEnclave  [32]byte
+	MrSigner   [32]byte
+	IsvProdId  uint16
+	IsvSvn     uint16
+	ReportData [64]byte
+}
+
+func parseReportBody(b []byte) *ReportBody {
+	r := &ReportBody{
+		MiscSelect: binary.LittleEndian.Uint32(b[16:20]),
+		IsvProdId:  binary.LittleEndian.Uint16(b[256:258]),
+		IsvSvn:     binary.LittleEndian.Uint16(b[258:260]),
+	}
+	copy(r.CpuSvn[:], b[0:16])
+	copy(r.Attributes[:], b[48:64])
+	copy(r.MrEnclave[:], b[64:96])
+	copy(r.MrSigner[:], b[128:160])
+	copy(r.ReportData[:], b[320:384])
+	return r
+}
+
+// Debug returns true if the report is issued by a debug enclave, whose memory can be inspected by the host
+func (r *ReportBody) Debug() bool {
+	return binary.LittleEndian.Uint64(r.Attributes[0:8])&sgxFlagsDebug != 0
+}
+
+// Quote is an SGX ECDSA quote (version 3) as created by the DCAP quoting enclave
+type Quote struct {
+	QeSvn      uint16
+	PceSvn     uint16
+	QeVendorId [16]byte
+	ReportBody *ReportBody
+
+	// Signature is the signature of the quote header and report body by AttestationKey
+	Signature      []byte
+	AttestationKey []byte
+	// QeReportBody is the report of the quoting enclave, signed by the PCK with QeReportSignature; it binds the
+	// attestation key to the quoting enclave
+	QeReportBody      *ReportBody
+	QeReportSignature []byte
+	QeAuthData        []byte
+	// PckCertChain is the PCK certificate followed by its issuer chain
+	PckCertChain []*x509.Certificate
+
+	signedData []byte
+	qeReport   []byte
+}
+
+// ParseQuote parses an SGX ECDSA quote; it only checks the structure, not the signatures
+func ParseQuote(raw []byte) (*Quote, error) {
+	r := &reader{b: raw}
+
+	header := r.next(quoteHeaderSize)
+	body := r.next(reportBodySize)
+	signatureDataLen := r.uint32()
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid quote")
+	}
+
+	version := binary.LittleEndian.Uint16(header[0:2])
+	if version != quoteVersion {
+		return nil, fmt.Errorf("unsupported quote version %d", version)
+	}
+	if keyType := binary.LittleEndian.Uint16(header[2:4]); keyType != attestationKeyTypeECDSAP256 {
+		return nil, fmt.Errorf("unsupported attestation key type %d", keyType)
+	}
+	if teeType := binary.LittleEndian.Uint32(header[4:8]); teeType != teeTypeSGX {
+		return nil, fmt.Errorf("unsupported tee type %d", teeType)
+	}
+
+	q := &Quote{
+		QeSvn:      binary.LittleEndian.Uint16(header[8:10]),
+		PceSvn:     binary.LittleEndian.Uint16(header[10:12]),
+		ReportBody: parseReportBody(body),
+		signedData: raw[:quoteHeaderSize+reportBodySize],
+	}
+	copy(q.QeVendorId[:], header[12:28])
+
+	r = &reader{b: r.next(int(signatureDataLen))}
+	q.Signature = r.next(ecdsaSignatureSize)
+	q.AttestationKey = r.next(ecdsaPublicKeySize)
+	q.qeReport = r.next(reportBodySize)
+	q.QeReportSignature = r.next(ecdsaSignatureSize)
+	q.QeAuthData = r.next(int(r.uint16()))
+	certDataType := r.uint16()
+	certData := r.next(int(r.uint32()))
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid quote signature data")
+	}
+	q.QeReportBody = parseReportBody(q.qeReport)
+
+	if certDataType != certDataTypePCKCertChain {
+		return nil, fmt.Errorf("unsupported certification data type %d", certDataType)
+	}
+	chain, err := parseCertificates(certData)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid PCK certificate chain")
+	}
+	q.PckCertChain = chain
+
+	return q, nil
+}
+
+// parseCertificates parses a chain of PEM-encoded certificates; trailing zero bytes are ignored
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
+
+// reader reads little-endian encoded quote fields; after the first error, all reads return nil or zero
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.b) < n {
+		r.err = fmt.Errorf("unexpected end of data")
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
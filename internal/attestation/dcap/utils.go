@@ -0,0 +1,64 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CollateralPathEnv defines the directory with the collateral used by the converter
+	CollateralPathEnv = "DCAP_COLLATERAL_PATH"
+	// RootCAEnv defines the PEM file with the Intel SGX root CA certificate used by the verifier
+	RootCAEnv = "DCAP_ROOT_CA"
+
+	rootCAFile = "Intel_SGX_Provisioning_Certification_RootCA.pem"
+)
+
+// collateralPath returns $DCAP_COLLATERAL_PATH, or $FPC_PATH/config/dcap as fallback
+func collateralPath() (string, error) {
+	if path := os.Getenv(CollateralPathEnv); len(path) != 0 {
+		return path, nil
+	}
+	return fpcConfigPath()
+}
+
+// loadRootCA loads the Intel SGX root CA certificate from $DCAP_ROOT_CA, or from
+// $FPC_PATH/config/dcap/Intel_SGX_Provisioning_Certification_RootCA.pem as fallback
+func loadRootCA() (*x509.Certificate, error) {
+	path := os.Getenv(RootCAEnv)
+	if len(path) == 0 {
+		configPath, err := fpcConfigPath()
+		if err != nil {
+			return nil, errors.Wrapf(err, "$%s not set", RootCAEnv)
+		}
+		path = filepath.Join(configPath, rootCAFile)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s", path)
+	}
+	certs, err := parseCertificates(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid root CA certificate in %s", path)
+	}
+	return certs[0], nil
+}
+
+func fpcConfigPath() (string, error) {
+	fpcPath := os.Getenv("FPC_PATH")
+	if len(fpcPath) == 0 {
+		return "", fmt.Errorf("$FPC_PATH not set")
+	}
+	return filepath.Join(fpcPath, "config", "dcap"), nil
+}
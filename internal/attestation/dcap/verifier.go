@@ -0,0 +1,376 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+// TCB status values of the TCB info and QE identity
+const (
+	TcbUpToDate                          = "UpToDate"
+	TcbSWHardeningNeeded                 = "SWHardeningNeeded"
+	TcbConfigurationNeeded               = "ConfigurationNeeded"
+	TcbConfigurationAndSWHardeningNeeded = "ConfigurationAndSWHardeningNeeded"
+	TcbOutOfDate                         = "OutOfDate"
+	TcbOutOfDateConfigurationNeeded      = "OutOfDateConfigurationNeeded"
+	TcbRevoked                           = "Revoked"
+)
+
+// Report is the result of a successful quote verification
+type Report struct {
+	ReportBody *ReportBody
+	// TcbStatus is the TCB status of the platform, which is downgraded if the quoting enclave is out of date
+	TcbStatus   string
+	TcbDate     time.Time
+	AdvisoryIDs []string
+	QeTcbStatus string
+	// IssueDate is the issue date of the collateral, i.e., the earlier of the TCB info and QE identity issue dates
+	IssueDate time.Time
+}
+
+type verifier struct {
+	rootCA func() (*x509.Certificate, error)
+	now    func() time.Time
+}
+
+// NewDcapVerifier creates a new verifier for Intel SGX DCAP (ECDSA) attestation. The quote is verified with the
+// collateral in the evidence against the Intel SGX root CA ($DCAP_ROOT_CA). The result reports the TCB status of the
+// platform and the issue date of the collateral as timestamp.
+func NewDcapVerifier() *types.Verifier {
+	v := &verifier{rootCA: loadRootCA, now: time.Now}
+	return &types.Verifier{
+		Type:   DcapType,
+		Verify: v.verify,
+	}
+}
+
+func (v *verifier) verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (*types.Result, error) {
+	e := &Evidence{}
+	if err := json.Unmarshal([]byte(evidence.Data), e); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal dcap evidence")
+	}
+	if e.Collateral == nil {
+		return nil, fmt.Errorf("no collateral in dcap evidence")
+	}
+
+	root, err := v.rootCA()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot load root CA")
+	}
+
+	now := expectedValidationValues.Time
+	if now.IsZero() {
+		now = v.now()
+	}
+	report, err := VerifyQuote(e.Quote, e.Collateral, root, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkReportBody(report.ReportBody, expectedValidationValues); err != nil {
+		return nil, err
+	}
+	return &types.Result{
+		Status:      report.TcbStatus,
+		AdvisoryIDs: report.AdvisoryIDs,
+		Timestamp:   report.IssueDate,
+		Debug:       report.ReportBody.Debug(),
+	}, nil
+}
+
+// checkReportBody compares mrenclave and report data of the enclave report with the expected values. As with EPID,
+// the report data is the hash of the statement, followed by zeros.
+func checkReportBody(body *ReportBody, expectedValidationValues *types.ValidationValues) error {
+	expectedMrenclave, err := hex.DecodeString(expectedValidationValues.Mrenclave)
+	if err != nil {
+		return errors.Wrap(err, "invalid expected mrenclave")
+	}
+	if !bytes.Equal(body.MrEnclave[:], expectedMrenclave) {
+		return fmt.Errorf("expected mrenclave mismatch")
+	}
+
+	var expectedReportData [64]byte
+	statementHash := sha256.Sum256(expectedValidationValues.Statement)
+	copy(expectedReportData[:], statementHash[:])
+	if body.ReportData != expectedReportData {
+		return fmt.Errorf("expected statement mismatch")
+	}
+	return nil
+}
+
+// VerifyQuote verifies a quote with its collateral against the root CA at the given time. The returned report
+// contains the verified enclave report and the TCB status of the platform.
+func VerifyQuote(rawQuote []byte, collateral *Collateral, root *x509.Certificate, now time.Time) (*Report, error) {
+	quote, err := ParseQuote(rawQuote)
+	if err != nil {
+		return nil, err
+	}
+
+	rootCaCrl, err := parseCrl([]byte(collateral.RootCaCrl))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid root CA CRL")
+	}
+	if err := checkCrl(rootCaCrl, root, now); err != nil {
+		return nil, errors.Wrap(err, "invalid root CA CRL")
+	}
+
+	// PCK certificate chain
+	pckChain, err := verifyCertChain(quote.PckCertChain, root, rootCaCrl, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid PCK certificate chain")
+	}
+	pckCert := pckChain[0]
+	pckCrl, err := parseCrl([]byte(collateral.PckCrl))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid PCK CRL")
+	}
+	if err := checkCrl(pckCrl, pckChain[1], now); err != nil {
+		return nil, errors.Wrap(err, "invalid PCK CRL")
+	}
+	if revoked(pckCert, pckCrl) {
+		return nil, fmt.Errorf("PCK certificate revoked")
+	}
+	pck, err := ParsePckExtensions(pckCert)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid PCK certificate")
+	}
+
+	// TCB info and QE identity
+	tcbSigningChain, err := parseCertificates([]byte(collateral.TcbSigningChain))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid TCB signing chain")
+	}
+	tcbSigningChain, err = verifyCertChain(tcbSigningChain, root, rootCaCrl, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid TCB signing chain")
+	}
+	tcbSigningCert := tcbSigningChain[0]
+	tcbInfo, err := parseTcbInfo(collateral.TcbInfo, tcbSigningCert)
+	if err != nil {
+		return nil, err
+	}
+	if now.After(tcbInfo.NextUpdate) {
+		return nil, fmt.Errorf("TCB info expired on %s", tcbInfo.NextUpdate)
+	}
+	qeIdentity, err := parseQeIdentity(collateral.QeIdentity, tcbSigningCert)
+	if err != nil {
+		return nil, err
+	}
+	if now.After(qeIdentity.NextUpdate) {
+		return nil, fmt.Errorf("QE identity expired on %s", qeIdentity.NextUpdate)
+	}
+
+	// the quoting enclave report is signed by the PCK and binds the attestation key
+	if err := verifyECDSA(pckCert.PublicKey.(*ecdsa.PublicKey), quote.qeReport, quote.QeReportSignature); err != nil {
+		return nil, errors.Wrap(err, "invalid QE report signature")
+	}
+	keyHash := sha256.Sum256(append(append([]byte{}, quote.AttestationKey...), quote.QeAuthData...))
+	var expectedQeReportData [64]byte
+	copy(expectedQeReportData[:], keyHash[:])
+	if quote.QeReportBody.ReportData != expectedQeReportData {
+		return nil, fmt.Errorf("attestation key not bound to QE report")
+	}
+
+	// the quote is signed by the attestation key
+	attestationKey := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(quote.AttestationKey[:32]),
+		Y:     new(big.Int).SetBytes(quote.AttestationKey[32:]),
+	}
+	if err := verifyECDSA(attestationKey, quote.signedData, quote.Signature); err != nil {
+		return nil, errors.Wrap(err, "invalid quote signature")
+	}
+
+	qeTcbLevel, err := matchQeIdentity(quote.QeReportBody, qeIdentity)
+	if err != nil {
+		return nil, err
+	}
+	tcbLevel, err := matchTcbInfo(pck, tcbInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var advisoryIDs []string
+	advisoryIDs = append(advisoryIDs, tcbLevel.AdvisoryIDs...)
+	advisoryIDs = append(advisoryIDs, qeTcbLevel.AdvisoryIDs...)
+	issueDate := tcbInfo.IssueDate
+	if qeIdentity.IssueDate.Before(issueDate) {
+		issueDate = qeIdentity.IssueDate
+	}
+
+	return &Report{
+		ReportBody:  quote.ReportBody,
+		TcbStatus:   convergeTcbStatus(tcbLevel.TcbStatus, qeTcbLevel.TcbStatus),
+		TcbDate:     tcbLevel.TcbDate,
+		AdvisoryIDs: advisoryIDs,
+		QeTcbStatus: qeTcbLevel.TcbStatus,
+		IssueDate:   issueDate,
+	}, nil
+}
+
+// verifyCertChain verifies the chain (leaf first) against the root and returns the verified chain, from the leaf
+// to the root. The certificates issued by the root must not be revoked by the root CA CRL.
+func verifyCertChain(chain []*x509.Certificate, root *x509.Certificate, rootCaCrl *x509.RevocationList, now time.Time) ([]*x509.Certificate, error) {
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	leaf := chain[0]
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, err
+	}
+	verified := chains[0]
+	if len(verified) < 2 {
+		return nil, fmt.Errorf("incomplete certificate chain")
+	}
+	if revoked(verified[len(verified)-2], rootCaCrl) {
+		return nil, fmt.Errorf("certificate %s revoked", verified[len(verified)-2].Subject.CommonName)
+	}
+	if _, ok := leaf.PublicKey.(*ecdsa.PublicKey); !ok {
+		return nil, fmt.Errorf("unsupported key in certificate %s", leaf.Subject.CommonName)
+	}
+	return verified, nil
+}
+
+// checkCrl checks that the CRL is signed by the issuer and not expired
+func checkCrl(crl *x509.RevocationList, issuer *x509.Certificate, now time.Time) error {
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return err
+	}
+	if now.After(crl.NextUpdate) {
+		return fmt.Errorf("CRL expired on %s", crl.NextUpdate)
+	}
+	return nil
+}
+
+func revoked(cert *x509.Certificate, crl *x509.RevocationList) bool {
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTcbInfo returns the highest TCB level of the platform that is not higher than the TCB of the PCK certificate
+func matchTcbInfo(pck *PckExtensions, tcbInfo *TcbInfo) (*TcbLevel, error) {
+	if pck.Fmspc != tcbInfo.Fmspc {
+		return nil, fmt.Errorf("TCB info for FMSPC %s, expected %s", tcbInfo.Fmspc, pck.Fmspc)
+	}
+	if pck.PceId != tcbInfo.PceId {
+		return nil, fmt.Errorf("TCB info for PCE ID %s, expected %s", tcbInfo.PceId, pck.PceId)
+	}
+
+	// the TCB levels are sorted in descending order
+	for i := range tcbInfo.TcbLevels {
+		level := &tcbInfo.TcbLevels[i]
+		if len(level.Tcb.SgxTcbComponents) != tcbComponents {
+			return nil, fmt.Errorf("invalid TCB level in TCB info")
+		}
+		if pck.PceSvn < level.Tcb.PceSvn {
+			continue
+		}
+		match := true
+		for j, c := range level.Tcb.SgxTcbComponents {
+			if pck.TcbComponents[j] < c.Svn {
+				match = false
+				break
+			}
+		}
+		if match {
+			return level, nil
+		}
+	}
+	return nil, fmt.Errorf("TCB of the platform is not supported")
+}
+
+// matchQeIdentity checks the quoting enclave report against the QE identity and returns its TCB level
+func matchQeIdentity(qe *ReportBody, identity *EnclaveIdentity) (*EnclaveTcbLevel, error) {
+	miscSelect, err := strconv.ParseUint(identity.MiscSelect, 16, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid miscselect in QE identity")
+	}
+	miscSelectMask, err := strconv.ParseUint(identity.MiscSelectMask, 16, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid miscselectMask in QE identity")
+	}
+	attributes, err := hex.DecodeString(identity.Attributes)
+	if err != nil || len(attributes) != len(qe.Attributes) {
+		return nil, fmt.Errorf("invalid attributes in QE identity")
+	}
+	attributesMask, err := hex.DecodeString(identity.AttributesMask)
+	if err != nil || len(attributesMask) != len(qe.Attributes) {
+		return nil, fmt.Errorf("invalid attributesMask in QE identity")
+	}
+	mrSigner, err := hex.DecodeString(identity.MrSigner)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid mrsigner in QE identity")
+	}
+
+	if uint64(qe.MiscSelect)&miscSelectMask != miscSelect {
+		return nil, fmt.Errorf("QE miscselect mismatch")
+	}
+	for i := range attributes {
+		if qe.Attributes[i]&attributesMask[i] != attributes[i] {
+			return nil, fmt.Errorf("QE attributes mismatch")
+		}
+	}
+	if !bytes.Equal(qe.MrSigner[:], mrSigner) {
+		return nil, fmt.Errorf("QE mrsigner mismatch")
+	}
+	if int(qe.IsvProdId) != identity.IsvProdId {
+		return nil, fmt.Errorf("QE isvprodid mismatch")
+	}
+
+	for i := range identity.TcbLevels {
+		if int(qe.IsvSvn) >= identity.TcbLevels[i].Tcb.IsvSvn {
+			return &identity.TcbLevels[i], nil
+		}
+	}
+	return nil, fmt.Errorf("TCB of the quoting enclave is not supported")
+}
+
+// convergeTcbStatus returns the TCB status of the platform, taking into account the TCB status of the quoting enclave
+func convergeTcbStatus(platformStatus, qeStatus string) string {
+	switch qeStatus {
+	case TcbUpToDate:
+		return platformStatus
+	case TcbOutOfDate:
+		switch platformStatus {
+		case TcbUpToDate, TcbSWHardeningNeeded:
+			return TcbOutOfDate
+		case TcbConfigurationNeeded, TcbConfigurationAndSWHardeningNeeded:
+			return TcbOutOfDateConfigurationNeeded
+		}
+		return platformStatus
+	default:
+		return TcbRevoked
+	}
+}
@@ -0,0 +1,259 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dcap
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	upToDatePlatform = testPlatform{tcbComponents: [tcbComponents]int{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5}, pceSvn: 11}
+	testMrenclave    = [32]byte{0xde, 0xad, 0xbe, 0xef}
+)
+
+func TestParseQuote(t *testing.T) {
+	p := newTestPKI(t, upToDatePlatform)
+	raw := p.quote(t, testEnclave{mrenclave: testMrenclave, qeIsvSvn: 8})
+
+	q, err := ParseQuote(raw)
+	require.NoError(t, err)
+	assert.Equal(t, testMrenclave, q.ReportBody.MrEnclave)
+	assert.False(t, q.ReportBody.Debug())
+	assert.Equal(t, testQeMrSigner, q.QeReportBody.MrSigner)
+	assert.EqualValues(t, 8, q.QeReportBody.IsvSvn)
+	require.Len(t, q.PckCertChain, 3)
+	assert.True(t, q.PckCertChain[0].Equal(p.pck.cert))
+
+	pck, err := ParsePckExtensions(q.PckCertChain[0])
+	require.NoError(t, err)
+	assert.Equal(t, "00906EA10000", pck.Fmspc)
+	assert.Equal(t, "0000", pck.PceId)
+	assert.Equal(t, upToDatePlatform.tcbComponents, pck.TcbComponents)
+	assert.Equal(t, 11, pck.PceSvn)
+
+	// truncated quotes
+	for _, n := range []int{0, quoteHeaderSize, len(raw) - 1} {
+		_, err = ParseQuote(raw[:n])
+		assert.Error(t, err)
+	}
+
+	// unsupported version
+	raw[0] = 4
+	_, err = ParseQuote(raw)
+	assert.EqualError(t, err, "unsupported quote version 4")
+}
+
+func TestVerifyQuote(t *testing.T) {
+	p := newTestPKI(t, upToDatePlatform)
+	collateral := defaultCollateral(t, p)
+	quote := p.quote(t, testEnclave{mrenclave: testMrenclave, qeIsvSvn: 8})
+
+	report, err := VerifyQuote(quote, collateral, p.root.cert, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, testMrenclave, report.ReportBody.MrEnclave)
+	assert.Equal(t, TcbUpToDate, report.TcbStatus)
+	assert.Equal(t, TcbUpToDate, report.QeTcbStatus)
+	assert.Empty(t, report.AdvisoryIDs)
+
+	// out of date quoting enclave
+	report, err = VerifyQuote(p.quote(t, testEnclave{mrenclave: testMrenclave, qeIsvSvn: 7}), collateral, p.root.cert, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, TcbOutOfDate, report.TcbStatus)
+	assert.Equal(t, TcbOutOfDate, report.QeTcbStatus)
+
+	// unsupported quoting enclave
+	_, err = VerifyQuote(p.quote(t, testEnclave{mrenclave: testMrenclave, qeIsvSvn: 5}), collateral, p.root.cert, testNow)
+	assert.EqualError(t, err, "TCB of the quoting enclave is not supported")
+
+	// expired collateral
+	_, err = VerifyQuote(quote, collateral, p.root.cert, testNow.AddDate(0, 2, 0))
+	assert.Error(t, err)
+
+	// untrusted root
+	other := newTestPKI(t, upToDatePlatform)
+	_, err = VerifyQuote(quote, collateral, other.root.cert, testNow)
+	assert.Error(t, err)
+
+	// collateral signed by another TCB signing key
+	forged := defaultCollateral(t, other)
+	forged.TcbSigningChain = collateral.TcbSigningChain
+	_, err = VerifyQuote(quote, forged, p.root.cert, testNow)
+	assert.Error(t, err)
+
+	// modified report body
+	modified := append([]byte{}, quote...)
+	modified[quoteHeaderSize+64] ^= 0xff
+	_, err = VerifyQuote(modified, collateral, p.root.cert, testNow)
+	assert.EqualError(t, err, "invalid quote signature: signature verification failed")
+
+	// collateral for another platform
+	tcbInfo := newTcbInfo(newTcbLevel(5, 11, TcbUpToDate))
+	tcbInfo.Fmspc = "00606A000000"
+	_, err = VerifyQuote(quote, p.collateral(t, tcbInfo, newQeIdentity(newQeTcbLevel(8, TcbUpToDate))), p.root.cert, testNow)
+	assert.EqualError(t, err, "TCB info for FMSPC 00606A000000, expected 00906EA10000")
+}
+
+func TestTcbLevels(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		platform    testPlatform
+		status      string
+		advisoryIDs []string
+	}{
+		{"up to date", upToDatePlatform, TcbUpToDate, nil},
+		{"newer than latest level", testPlatform{tcbComponents: [tcbComponents]int{7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7}, pceSvn: 12}, TcbUpToDate, nil},
+		{"one component out of date", testPlatform{tcbComponents: [tcbComponents]int{5, 5, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5}, pceSvn: 11}, TcbOutOfDate, []string{"INTEL-SA-00334"}},
+		{"pce out of date", testPlatform{tcbComponents: upToDatePlatform.tcbComponents, pceSvn: 10}, TcbOutOfDate, []string{"INTEL-SA-00334"}},
+		{"not supported", testPlatform{tcbComponents: [tcbComponents]int{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}, pceSvn: 11}, "", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestPKI(t, tc.platform)
+			report, err := VerifyQuote(p.quote(t, testEnclave{mrenclave: testMrenclave, qeIsvSvn: 8}), defaultCollateral(t, p), p.root.cert, testNow)
+			if tc.status == "" {
+				assert.EqualError(t, err, "TCB of the platform is not supported")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.status, report.TcbStatus)
+			assert.Equal(t, tc.advisoryIDs, report.AdvisoryIDs)
+		})
+	}
+}
+
+func TestRevocation(t *testing.T) {
+	p := newTestPKI(t, upToDatePlatform)
+	quote := p.quote(t, testEnclave{mrenclave: testMrenclave, qeIsvSvn: 8})
+
+	// revoked PCK certificate
+	p.revokedByPckCA = []*big.Int{p.pck.cert.SerialNumber}
+	_, err := VerifyQuote(quote, defaultCollateral(t, p), p.root.cert, testNow)
+	assert.EqualError(t, err, "PCK certificate revoked")
+
+	// revoked PCK CA
+	p.revokedByPckCA = nil
+	p.revokedByRoot = []*big.Int{p.pckCA.cert.SerialNumber}
+	_, err = VerifyQuote(quote, defaultCollateral(t, p), p.root.cert, testNow)
+	assert.EqualError(t, err, "invalid PCK certificate chain: certificate Intel SGX PCK Platform CA revoked")
+
+	// revoked TCB signing certificate
+	p.revokedByRoot = []*big.Int{p.tcbSigning.cert.SerialNumber}
+	_, err = VerifyQuote(quote, defaultCollateral(t, p), p.root.cert, testNow)
+	assert.EqualError(t, err, "invalid TCB signing chain: certificate Intel SGX TCB Signing revoked")
+
+	// CRL not signed by the issuer
+	p.revokedByRoot = nil
+	collateral := defaultCollateral(t, p)
+	collateral.PckCrl = collateral.RootCaCrl
+	_, err = VerifyQuote(quote, collateral, p.root.cert, testNow)
+	assert.Error(t, err)
+}
+
+func TestVerifier(t *testing.T) {
+	p := newTestPKI(t, upToDatePlatform)
+	collateral := defaultCollateral(t, p)
+	v := &verifier{
+		rootCA: func() (*x509.Certificate, error) { return p.root.cert, nil },
+		now:    func() time.Time { return testNow },
+	}
+	statement := []byte("someAttestedData")
+	expected := &types.ValidationValues{Statement: statement, Mrenclave: hex.EncodeToString(testMrenclave[:])}
+
+	evidence := func(enclave testEnclave) *types.Evidence {
+		data, err := json.Marshal(&Evidence{Quote: p.quote(t, enclave), Collateral: collateral})
+		require.NoError(t, err)
+		return &types.Evidence{Type: DcapType, Data: string(data)}
+	}
+
+	result, err := v.verify(evidence(testEnclave{mrenclave: testMrenclave, statement: statement, qeIsvSvn: 8}), expected)
+	require.NoError(t, err)
+	assert.Equal(t, &types.Result{Status: TcbUpToDate, Timestamp: testNow.AddDate(0, 0, -1)}, result)
+
+	_, err = v.verify(evidence(testEnclave{mrenclave: [32]byte{0x01}, statement: statement, qeIsvSvn: 8}), expected)
+	assert.EqualError(t, err, "expected mrenclave mismatch")
+
+	_, err = v.verify(evidence(testEnclave{mrenclave: testMrenclave, statement: []byte("someOtherData"), qeIsvSvn: 8}), expected)
+	assert.EqualError(t, err, "expected statement mismatch")
+
+	// debug enclaves and degraded platforms are reported in the result, and evaluated by the verification policy
+	result, err = v.verify(evidence(testEnclave{mrenclave: testMrenclave, statement: statement, debug: true, qeIsvSvn: 8}), expected)
+	require.NoError(t, err)
+	assert.True(t, result.Debug)
+
+	collateral = p.collateral(t, newTcbInfo(newTcbLevel(5, 11, TcbRevoked, "INTEL-SA-00615")), newQeIdentity(newQeTcbLevel(8, TcbUpToDate)))
+	result, err = v.verify(evidence(testEnclave{mrenclave: testMrenclave, statement: statement, qeIsvSvn: 8}), expected)
+	require.NoError(t, err)
+	assert.Equal(t, TcbRevoked, result.Status)
+	assert.Equal(t, []string{"INTEL-SA-00615"}, result.AdvisoryIDs)
+
+	// the collateral must be valid at the verification time
+	expected.Time = testNow.AddDate(1, 0, 0)
+	_, err = v.verify(evidence(testEnclave{mrenclave: testMrenclave, statement: statement, qeIsvSvn: 8}), expected)
+	assert.Error(t, err)
+	expected.Time = time.Time{}
+
+	// no collateral
+	_, err = v.verify(&types.Evidence{Type: DcapType, Data: "{}"}, expected)
+	assert.EqualError(t, err, "no collateral in dcap evidence")
+}
+
+func TestConverter(t *testing.T) {
+	p := newTestPKI(t, upToDatePlatform)
+	collateral := defaultCollateral(t, p)
+	statement := []byte("someAttestedData")
+	quote := p.quote(t, testEnclave{mrenclave: testMrenclave, statement: statement, qeIsvSvn: 8})
+	attestation := base64.StdEncoding.EncodeToString(quote)
+
+	// the root CA CRL is stored DER-encoded, as distributed by Intel
+	rootCaCrl, _ := pem.Decode([]byte(collateral.RootCaCrl))
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"tcb_info_00906ea10000.json": collateral.TcbInfo,
+		"qe_identity.json":           collateral.QeIdentity,
+		"tcb_signing_chain.pem":      collateral.TcbSigningChain,
+		"pck_crl_platform.crl":       collateral.PckCrl,
+		"root_ca_crl.crl":            string(rootCaCrl.Bytes),
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0600))
+	}
+	t.Setenv(CollateralPathEnv, dir)
+
+	evidenceBytes, err := NewDcapConverter().Converter([]byte(attestation))
+	require.NoError(t, err)
+
+	// the evidence can be verified offline
+	rootCAPath := filepath.Join(t.TempDir(), "root.pem")
+	require.NoError(t, os.WriteFile(rootCAPath, pemCert(p.root.cert), 0600))
+	t.Setenv(RootCAEnv, rootCAPath)
+	v := &verifier{rootCA: loadRootCA, now: func() time.Time { return testNow }}
+	_, err = v.verify(&types.Evidence{Type: DcapType, Data: string(evidenceBytes)}, &types.ValidationValues{
+		Statement: statement,
+		Mrenclave: hex.EncodeToString(testMrenclave[:]),
+	})
+	assert.NoError(t, err)
+
+	// missing collateral
+	require.NoError(t, os.Remove(filepath.Join(dir, "qe_identity.json")))
+	_, err = NewDcapConverter().Converter([]byte(attestation))
+	assert.Error(t, err)
+
+	// not a quote
+	_, err = NewDcapConverter().Converter([]byte("bm90IGEgcXVvdGU="))
+	assert.Error(t, err)
+}
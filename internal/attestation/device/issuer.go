@@ -0,0 +1,108 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package device implements an attestation issuer for enclaves that obtain their quotes through an attestation
+// pseudo-filesystem, such as /dev/attestation of Gramine. The quotes are converted and verified as the quotes of
+// enclaves built with the Intel SGX SDK, i.e., by the epid and dcap packages.
+package device
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPath is the attestation device of Gramine
+	DefaultPath = "/dev/attestation"
+	// PathEnv overrides the path of the attestation device
+	PathEnv = "FPC_ATTESTATION_DEVICE"
+
+	// files of the attestation device
+	attestationTypeFile = "attestation_type"
+	userReportDataFile  = "user_report_data"
+	quoteFile           = "quote"
+
+	userReportDataSize = 64
+)
+
+// Path returns the path of the attestation device, i.e., $FPC_ATTESTATION_DEVICE or DefaultPath
+func Path() string {
+	if path := os.Getenv(PathEnv); len(path) > 0 {
+		return path
+	}
+	return DefaultPath
+}
+
+// NewDeviceIssuer creates a new attestation issuer that reads quotes from the attestation device at the given path.
+// The attestation type is determined by the device: "dcap" quotes are issued as dcap.DcapType, "epid" quotes as
+// epidType, i.e., as epid.LinkableType or epid.UnlinkableType according to the SPID of the platform.
+func NewDeviceIssuer(path, epidType string) (*types.Issuer, error) {
+	deviceType, err := os.ReadFile(filepath.Join(path, attestationTypeFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read attestation device")
+	}
+
+	var attestationType string
+	switch t := strings.TrimSpace(string(deviceType)); t {
+	case "dcap":
+		attestationType = dcap.DcapType
+	case "epid":
+		if epidType != epid.LinkableType && epidType != epid.UnlinkableType {
+			return nil, fmt.Errorf("invalid EPID attestation type '%s'", epidType)
+		}
+		attestationType = epidType
+	case "none":
+		return nil, fmt.Errorf("attestation device at %s does not support remote attestation", path)
+	default:
+		return nil, fmt.Errorf("unsupported attestation type '%s' of attestation device at %s", t, path)
+	}
+
+	d := &device{path: path, attestationType: attestationType}
+	return &types.Issuer{
+		Type:  attestationType,
+		Issue: d.issue,
+	}, nil
+}
+
+type device struct {
+	path            string
+	attestationType string
+}
+
+// issue binds the custom data to a new quote, as the Intel SGX SDK based enclaves do: the report data is the hash of
+// the custom data, followed by zeros
+func (d *device) issue(customData []byte) ([]byte, error) {
+	reportData := make([]byte, userReportDataSize)
+	hash := sha256.Sum256(customData)
+	copy(reportData, hash[:])
+
+	if err := os.WriteFile(filepath.Join(d.path, userReportDataFile), reportData, 0600); err != nil {
+		return nil, errors.Wrap(err, "cannot write report data to attestation device")
+	}
+
+	quote, err := os.ReadFile(filepath.Join(d.path, quoteFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read quote from attestation device")
+	}
+	if len(quote) == 0 {
+		return nil, fmt.Errorf("empty quote from attestation device")
+	}
+
+	return json.Marshal(&types.Attestation{
+		Type: d.attestationType,
+		Data: base64.StdEncoding.EncodeToString(quote),
+	})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package device
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeDevice creates an attestation device directory that returns the given quote
+func newFakeDevice(t *testing.T, attestationType string, quote []byte) string {
+	path := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(path, attestationTypeFile), []byte(attestationType), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(path, userReportDataFile), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(path, quoteFile), quote, 0644))
+	return path
+}
+
+func TestDeviceIssuer(t *testing.T) {
+	quote := []byte("some quote")
+	path := newFakeDevice(t, "dcap\n", quote)
+
+	issuer, err := NewDeviceIssuer(path, epid.UnlinkableType)
+	require.NoError(t, err)
+	assert.Equal(t, dcap.DcapType, issuer.Type)
+
+	attestationBytes, err := issuer.Issue([]byte("some statement"))
+	require.NoError(t, err)
+
+	att := &types.Attestation{}
+	require.NoError(t, json.Unmarshal(attestationBytes, att))
+	assert.Equal(t, dcap.DcapType, att.Type)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(quote), att.Data)
+
+	// the report data is the hash of the statement, followed by zeros
+	reportData, err := os.ReadFile(filepath.Join(path, userReportDataFile))
+	require.NoError(t, err)
+	hash := sha256.Sum256([]byte("some statement"))
+	assert.Equal(t, append(hash[:], make([]byte, 32)...), reportData)
+
+	require.NoError(t, os.WriteFile(filepath.Join(path, quoteFile), nil, 0644))
+	_, err = issuer.Issue([]byte("some statement"))
+	assert.EqualError(t, err, "empty quote from attestation device")
+
+	require.NoError(t, os.Remove(filepath.Join(path, quoteFile)))
+	_, err = issuer.Issue([]byte("some statement"))
+	assert.ErrorContains(t, err, "cannot read quote from attestation device")
+}
+
+func TestDeviceIssuerTypes(t *testing.T) {
+	issuer, err := NewDeviceIssuer(newFakeDevice(t, "epid", []byte("quote")), epid.LinkableType)
+	require.NoError(t, err)
+	assert.Equal(t, epid.LinkableType, issuer.Type)
+
+	issuer, err = NewDeviceIssuer(newFakeDevice(t, "epid", []byte("quote")), epid.UnlinkableType)
+	require.NoError(t, err)
+	assert.Equal(t, epid.UnlinkableType, issuer.Type)
+
+	_, err = NewDeviceIssuer(newFakeDevice(t, "epid", []byte("quote")), "simulated")
+	assert.EqualError(t, err, "invalid EPID attestation type 'simulated'")
+
+	path := newFakeDevice(t, "none", nil)
+	_, err = NewDeviceIssuer(path, epid.UnlinkableType)
+	assert.EqualError(t, err, "attestation device at "+path+" does not support remote attestation")
+
+	_, err = NewDeviceIssuer(newFakeDevice(t, "tdx", nil), epid.UnlinkableType)
+	assert.ErrorContains(t, err, "unsupported attestation type 'tdx'")
+
+	_, err = NewDeviceIssuer(filepath.Join(t.TempDir(), "missing"), epid.UnlinkableType)
+	assert.ErrorContains(t, err, "cannot read attestation device")
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv(PathEnv, "")
+	assert.Equal(t, DefaultPath, Path())
+
+	t.Setenv(PathEnv, "/some/device")
+	assert.Equal(t, "/some/device", Path())
+}
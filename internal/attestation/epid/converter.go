@@ -41,7 +41,12 @@ func newEpidConverter() types.ConvertFunction {
 			return nil, errors.Wrap(err, "cannot load IAS API key")
 		}
 
-		ias := NewIASClient(apiKey)
+		opts, err := iasClientOptionsFromEnv()
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid IAS client configuration")
+		}
+
+		ias := NewIASClient(apiKey, opts...)
 		evidence, err := ias.RequestAttestationReport(string(attestationBytes))
 		if err != nil {
 			return nil, errors.Wrap(err, "cannot convert epid attestation")
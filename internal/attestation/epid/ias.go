@@ -8,14 +8,21 @@ package epid
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/pkg/errors"
 )
 
+var logger = flogging.MustGetLogger("fpc.attestation.epid")
+
 const DefaultIASUrl = "https://api.trustedservices.intel.com/sgx/dev/attestation/v4/report"
 
 type IntelAttestationService interface {
@@ -58,10 +65,39 @@ type IASClient struct {
 	url        string
 	apiKey     string
 	httpClient HTTPClient
+
+	timeout time.Duration
+	retry   RetryPolicy
+	proxy   *url.URL
+	rootCAs *x509.CertPool
+	cache   *reportCache
+
+	now   func() time.Time
+	sleep func(time.Duration)
 }
 
 type IASClientOption func(*IASClient)
 
+// RetryPolicy defines how the IASClient retries requests that failed due to network errors or temporary IAS errors
+// (see IASError.Temporary). The backoff between two attempts starts with InitialBackoff and doubles with every
+// retry, up to MaxBackoff; if IAS asks to retry later (Retry-After), the IASClient waits at least that long.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries after the first attempt; 0 disables retries
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Budget is the maximum total time of a request, including all attempts and backoffs; 0 means no limit
+	Budget time.Duration
+}
+
+// DefaultRetryPolicy retries a request up to 3 times within 2 minutes
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+	Budget:         2 * time.Minute,
+}
+
 // WithUrl option allows to override the default IAS endpoint (DefaultIASUrl)
 func WithUrl(url string) IASClientOption {
 	return func(c *IASClient) {
@@ -70,12 +106,51 @@ func WithUrl(url string) IASClientOption {
 }
 
 // WithHttpClient option allows to use a custom http client. Mainly used for testing
+// Note that WithProxy and WithRootCAs only apply to the default http client.
 func WithHttpClient(client HTTPClient) IASClientOption {
 	return func(c *IASClient) {
 		c.httpClient = client
 	}
 }
 
+// WithTimeout option limits the duration of each attempt to reach IAS
+func WithTimeout(timeout time.Duration) IASClientOption {
+	return func(c *IASClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy option enables retries of failed requests
+func WithRetryPolicy(retry RetryPolicy) IASClientOption {
+	return func(c *IASClient) {
+		c.retry = retry
+	}
+}
+
+// WithProxy option sends requests through the given HTTP proxy instead of the proxy defined by the environment
+// (HTTPS_PROXY and NO_PROXY)
+func WithProxy(proxy *url.URL) IASClientOption {
+	return func(c *IASClient) {
+		c.proxy = proxy
+	}
+}
+
+// WithRootCAs option verifies the IAS TLS certificate (or the certificate of a TLS-intercepting proxy) against the
+// given CAs instead of the system CAs, see LoadCABundle
+func WithRootCAs(rootCAs *x509.CertPool) IASClientOption {
+	return func(c *IASClient) {
+		c.rootCAs = rootCAs
+	}
+}
+
+// WithCache option stores the reports in the given directory and returns the stored report when the same quote is
+// submitted again, instead of requesting a new report
+func WithCache(dir string) IASClientOption {
+	return func(c *IASClient) {
+		c.cache = &reportCache{dir: dir}
+	}
+}
+
 // NewIASClient returns a new IASClient instance using DefaultIASUrl as IAS endpoint
 // This method requires an API Key as input in order to authenticate with the IAS.
 // Optionally, IASClientOption can be provided to change the behavior of the IASClient.
@@ -83,6 +158,8 @@ func NewIASClient(apiKey string, opts ...IASClientOption) *IASClient {
 	client := &IASClient{
 		url:    DefaultIASUrl,
 		apiKey: apiKey,
+		now:    time.Now,
+		sleep:  time.Sleep,
 	}
 
 	// apply options
@@ -92,7 +169,14 @@ func NewIASClient(apiKey string, opts ...IASClientOption) *IASClient {
 
 	// create default http client if not provided via options
 	if client.httpClient == nil {
-		client.httpClient = &http.Client{}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if client.proxy != nil {
+			transport.Proxy = http.ProxyURL(client.proxy)
+		}
+		if client.rootCAs != nil {
+			transport.TLSClientConfig = &tls.Config{RootCAs: client.rootCAs}
+		}
+		client.httpClient = &http.Client{Transport: transport}
 	}
 
 	return client
@@ -103,12 +187,18 @@ func NewIASClient(apiKey string, opts ...IASClientOption) *IASClient {
 // The report returned by the attestation service is packaged as a IASReport and serialized as json string.
 func (i *IASClient) RequestAttestationReport(quoteBase64 string) (reportJson string, err error) {
 
+	if i.cache != nil {
+		if reportJson, ok := i.cache.get(quoteBase64); ok {
+			return reportJson, nil
+		}
+	}
+
 	// build request
 	request := &IASRequest{
 		Quote: quoteBase64,
 	}
 
-	report, err := i.requestAttestationReport(request)
+	report, err := i.requestAttestationReportWithRetry(request)
 	if err != nil {
 		return "", errors.Wrap(err, "")
 	}
@@ -119,9 +209,48 @@ func (i *IASClient) RequestAttestationReport(quoteBase64 string) (reportJson str
 	}
 	reportJson = string(serializedReport)
 
+	if i.cache != nil {
+		i.cache.put(quoteBase64, reportJson)
+	}
+
 	return reportJson, nil
 }
 
+func (i *IASClient) requestAttestationReportWithRetry(request *IASRequest) (*IASReport, error) {
+	start := i.now()
+	backoff := i.retry.InitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		report, err := i.requestAttestationReport(request)
+		if err == nil || !retryable(err) {
+			return report, err
+		}
+		if attempt >= i.retry.MaxRetries {
+			if attempt > 0 {
+				return nil, errors.Wrapf(err, "giving up after %d attempts", attempt+1)
+			}
+			return nil, err
+		}
+
+		wait := backoff
+		var iasErr *IASError
+		if errors.As(err, &iasErr) && iasErr.RetryAfter > wait {
+			wait = iasErr.RetryAfter
+		}
+		if i.retry.Budget > 0 && i.now().Sub(start)+wait > i.retry.Budget {
+			return nil, errors.Wrapf(err, "retry budget of %s exhausted after %d attempts", i.retry.Budget, attempt+1)
+		}
+
+		logger.Warningf("IAS request failed (attempt %d of %d), retrying in %s: %s", attempt+1, i.retry.MaxRetries+1, wait, err)
+		i.sleep(wait)
+
+		backoff *= 2
+		if i.retry.MaxBackoff > 0 && backoff > i.retry.MaxBackoff {
+			backoff = i.retry.MaxBackoff
+		}
+	}
+}
+
 func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASReport, err error) {
 
 	requestJson, err := json.Marshal(request)
@@ -129,8 +258,15 @@ func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASRe
 		return nil, errors.Wrap(err, "cannot perform http request")
 	}
 
+	ctx := context.Background()
+	if i.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.timeout)
+		defer cancel()
+	}
+
 	// call IAS
-	req, err := http.NewRequest("POST", i.url, bytes.NewReader(requestJson))
+	req, err := http.NewRequestWithContext(ctx, "POST", i.url, bytes.NewReader(requestJson))
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create http request")
 	}
@@ -140,7 +276,7 @@ func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASRe
 
 	resp, err := i.httpClient.Do(req)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot perform http request")
+		return nil, &networkError{errors.Wrap(err, "cannot perform http request")}
 	}
 	defer resp.Body.Close()
 
@@ -148,7 +284,7 @@ func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASRe
 
 	// check response status code
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("request failed! Reason: %d %s. Request ID: %s", resp.StatusCode, resp.Status, reportRequestId)
+		return nil, newIASError(resp.StatusCode, resp.Status, reportRequestId, resp.Header.Get("Retry-After"), i.now())
 	}
 
 	// get header
@@ -157,6 +293,9 @@ func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASRe
 
 	// get the response body
 	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &networkError{errors.Wrap(err, "cannot read http response")}
+	}
 
 	report = &IASReport{
 		Signature:    reportSignature,
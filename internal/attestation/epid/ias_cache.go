@@ -0,0 +1,63 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package epid
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// reportCache stores IAS reports (serialized IASReport) in a directory, one file per quote named by the quote hash.
+// Cache failures are logged, but do not fail the report request.
+type reportCache struct {
+	dir string
+}
+
+func (c *reportCache) path(quoteBase64 string) string {
+	hash := sha256.Sum256([]byte(quoteBase64))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+func (c *reportCache) get(quoteBase64 string) (string, bool) {
+	data, err := os.ReadFile(c.path(quoteBase64))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warningf("cannot read cached IAS report: %s", err)
+		}
+		return "", false
+	}
+	logger.Debugf("using cached IAS report %s", c.path(quoteBase64))
+	return string(data), true
+}
+
+func (c *reportCache) put(quoteBase64, reportJson string) {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		logger.Warningf("cannot create IAS report cache: %s", err)
+		return
+	}
+
+	// write to a temporary file first, so that concurrent readers never see a partial report
+	tmp, err := os.CreateTemp(c.dir, ".report-*")
+	if err != nil {
+		logger.Warningf("cannot cache IAS report: %s", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(reportJson)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(quoteBase64))
+	}
+	if err != nil {
+		logger.Warningf("cannot cache IAS report: %s", err)
+	}
+}
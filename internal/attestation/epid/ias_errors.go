@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package epid
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// IASError is returned if IAS rejects a report request. Use errors.Is to check for a particular status code, e.g.,
+// errors.Is(err, ErrInvalidQuote).
+type IASError struct {
+	StatusCode int
+	Status     string
+	RequestID  string
+	// RetryAfter is the time after which IAS asks to retry the request (Retry-After), if any
+	RetryAfter time.Duration
+}
+
+// Errors for the status codes returned by IAS, see the IAS API specification
+var (
+	ErrInvalidQuote       = &IASError{StatusCode: http.StatusBadRequest}
+	ErrUnauthorized       = &IASError{StatusCode: http.StatusUnauthorized}
+	ErrNotFound           = &IASError{StatusCode: http.StatusNotFound}
+	ErrTooManyRequests    = &IASError{StatusCode: http.StatusTooManyRequests}
+	ErrInternalError      = &IASError{StatusCode: http.StatusInternalServerError}
+	ErrServiceUnavailable = &IASError{StatusCode: http.StatusServiceUnavailable}
+)
+
+func newIASError(statusCode int, status, requestID, retryAfter string, now time.Time) *IASError {
+	e := &IASError{StatusCode: statusCode, Status: status, RequestID: requestID}
+	// Retry-After is either a number of seconds or an HTTP date
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(retryAfter); err == nil && date.After(now) {
+		e.RetryAfter = date.Sub(now)
+	}
+	return e
+}
+
+func (e *IASError) Error() string {
+	status := e.Status
+	if len(status) == 0 {
+		status = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("request failed! Reason: %s. Request ID: %s", status, e.RequestID)
+}
+
+// Is reports whether target is an IASError with the same status code
+func (e *IASError) Is(target error) bool {
+	t, ok := target.(*IASError)
+	return ok && t.StatusCode == e.StatusCode
+}
+
+// Temporary reports whether the request may succeed if retried later
+func (e *IASError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// networkError is returned if IAS cannot be reached or the response cannot be read; such requests are retried
+type networkError struct {
+	error
+}
+
+func (e *networkError) Unwrap() error {
+	return e.error
+}
+
+func retryable(err error) bool {
+	var netErr *networkError
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var iasErr *IASError
+	return errors.As(err, &iasErr) && iasErr.Temporary()
+}
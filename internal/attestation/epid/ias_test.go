@@ -9,10 +9,16 @@ package epid
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/fakes"
 	"github.com/stretchr/testify/assert"
@@ -71,3 +77,194 @@ func TestIAS(t *testing.T) {
 
 	assert.EqualValues(t, expectedReport, report)
 }
+
+func iasResponse(statusCode int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func newTestIASClient(fakeHttpClient *fakes.HTTPClient, opts ...IASClientOption) (*IASClient, *[]time.Duration) {
+	var sleeps []time.Duration
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	client := NewIASClient("some_key", append([]IASClientOption{WithHttpClient(fakeHttpClient)}, opts...)...)
+	client.now = func() time.Time { return now }
+	client.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+	return client, &sleeps
+}
+
+func TestIASErrors(t *testing.T) {
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoReturns(iasResponse(400, http.Header{"Request-Id": []string{"some id"}}, ""), nil)
+
+	client, sleeps := newTestIASClient(fakeHttpClient, WithRetryPolicy(DefaultRetryPolicy))
+	_, err := client.RequestAttestationReport("quote")
+	assert.True(t, errors.Is(err, ErrInvalidQuote))
+	assert.False(t, errors.Is(err, ErrUnauthorized))
+	assert.EqualError(t, err, ": request failed! Reason: 400 Bad Request. Request ID: some id")
+
+	var iasErr *IASError
+	assert.True(t, errors.As(err, &iasErr))
+	assert.Equal(t, "some id", iasErr.RequestID)
+
+	// invalid quotes are not retried
+	assert.Equal(t, 1, fakeHttpClient.DoCallCount())
+	assert.Empty(t, *sleeps)
+
+	fakeHttpClient.DoReturns(iasResponse(401, nil, ""), nil)
+	_, err = client.RequestAttestationReport("quote")
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+	assert.False(t, iasErr.Temporary())
+	assert.True(t, ErrServiceUnavailable.Temporary())
+}
+
+func TestIASRetry(t *testing.T) {
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoReturnsOnCall(0, iasResponse(503, nil, ""), nil)
+	fakeHttpClient.DoReturnsOnCall(1, nil, fmt.Errorf("connection reset"))
+	fakeHttpClient.DoReturnsOnCall(2, iasResponse(503, http.Header{"Retry-After": []string{"10"}}, ""), nil)
+	fakeHttpClient.DoReturnsOnCall(3, iasResponse(200, nil, "some body"), nil)
+
+	client, sleeps := newTestIASClient(fakeHttpClient, WithRetryPolicy(RetryPolicy{
+		MaxRetries:     3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     3 * time.Second,
+	}))
+	reportJson, err := client.RequestAttestationReport("quote")
+	assert.NoError(t, err)
+	assert.Contains(t, reportJson, "some body")
+	assert.Equal(t, 4, fakeHttpClient.DoCallCount())
+	// exponential backoff, unless IAS asks to wait longer
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 10 * time.Second}, *sleeps)
+
+	// out of retries
+	fakeHttpClient = &fakes.HTTPClient{}
+	fakeHttpClient.DoReturns(iasResponse(500, nil, ""), nil)
+	client, sleeps = newTestIASClient(fakeHttpClient, WithRetryPolicy(RetryPolicy{
+		MaxRetries:     3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     3 * time.Second,
+	}))
+	_, err = client.RequestAttestationReport("quote")
+	assert.True(t, errors.Is(err, ErrInternalError))
+	assert.ErrorContains(t, err, "giving up after 4 attempts")
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, *sleeps)
+
+	// out of budget
+	fakeHttpClient = &fakes.HTTPClient{}
+	fakeHttpClient.DoReturns(nil, fmt.Errorf("connection refused"))
+	client, sleeps = newTestIASClient(fakeHttpClient, WithRetryPolicy(RetryPolicy{
+		MaxRetries:     10,
+		InitialBackoff: time.Second,
+		Budget:         5 * time.Second,
+	}))
+	_, err = client.RequestAttestationReport("quote")
+	assert.ErrorContains(t, err, "retry budget of 5s exhausted after 3 attempts")
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *sleeps)
+
+	// no retries by default
+	fakeHttpClient = &fakes.HTTPClient{}
+	fakeHttpClient.DoReturns(iasResponse(503, nil, ""), nil)
+	client, _ = newTestIASClient(fakeHttpClient)
+	_, err = client.RequestAttestationReport("quote")
+	assert.True(t, errors.Is(err, ErrServiceUnavailable))
+	assert.Equal(t, 1, fakeHttpClient.DoCallCount())
+}
+
+func TestIASTimeout(t *testing.T) {
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoReturns(iasResponse(200, nil, "some body"), nil)
+
+	client, _ := newTestIASClient(fakeHttpClient, WithTimeout(time.Minute))
+	_, err := client.RequestAttestationReport("quote")
+	assert.NoError(t, err)
+	_, ok := fakeHttpClient.DoArgsForCall(0).Context().Deadline()
+	assert.True(t, ok)
+
+	client, _ = newTestIASClient(fakeHttpClient)
+	_, err = client.RequestAttestationReport("quote")
+	assert.NoError(t, err)
+	_, ok = fakeHttpClient.DoArgsForCall(1).Context().Deadline()
+	assert.False(t, ok)
+}
+
+func TestIASCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoReturnsOnCall(0, iasResponse(200, nil, "some body"), nil)
+	fakeHttpClient.DoReturnsOnCall(1, iasResponse(200, nil, "another body"), nil)
+
+	client, _ := newTestIASClient(fakeHttpClient, WithCache(dir))
+	report1, err := client.RequestAttestationReport("quote")
+	assert.NoError(t, err)
+
+	// the same quote is served from the cache
+	report2, err := client.RequestAttestationReport("quote")
+	assert.NoError(t, err)
+	assert.Equal(t, report1, report2)
+	assert.Equal(t, 1, fakeHttpClient.DoCallCount())
+
+	// another quote is not
+	report3, err := client.RequestAttestationReport("another quote")
+	assert.NoError(t, err)
+	assert.Contains(t, report3, "another body")
+	assert.Equal(t, 2, fakeHttpClient.DoCallCount())
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	// failed requests are not cached
+	fakeHttpClient.DoReturns(iasResponse(400, nil, ""), nil)
+	_, err = client.RequestAttestationReport("invalid quote")
+	assert.Error(t, err)
+	files, err = os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestIASClientOptionsFromEnv(t *testing.T) {
+	caBundle := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caBundle, newTestIAS(t).rootPem, 0644))
+
+	t.Setenv(IASTimeoutEnv, "10s")
+	t.Setenv(IASMaxRetriesEnv, "5")
+	t.Setenv(IASRetryBudgetEnv, "1m")
+	t.Setenv(IASProxyEnv, "http://proxy.example.com:3128")
+	t.Setenv(IASCABundleEnv, caBundle)
+	t.Setenv(IASCachePathEnv, t.TempDir())
+
+	opts, err := iasClientOptionsFromEnv()
+	assert.NoError(t, err)
+	client := NewIASClient("some_key", opts...)
+	assert.Equal(t, 10*time.Second, client.timeout)
+	assert.Equal(t, 5, client.retry.MaxRetries)
+	assert.Equal(t, time.Minute, client.retry.Budget)
+	assert.NotNil(t, client.cache)
+
+	transport := client.httpClient.(*http.Client).Transport.(*http.Transport)
+	proxy, err := transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "api.trustedservices.intel.com"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "proxy.example.com:3128", proxy.Host)
+	assert.NotNil(t, transport.TLSClientConfig.RootCAs)
+
+	t.Setenv(IASTimeoutEnv, "soon")
+	_, err = iasClientOptionsFromEnv()
+	assert.ErrorContains(t, err, "invalid $IAS_TIMEOUT")
+
+	t.Setenv(IASTimeoutEnv, "")
+	t.Setenv(IASCABundleEnv, filepath.Join(t.TempDir(), "missing.pem"))
+	_, err = iasClientOptionsFromEnv()
+	assert.ErrorContains(t, err, "invalid $IAS_CA_BUNDLE")
+}
@@ -58,16 +58,25 @@ func (v *VerifierImpl) VerifyEvidence(evidenceBytes []byte, expectedStatementByt
 	return nil
 }
 
-func Verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) error {
+func Verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (*types.Result, error) {
 
 	// note that the PDO-based verifier implementation requires the "entire" evidence as json
 	evidenceBytes, err := json.Marshal(evidence)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	verifier := &VerifierImpl{}
-	return verifier.VerifyEvidence(evidenceBytes, expectedValidationValues.Statement, expectedValidationValues.Mrenclave)
+	if err := verifier.VerifyEvidence(evidenceBytes, expectedValidationValues.Statement, expectedValidationValues.Mrenclave); err != nil {
+		return nil, err
+	}
+
+	// the report is verified, so we can take the result from its body
+	_, body, err := epid.UnmarshalIASReport(evidence.Data)
+	if err != nil {
+		return nil, err
+	}
+	return body.Result()
 }
 
 func NewEpidLinkableVerifier() *types.Verifier {
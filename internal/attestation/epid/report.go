@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package epid
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// iasTimestampLayout is the format of the IAS report timestamp, which is in UTC
+	iasTimestampLayout = "2006-01-02T15:04:05.999999999"
+
+	// the quote body in the IAS report is an sgx_quote_t without signature; the report body follows the 48 byte
+	// quote header
+	quoteBodySize    = 432
+	reportBodyOffset = 48
+	attributesOffset = reportBodyOffset + 48
+	sgxFlagsDebug    = 0x2
+)
+
+// UnmarshalIASReport returns the IAS report and its parsed body from the evidence of an EPID attestation. Note that
+// this does not verify the report.
+func UnmarshalIASReport(evidenceData string) (*IASReport, *IASResponseBody, error) {
+	report := &IASReport{}
+	if err := json.Unmarshal([]byte(evidenceData), report); err != nil {
+		return nil, nil, errors.Wrap(err, "cannot unmarshal IAS report")
+	}
+	body := &IASResponseBody{}
+	if err := json.Unmarshal([]byte(report.Body), body); err != nil {
+		return nil, nil, errors.Wrap(err, "cannot unmarshal IAS report body")
+	}
+	return report, body, nil
+}
+
+// QuoteBody returns the decoded enclave quote (without signature) of the IAS report body
+func (b *IASResponseBody) QuoteBody() ([]byte, error) {
+	quoteBody, err := base64.StdEncoding.DecodeString(b.IsvEnclaveQuoteBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode quote body")
+	}
+	if len(quoteBody) != quoteBodySize {
+		return nil, fmt.Errorf("unexpected quote body size %d", len(quoteBody))
+	}
+	return quoteBody, nil
+}
+
+// Result returns the verification result described by the IAS report body
+func (b *IASResponseBody) Result() (*types.Result, error) {
+	quoteBody, err := b.QuoteBody()
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := time.Parse(iasTimestampLayout, b.Timestamp)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid IAS report timestamp")
+	}
+
+	flags := binary.LittleEndian.Uint64(quoteBody[attributesOffset : attributesOffset+8])
+	return &types.Result{
+		Status:      b.IsvEnclaveQuoteStatus,
+		AdvisoryIDs: b.AdvisoryIDs,
+		Timestamp:   timestamp,
+		Debug:       flags&sgxFlagsDebug != 0,
+	}, nil
+}
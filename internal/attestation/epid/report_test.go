@@ -0,0 +1,61 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package epid
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIASReportResult(t *testing.T) {
+	quoteBody := make([]byte, quoteBodySize)
+	quoteBody[attributesOffset] = sgxFlagsDebug
+
+	body := &IASResponseBody{
+		Timestamp:             "2024-05-01T12:30:00.123456",
+		IsvEnclaveQuoteStatus: "SW_HARDENING_NEEDED",
+		IsvEnclaveQuoteBody:   base64.StdEncoding.EncodeToString(quoteBody),
+		AdvisoryIDs:           []string{"INTEL-SA-00334"},
+	}
+	bodyBytes, err := json.Marshal(body)
+	assert.NoError(t, err)
+	reportBytes, err := json.Marshal(&IASReport{Body: string(bodyBytes)})
+	assert.NoError(t, err)
+
+	_, parsed, err := UnmarshalIASReport(string(reportBytes))
+	assert.NoError(t, err)
+
+	result, err := parsed.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "SW_HARDENING_NEEDED", result.Status)
+	assert.Equal(t, []string{"INTEL-SA-00334"}, result.AdvisoryIDs)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC), result.Timestamp)
+	assert.True(t, result.Debug)
+
+	// production enclave
+	quoteBody[attributesOffset] = 0
+	parsed.IsvEnclaveQuoteBody = base64.StdEncoding.EncodeToString(quoteBody)
+	result, err = parsed.Result()
+	assert.NoError(t, err)
+	assert.False(t, result.Debug)
+
+	parsed.IsvEnclaveQuoteBody = base64.StdEncoding.EncodeToString(quoteBody[:100])
+	_, err = parsed.Result()
+	assert.EqualError(t, err, "unexpected quote body size 100")
+
+	parsed.IsvEnclaveQuoteBody = base64.StdEncoding.EncodeToString(quoteBody)
+	parsed.Timestamp = "yesterday"
+	_, err = parsed.Result()
+	assert.Error(t, err)
+
+	_, _, err = UnmarshalIASReport("not json")
+	assert.Error(t, err)
+}
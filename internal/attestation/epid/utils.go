@@ -8,14 +8,41 @@ SPDX-License-Identifier: Apache-2.0
 package epid
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	// RootCAEnv defines the PEM file with the IAS root CA certificate used by the verifier
+	RootCAEnv = "IAS_ROOT_CA"
+
+	rootCAFile = "Intel_SGX_Attestation_RootCA.pem"
+
+	// IASTimeoutEnv defines the timeout of each IAS request attempt, e.g., "30s"
+	IASTimeoutEnv = "IAS_TIMEOUT"
+	// IASMaxRetriesEnv defines the maximum number of retries of a failed IAS request
+	IASMaxRetriesEnv = "IAS_MAX_RETRIES"
+	// IASRetryBudgetEnv defines the maximum total time of an IAS request including retries, e.g., "2m"
+	IASRetryBudgetEnv = "IAS_RETRY_BUDGET"
+	// IASProxyEnv defines the HTTP proxy for IAS requests; if not set, HTTPS_PROXY is used
+	IASProxyEnv = "IAS_PROXY"
+	// IASCABundleEnv defines a PEM file with the CAs used to verify the TLS certificate of IAS (or the proxy)
+	IASCABundleEnv = "IAS_CA_BUNDLE"
+	// IASCachePathEnv defines a directory to cache IAS reports in
+	IASCachePathEnv = "IAS_CACHE_PATH"
+
+	defaultIASTimeout = 30 * time.Second
+)
+
 // loadApiKey tries to load the IAS API Key from environment variable.
 // If env var not set, use loadApiKeyFromCredentialsEnvPath and then loadApiKeyFromFPCConfig as fallback
 func loadApiKey() (string, error) {
@@ -70,3 +97,91 @@ func loadApiKeyFromPath(path string) (string, error) {
 
 	return strings.TrimSuffix(string(data), "\n"), nil
 }
+
+// loadRootCA loads the IAS root CA certificate from $IAS_ROOT_CA, or from
+// $FPC_PATH/config/ias/Intel_SGX_Attestation_RootCA.pem as fallback
+func loadRootCA() (*x509.Certificate, error) {
+	path := os.Getenv(RootCAEnv)
+	if len(path) == 0 {
+		fpcPath := os.Getenv("FPC_PATH")
+		if len(fpcPath) == 0 {
+			return nil, fmt.Errorf("neither $%s nor $FPC_PATH set", RootCAEnv)
+		}
+		path = filepath.Join(fpcPath, "config", "ias", rootCAFile)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s", path)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("no PEM certificate in %s", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid root CA certificate in %s", path)
+	}
+	return cert, nil
+}
+
+// LoadCABundle loads the CA certificates in a PEM file, e.g., to use with WithRootCAs
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s", path)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.Errorf("no PEM certificates in %s", path)
+	}
+	return pool, nil
+}
+
+// iasClientOptionsFromEnv returns the IASClient options defined by the IAS_* environment variables. By default,
+// requests time out after 30s and are retried according to DefaultRetryPolicy.
+func iasClientOptionsFromEnv() ([]IASClientOption, error) {
+	timeout := defaultIASTimeout
+	retry := DefaultRetryPolicy
+
+	if v := os.Getenv(IASTimeoutEnv); len(v) > 0 {
+		var err error
+		if timeout, err = time.ParseDuration(v); err != nil {
+			return nil, errors.Wrapf(err, "invalid $%s", IASTimeoutEnv)
+		}
+	}
+	if v := os.Getenv(IASMaxRetriesEnv); len(v) > 0 {
+		var err error
+		if retry.MaxRetries, err = strconv.Atoi(v); err != nil {
+			return nil, errors.Wrapf(err, "invalid $%s", IASMaxRetriesEnv)
+		}
+	}
+	if v := os.Getenv(IASRetryBudgetEnv); len(v) > 0 {
+		var err error
+		if retry.Budget, err = time.ParseDuration(v); err != nil {
+			return nil, errors.Wrapf(err, "invalid $%s", IASRetryBudgetEnv)
+		}
+	}
+
+	opts := []IASClientOption{WithTimeout(timeout), WithRetryPolicy(retry)}
+
+	if v := os.Getenv(IASProxyEnv); len(v) > 0 {
+		proxy, err := url.Parse(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid $%s", IASProxyEnv)
+		}
+		opts = append(opts, WithProxy(proxy))
+	}
+	if v := os.Getenv(IASCABundleEnv); len(v) > 0 {
+		rootCAs, err := LoadCABundle(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid $%s", IASCABundleEnv)
+		}
+		opts = append(opts, WithRootCAs(rootCAs))
+	}
+	if v := os.Getenv(IASCachePathEnv); len(v) > 0 {
+		opts = append(opts, WithCache(v))
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package epid
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// offsets of mrenclave and report data in the quote body (see sgx_report_body_t)
+	mrEnclaveOffset  = reportBodyOffset + 64
+	mrEnclaveSize    = 32
+	reportDataOffset = reportBodyOffset + 320
+	reportDataSize   = 64
+)
+
+type verifier struct {
+	rootCA func() (*x509.Certificate, error)
+	now    func() time.Time
+}
+
+// NewEpidLinkableVerifier creates a new verifier for Intel SGX EPID (linkable) attestation. In contrast to the
+// verifier in the pdo package, it is implemented in Go and does not require cgo.
+func NewEpidLinkableVerifier() *types.Verifier {
+	return newEpidVerifier(LinkableType)
+}
+
+// NewEpidUnlinkableVerifier creates a new verifier for Intel SGX EPID (unlinkable) attestation. In contrast to the
+// verifier in the pdo package, it is implemented in Go and does not require cgo.
+func NewEpidUnlinkableVerifier() *types.Verifier {
+	return newEpidVerifier(UnlinkableType)
+}
+
+func newEpidVerifier(attestationType string) *types.Verifier {
+	v := &verifier{rootCA: loadRootCA, now: time.Now}
+	return &types.Verifier{
+		Type:   attestationType,
+		Verify: v.verify,
+	}
+}
+
+func (v *verifier) verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (*types.Result, error) {
+	report := &IASReport{}
+	if err := json.Unmarshal([]byte(evidence.Data), report); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal IAS report")
+	}
+
+	root, err := v.rootCA()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot load IAS root CA")
+	}
+
+	now := expectedValidationValues.Time
+	if now.IsZero() {
+		now = v.now()
+	}
+	body, err := VerifyIASReport(report, root, now)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteBody, err := body.QuoteBody()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkQuoteBody(quoteBody, expectedValidationValues); err != nil {
+		return nil, err
+	}
+
+	return body.Result()
+}
+
+// VerifyIASReport verifies the signing certificate chain of an IAS report against the IAS root CA at the given
+// time and the report signature, and returns the parsed report body
+func VerifyIASReport(report *IASReport, root *x509.Certificate, now time.Time) (*IASResponseBody, error) {
+	certs, err := parseIASCertificates(report.Certificates)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, errors.Wrap(err, "invalid IAS signing certificate")
+	}
+
+	signingKey, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("IAS signing certificate has no RSA key")
+	}
+	signature, err := base64.StdEncoding.DecodeString(report.Signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode IAS report signature")
+	}
+	digest := sha256.Sum256([]byte(report.Body))
+	if err := rsa.VerifyPKCS1v15(signingKey, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, errors.Wrap(err, "invalid IAS report signature")
+	}
+
+	body := &IASResponseBody{}
+	if err := json.Unmarshal([]byte(report.Body), body); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal IAS report body")
+	}
+	return body, nil
+}
+
+// parseIASCertificates parses the URL-encoded PEM certificate chain of the X-IASReport-Signing-Certificate header,
+// starting with the signing certificate
+func parseIASCertificates(encoded string) ([]*x509.Certificate, error) {
+	decoded, err := url.PathUnescape(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode IAS certificates")
+	}
+
+	var certs []*x509.Certificate
+	rest := []byte(decoded)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid IAS certificate")
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no IAS certificates")
+	}
+	return certs, nil
+}
+
+// checkQuoteBody compares mrenclave and report data of the quote body with the expected values. The report data is
+// the hash of the statement, followed by zeros.
+func checkQuoteBody(quoteBody []byte, expectedValidationValues *types.ValidationValues) error {
+	expectedMrenclave, err := hex.DecodeString(expectedValidationValues.Mrenclave)
+	if err != nil {
+		return errors.Wrap(err, "invalid expected mrenclave")
+	}
+	if !bytes.Equal(quoteBody[mrEnclaveOffset:mrEnclaveOffset+mrEnclaveSize], expectedMrenclave) {
+		return fmt.Errorf("expected mrenclave mismatch")
+	}
+
+	expectedReportData := make([]byte, reportDataSize)
+	statementHash := sha256.Sum256(expectedValidationValues.Statement)
+	copy(expectedReportData, statementHash[:])
+	if !bytes.Equal(quoteBody[reportDataOffset:reportDataOffset+reportDataSize], expectedReportData) {
+		return fmt.Errorf("expected statement mismatch")
+	}
+	return nil
+}
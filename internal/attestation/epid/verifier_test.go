@@ -0,0 +1,206 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package epid
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testNow       = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	testMrenclave = "98aed61c91f258a37c68ed4943297695647ec7bbe6008cc111b0a12650ebeb91"
+	testStatement = []byte("some statement")
+)
+
+type testIAS struct {
+	root       *x509.Certificate
+	rootPem    []byte
+	signingKey *rsa.PrivateKey
+	signingPem []byte
+}
+
+func newCert(t *testing.T, template, parent *x509.Certificate, pub *rsa.PublicKey, priv *rsa.PrivateKey) (*x509.Certificate, []byte) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newTestIAS(t *testing.T) *testIAS {
+	rootKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test SGX Attestation Report Signing CA"},
+		NotBefore:             testNow.AddDate(-1, 0, 0),
+		NotAfter:              testNow.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	root, rootPem := newCert(t, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, signingPem := newCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test SGX Attestation Report Signing"},
+		NotBefore:    testNow.AddDate(-1, 0, 0),
+		NotAfter:     testNow.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}, root, &signingKey.PublicKey, rootKey)
+
+	return &testIAS{root: root, rootPem: rootPem, signingKey: signingKey, signingPem: signingPem}
+}
+
+func newQuoteBody(mrenclave string, statement []byte) []byte {
+	quoteBody := make([]byte, quoteBodySize)
+	m, _ := hex.DecodeString(mrenclave)
+	copy(quoteBody[mrEnclaveOffset:], m)
+	h := sha256.Sum256(statement)
+	copy(quoteBody[reportDataOffset:], h[:])
+	return quoteBody
+}
+
+// report returns an IAS report as returned by the IASClient, i.e., with URL-encoded certificates
+func (i *testIAS) report(t *testing.T, quoteBody []byte) *IASReport {
+	body, err := json.Marshal(&IASResponseBody{
+		Id:                    "1",
+		Timestamp:             testNow.Add(-time.Hour).Format(iasTimestampLayout),
+		Version:               4,
+		IsvEnclaveQuoteStatus: "OK",
+		IsvEnclaveQuoteBody:   base64.StdEncoding.EncodeToString(quoteBody),
+	})
+	require.NoError(t, err)
+
+	digest := sha256.Sum256(body)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.signingKey, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return &IASReport{
+		Signature:    base64.StdEncoding.EncodeToString(signature),
+		Certificates: url.PathEscape(string(i.signingPem) + string(i.rootPem)),
+		Body:         string(body),
+	}
+}
+
+func TestVerifyIASReport(t *testing.T) {
+	ias := newTestIAS(t)
+	report := ias.report(t, newQuoteBody(testMrenclave, testStatement))
+
+	body, err := VerifyIASReport(report, ias.root, testNow)
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", body.IsvEnclaveQuoteStatus)
+
+	// signing certificate expired
+	_, err = VerifyIASReport(report, ias.root, testNow.AddDate(2, 0, 0))
+	assert.ErrorContains(t, err, "invalid IAS signing certificate")
+
+	// another root
+	_, err = VerifyIASReport(report, newTestIAS(t).root, testNow)
+	assert.ErrorContains(t, err, "invalid IAS signing certificate")
+
+	// tampered body
+	tampered := *report
+	tampered.Body = report.Body[:len(report.Body)-1] + " }"
+	_, err = VerifyIASReport(&tampered, ias.root, testNow)
+	assert.ErrorContains(t, err, "invalid IAS report signature")
+
+	tampered = *report
+	tampered.Signature = "not base64"
+	_, err = VerifyIASReport(&tampered, ias.root, testNow)
+	assert.ErrorContains(t, err, "cannot decode IAS report signature")
+
+	tampered = *report
+	tampered.Certificates = ""
+	_, err = VerifyIASReport(&tampered, ias.root, testNow)
+	assert.EqualError(t, err, "no IAS certificates")
+}
+
+func TestEpidVerifier(t *testing.T) {
+	ias := newTestIAS(t)
+	v := &verifier{
+		rootCA: func() (*x509.Certificate, error) { return ias.root, nil },
+		now:    func() time.Time { return testNow },
+	}
+
+	evidence := func(report *IASReport) *types.Evidence {
+		data, err := json.Marshal(report)
+		require.NoError(t, err)
+		return &types.Evidence{Type: LinkableType, Data: string(data)}
+	}
+	expected := &types.ValidationValues{Statement: testStatement, Mrenclave: testMrenclave}
+
+	result, err := v.verify(evidence(ias.report(t, newQuoteBody(testMrenclave, testStatement))), expected)
+	assert.NoError(t, err)
+	assert.Equal(t, &types.Result{Status: "OK", Timestamp: testNow.Add(-time.Hour)}, result)
+
+	_, err = v.verify(evidence(ias.report(t, newQuoteBody(testMrenclave, []byte("another statement")))), expected)
+	assert.EqualError(t, err, "expected statement mismatch")
+
+	otherMrenclave := "0000000000000000000000000000000000000000000000000000000000000000"
+	_, err = v.verify(evidence(ias.report(t, newQuoteBody(otherMrenclave, testStatement))), expected)
+	assert.EqualError(t, err, "expected mrenclave mismatch")
+
+	_, err = v.verify(evidence(ias.report(t, []byte("short quote"))), expected)
+	assert.EqualError(t, err, "unexpected quote body size 11")
+
+	// the verification time is the transaction timestamp, if given
+	_, err = v.verify(evidence(ias.report(t, newQuoteBody(testMrenclave, testStatement))), &types.ValidationValues{
+		Statement: testStatement,
+		Mrenclave: testMrenclave,
+		Time:      testNow.AddDate(2, 0, 0),
+	})
+	assert.ErrorContains(t, err, "invalid IAS signing certificate")
+
+	_, err = v.verify(&types.Evidence{Type: LinkableType, Data: "not json"}, expected)
+	assert.ErrorContains(t, err, "cannot unmarshal IAS report")
+}
+
+func TestLoadRootCA(t *testing.T) {
+	ias := newTestIAS(t)
+
+	path := filepath.Join(t.TempDir(), "root.pem")
+	require.NoError(t, os.WriteFile(path, ias.rootPem, 0644))
+	t.Setenv(RootCAEnv, path)
+	root, err := loadRootCA()
+	assert.NoError(t, err)
+	assert.True(t, ias.root.Equal(root))
+
+	fpcPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(fpcPath, "config", "ias"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(fpcPath, "config", "ias", rootCAFile), ias.rootPem, 0644))
+	t.Setenv(RootCAEnv, "")
+	t.Setenv("FPC_PATH", fpcPath)
+	root, err = loadRootCA()
+	assert.NoError(t, err)
+	assert.True(t, ias.root.Equal(root))
+
+	t.Setenv("FPC_PATH", "")
+	_, err = loadRootCA()
+	assert.Error(t, err)
+}
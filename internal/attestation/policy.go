@@ -0,0 +1,170 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/pkg/errors"
+)
+
+// VerificationPolicyEnv defines the JSON file with the verification policy of ERCC
+const VerificationPolicyEnv = "FPC_ATTESTATION_POLICY"
+
+// AnyAdvisory in AllowedAdvisoryIDs allows all advisories
+const AnyAdvisory = "*"
+
+// VerificationPolicy decides which platforms and enclaves are acceptable, based on the result of the evidence
+// verification. For example:
+//
+//	{
+//	  "allowed_statuses": ["OK", "SW_HARDENING_NEEDED", "UpToDate", "SWHardeningNeeded"],
+//	  "allowed_advisory_ids": ["INTEL-SA-00334", "INTEL-SA-00615"],
+//	  "max_age": "720h",
+//	  "allow_debug": false
+//	}
+type VerificationPolicy struct {
+	// AllowedStatuses are the acceptable TCB statuses, e.g., "OK" and "GROUP_OUT_OF_DATE" for EPID, "UpToDate" and
+	// "OutOfDate" for DCAP, or "SIMULATED" for simulated evidence
+	AllowedStatuses []string `json:"allowed_statuses"`
+	// AllowedAdvisoryIDs are the security advisories that may apply to an acceptable platform; "*" allows all
+	AllowedAdvisoryIDs []string `json:"allowed_advisory_ids"`
+	// MaxAge is the maximum age of the evidence status (e.g., of the IAS report) at the time of the verification,
+	// i.e., at the transaction timestamp; if zero, the age is not checked
+	MaxAge Duration `json:"max_age,omitempty"`
+	// AllowDebug allows debug enclaves, whose memory can be inspected by the host
+	AllowDebug bool `json:"allow_debug"`
+}
+
+// DefaultVerificationPolicy returns the policy used if no policy is configured. It accepts platforms that are up to
+// date, out of date or need configuration changes, regardless of advisories and evidence age, as well as debug
+// enclaves, which are built by default (SGX_BUILD=DEBUG).
+func DefaultVerificationPolicy() *VerificationPolicy {
+	return &VerificationPolicy{
+		AllowedStatuses: []string{
+			// EPID
+			"OK",
+			"GROUP_OUT_OF_DATE",
+			"CONFIGURATION_NEEDED",
+			"SW_HARDENING_NEEDED",
+			"CONFIGURATION_AND_SW_HARDENING_NEEDED",
+			// DCAP
+			dcap.TcbUpToDate,
+			dcap.TcbOutOfDate,
+			dcap.TcbConfigurationNeeded,
+			dcap.TcbSWHardeningNeeded,
+			dcap.TcbConfigurationAndSWHardeningNeeded,
+			dcap.TcbOutOfDateConfigurationNeeded,
+			simulation.SimulatedStatus,
+		},
+		AllowedAdvisoryIDs: []string{AnyAdvisory},
+		AllowDebug:         true,
+	}
+}
+
+// ClientVerificationPolicy returns the policy of NewDefaultCredentialVerifier. Unlike DefaultVerificationPolicy, it
+// rejects simulated evidence and debug enclaves, as neither protects the chaincode from the host.
+func ClientVerificationPolicy() *VerificationPolicy {
+	policy := DefaultVerificationPolicy()
+	var statuses []string
+	for _, status := range policy.AllowedStatuses {
+		if status != simulation.SimulatedStatus {
+			statuses = append(statuses, status)
+		}
+	}
+	policy.AllowedStatuses = statuses
+	policy.AllowDebug = false
+	return policy
+}
+
+// LoadVerificationPolicy reads a verification policy from a JSON file
+func LoadVerificationPolicy(path string) (*VerificationPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read verification policy")
+	}
+	policy := &VerificationPolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, errors.Wrapf(err, "invalid verification policy in %s", path)
+	}
+	return policy, nil
+}
+
+// VerificationPolicyFromEnv returns the verification policy defined by FPC_ATTESTATION_POLICY, or the default
+// verification policy if FPC_ATTESTATION_POLICY is not set
+func VerificationPolicyFromEnv() (*VerificationPolicy, error) {
+	path := os.Getenv(VerificationPolicyEnv)
+	if len(path) == 0 {
+		return DefaultVerificationPolicy(), nil
+	}
+	return LoadVerificationPolicy(path)
+}
+
+// Evaluate returns an error if the verification result is not acceptable at the given verification time
+func (p *VerificationPolicy) Evaluate(result *types.Result, verificationTime time.Time) error {
+	if !contains(p.AllowedStatuses, result.Status) {
+		return fmt.Errorf("status %s not allowed by policy", result.Status)
+	}
+	if !contains(p.AllowedAdvisoryIDs, AnyAdvisory) {
+		for _, id := range result.AdvisoryIDs {
+			if !contains(p.AllowedAdvisoryIDs, id) {
+				return fmt.Errorf("advisory %s not allowed by policy", id)
+			}
+		}
+	}
+	if result.Debug && !p.AllowDebug {
+		return fmt.Errorf("debug enclave not allowed by policy")
+	}
+	if p.MaxAge > 0 {
+		if result.Timestamp.IsZero() {
+			return fmt.Errorf("evidence has no timestamp, but policy requires max age %s", p.MaxAge)
+		}
+		if age := verificationTime.Sub(result.Timestamp); age > time.Duration(p.MaxAge) {
+			return fmt.Errorf("evidence issued at %s exceeds max age %s", result.Timestamp.Format(time.RFC3339), p.MaxAge)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration is a time.Duration that is encoded in JSON as string, e.g., "24h"
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	policy := &VerificationPolicy{
+		AllowedStatuses:    []string{"OK", "SW_HARDENING_NEEDED"},
+		AllowedAdvisoryIDs: []string{"INTEL-SA-00334"},
+		MaxAge:             Duration(24 * time.Hour),
+	}
+
+	tests := []struct {
+		name   string
+		result *types.Result
+		err    string
+	}{
+		{"ok", &types.Result{Status: "OK", Timestamp: now.Add(-time.Hour)}, ""},
+		{"allowed advisory", &types.Result{Status: "SW_HARDENING_NEEDED", AdvisoryIDs: []string{"INTEL-SA-00334"}, Timestamp: now}, ""},
+		{"status", &types.Result{Status: "GROUP_OUT_OF_DATE", Timestamp: now}, "status GROUP_OUT_OF_DATE not allowed by policy"},
+		{"advisory", &types.Result{Status: "SW_HARDENING_NEEDED", AdvisoryIDs: []string{"INTEL-SA-00334", "INTEL-SA-00615"}, Timestamp: now}, "advisory INTEL-SA-00615 not allowed by policy"},
+		{"debug", &types.Result{Status: "OK", Timestamp: now, Debug: true}, "debug enclave not allowed by policy"},
+		{"no timestamp", &types.Result{Status: "OK"}, "evidence has no timestamp, but policy requires max age 24h0m0s"},
+		{"too old", &types.Result{Status: "OK", Timestamp: now.Add(-25 * time.Hour)}, "evidence issued at 2024-04-30T11:00:00Z exceeds max age 24h0m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Evaluate(tt.result, now)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+
+	// without max age, the timestamp is not checked
+	policy.MaxAge = 0
+	assert.NoError(t, policy.Evaluate(&types.Result{Status: "OK"}, now))
+
+	// the wildcard allows all advisories
+	policy.AllowedAdvisoryIDs = []string{AnyAdvisory}
+	assert.NoError(t, policy.Evaluate(&types.Result{Status: "OK", AdvisoryIDs: []string{"INTEL-SA-00615"}}, now))
+}
+
+func TestDefaultVerificationPolicy(t *testing.T) {
+	policy := DefaultVerificationPolicy()
+
+	for _, status := range []string{"OK", "GROUP_OUT_OF_DATE", "UpToDate", "OutOfDate", simulation.SimulatedStatus} {
+		assert.NoError(t, policy.Evaluate(&types.Result{Status: status, AdvisoryIDs: []string{"INTEL-SA-00615"}, Debug: true}, time.Now()), status)
+	}
+
+	for _, status := range []string{"SIGNATURE_INVALID", "GROUP_REVOKED", "KEY_REVOKED", "Revoked", ""} {
+		assert.Error(t, policy.Evaluate(&types.Result{Status: status}, time.Now()), status)
+	}
+}
+
+func TestVerificationPolicyFromEnv(t *testing.T) {
+	t.Setenv(VerificationPolicyEnv, "")
+	policy, err := VerificationPolicyFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultVerificationPolicy(), policy)
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+		"allowed_statuses": ["OK"],
+		"allowed_advisory_ids": ["INTEL-SA-00334"],
+		"max_age": "720h",
+		"allow_debug": false
+	}`), 0644))
+
+	t.Setenv(VerificationPolicyEnv, path)
+	policy, err = VerificationPolicyFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, &VerificationPolicy{
+		AllowedStatuses:    []string{"OK"},
+		AllowedAdvisoryIDs: []string{"INTEL-SA-00334"},
+		MaxAge:             Duration(720 * time.Hour),
+	}, policy)
+
+	// round trip
+	b, err := json.Marshal(policy)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"max_age":"720h0m0s"`)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"max_age": "a month"}`), 0644))
+	_, err = VerificationPolicyFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv(VerificationPolicyEnv, filepath.Join(t.TempDir(), "missing.json"))
+	_, err = VerificationPolicyFromEnv()
+	assert.Error(t, err)
+}
+
+func TestCredentialVerifierPolicy(t *testing.T) {
+	now := time.Now()
+	var validationValues *types.ValidationValues
+	dummy := &types.Verifier{
+		Type: "dummy",
+		Verify: func(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (*types.Result, error) {
+			validationValues = expectedValidationValues
+			return &types.Result{Status: "OK", Debug: true}, nil
+		},
+	}
+
+	credentials := &protos.Credentials{
+		SerializedAttestedData: &anypb.Any{Value: []byte("statement")},
+		Evidence:               []byte(`{"attestation_type": "dummy"}`),
+	}
+
+	// default policy allows debug enclaves
+	v := NewCredentialVerifier(dummy)
+	assert.NoError(t, v.VerifyCredentials(credentials, "mrenclave", now))
+	assert.Equal(t, "mrenclave", validationValues.Mrenclave)
+	assert.Equal(t, now, validationValues.Time)
+
+	v = NewCredentialVerifierWithPolicy(&VerificationPolicy{AllowedStatuses: []string{"OK"}}, dummy)
+	assert.EqualError(t, v.VerifyCredentials(credentials, "mrenclave", now), "verification policy violated: debug enclave not allowed by policy")
+
+	// the result is returned even if the policy is violated
+	result, err := v.EvaluateCredentials(credentials, "mrenclave", now)
+	assert.EqualError(t, err, "verification policy violated: debug enclave not allowed by policy")
+	assert.Equal(t, &types.Result{Status: "OK", Debug: true}, result)
+
+	result, err = v.EvaluateCredentials(&protos.Credentials{Evidence: []byte("not json")}, "mrenclave", now)
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestClientVerificationPolicy(t *testing.T) {
+	policy := ClientVerificationPolicy()
+
+	for _, status := range []string{"OK", "GROUP_OUT_OF_DATE", "UpToDate", "OutOfDate"} {
+		assert.NoError(t, policy.Evaluate(&types.Result{Status: status, AdvisoryIDs: []string{"INTEL-SA-00615"}}, time.Now()), status)
+	}
+
+	assert.Error(t, policy.Evaluate(&types.Result{Status: simulation.SimulatedStatus}, time.Now()))
+	assert.Error(t, policy.Evaluate(&types.Result{Status: "OK", Debug: true}, time.Now()))
+}
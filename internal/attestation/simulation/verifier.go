@@ -10,12 +10,15 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 )
 
+// SimulatedStatus is the status of simulated evidence
+const SimulatedStatus = "SIMULATED"
+
 func NewSimulationVerifier() *types.Verifier {
 	return &types.Verifier{
 		Type: SimulationType,
-		Verify: func(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (err error) {
+		Verify: func(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (*types.Result, error) {
 			// NO-OP
-			return nil
+			return &types.Result{Status: SimulatedStatus}, nil
 		},
 	}
 }
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 
 package types
 
+import "time"
+
 type ConvertFunction func(attestationBytes []byte) (evidenceBytes []byte, err error)
 
 type Converter struct {
@@ -14,7 +16,7 @@ type Converter struct {
 	Converter ConvertFunction
 }
 
-type VerifyFunction func(evidence *Evidence, expectedValidationValues *ValidationValues) error
+type VerifyFunction func(evidence *Evidence, expectedValidationValues *ValidationValues) (*Result, error)
 
 type Verifier struct {
 	Type   string
@@ -41,4 +43,19 @@ type Evidence struct {
 type ValidationValues struct {
 	Statement []byte
 	Mrenclave string
+	// Time is the time of the verification, i.e., the transaction timestamp in ERCC; if zero, the current time is used
+	Time time.Time
+}
+
+// Result describes the platform and enclave attested by successfully verified evidence. It is evaluated by the
+// verification policy, which decides whether the platform is acceptable.
+type Result struct {
+	// Status is the TCB status of the platform, e.g., "OK" or "GROUP_OUT_OF_DATE" for EPID and "UpToDate" or
+	// "OutOfDate" for DCAP
+	Status      string
+	AdvisoryIDs []string
+	// Timestamp is the time at which the status was issued, e.g., the IAS report timestamp; zero if unknown
+	Timestamp time.Time
+	// Debug is true for debug enclaves, whose memory can be inspected by the host
+	Debug bool
 }
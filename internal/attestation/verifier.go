@@ -9,7 +9,11 @@ package attestation
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/dcap"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/epid"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/pkg/errors"
@@ -37,10 +41,10 @@ func (d *verifierDispatcher) Register(verifiers ...*types.Verifier) error {
 	return nil
 }
 
-func (d *verifierDispatcher) Verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) error {
+func (d *verifierDispatcher) Verify(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (*types.Result, error) {
 	verify, ok := d.verifiers[evidence.Type]
 	if !ok {
-		return fmt.Errorf("'%s' type is not registered", evidence.Type)
+		return nil, fmt.Errorf("'%s' type is not registered", evidence.Type)
 	}
 
 	logger.Debugf("Invoke verifier of type '%s'", evidence.Type)
@@ -49,13 +53,51 @@ func (d *verifierDispatcher) Verify(evidence *types.Evidence, expectedValidation
 
 type CredentialVerifier struct {
 	dispatcher *verifierDispatcher
+	policy     *VerificationPolicy
 }
 
 type Verifier interface {
-	VerifyCredentials(credentials *protos.Credentials, expectedMrenclave string) (err error)
+	// VerifyCredentials verifies the credentials of an enclave at the given time, i.e., the transaction timestamp
+	VerifyCredentials(credentials *protos.Credentials, expectedMrenclave string, timestamp time.Time) (err error)
 }
 
+// NewDefaultCredentialVerifier returns the CredentialVerifier recommended for clients: it verifies EPID and DCAP
+// credentials and evaluates the results against the ClientVerificationPolicy. Simulated credentials are rejected, as
+// anyone can forge them; see NewCredentialVerifierWithSimulation.
+func NewDefaultCredentialVerifier() *CredentialVerifier {
+	return NewDefaultCredentialVerifierWithPolicy(ClientVerificationPolicy())
+}
+
+// NewDefaultCredentialVerifierWithPolicy returns a CredentialVerifier like NewDefaultCredentialVerifier, which
+// evaluates the verification results against the given policy
+func NewDefaultCredentialVerifierWithPolicy(policy *VerificationPolicy) *CredentialVerifier {
+	return NewCredentialVerifierWithPolicy(policy,
+		epid.NewEpidLinkableVerifier(),
+		epid.NewEpidUnlinkableVerifier(),
+		dcap.NewDcapVerifier(),
+	)
+}
+
+// NewCredentialVerifierWithSimulation returns a CredentialVerifier for the evidence produced by
+// NewDefaultCredentialConverter, i.e., for simulated, EPID and DCAP credentials, which evaluates the verification
+// results against the given policy. Simulated credentials are not verified at all, thus, this verifier must only be
+// used for development without SGX, along with a policy that allows the SIMULATED status.
+func NewCredentialVerifierWithSimulation(policy *VerificationPolicy) *CredentialVerifier {
+	return NewCredentialVerifierWithPolicy(policy,
+		simulation.NewSimulationVerifier(),
+		epid.NewEpidLinkableVerifier(),
+		epid.NewEpidUnlinkableVerifier(),
+		dcap.NewDcapVerifier(),
+	)
+}
+
+// NewCredentialVerifier returns a CredentialVerifier that evaluates the verification results against the
+// DefaultVerificationPolicy
 func NewCredentialVerifier(verifier ...*types.Verifier) *CredentialVerifier {
+	return NewCredentialVerifierWithPolicy(DefaultVerificationPolicy(), verifier...)
+}
+
+func NewCredentialVerifierWithPolicy(policy *VerificationPolicy, verifier ...*types.Verifier) *CredentialVerifier {
 	dispatcher := newVerifierDispatcher()
 	err := dispatcher.Register(verifier...)
 	if err != nil {
@@ -63,22 +105,38 @@ func NewCredentialVerifier(verifier ...*types.Verifier) *CredentialVerifier {
 		logger.Panicf("cannot create new credential converter! Reason: %s", err.Error())
 	}
 
-	return &CredentialVerifier{dispatcher: dispatcher}
+	return &CredentialVerifier{dispatcher: dispatcher, policy: policy}
 }
 
-func (c *CredentialVerifier) VerifyCredentials(credentials *protos.Credentials, expectedMrenclave string) error {
+func (c *CredentialVerifier) VerifyCredentials(credentials *protos.Credentials, expectedMrenclave string, timestamp time.Time) error {
+	_, err := c.EvaluateCredentials(credentials, expectedMrenclave, timestamp)
+	return err
+}
 
+// EvaluateCredentials verifies the credentials like VerifyCredentials, but also returns the verification result of
+// the evidence, e.g., for diagnostics. The result is also returned if the evidence is valid but violates the policy.
+func (c *CredentialVerifier) EvaluateCredentials(credentials *protos.Credentials, expectedMrenclave string, timestamp time.Time) (*types.Result, error) {
 	evidence, err := unmarshalEvidence(credentials.Evidence)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	expectedValues := &types.ValidationValues{
 		Statement: credentials.SerializedAttestedData.Value,
 		Mrenclave: expectedMrenclave,
+		Time:      timestamp,
+	}
+
+	result, err := c.dispatcher.Verify(evidence, expectedValues)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.policy.Evaluate(result, timestamp); err != nil {
+		return result, errors.Wrap(err, "verification policy violated")
 	}
 
-	return c.dispatcher.Verify(evidence, expectedValues)
+	return result, nil
 }
 
 func unmarshalEvidence(serializedEvidence []byte) (*types.Evidence, error) {
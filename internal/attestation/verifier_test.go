@@ -8,17 +8,20 @@ package attestation
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/types"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 func NewDummyVerifier() *types.Verifier {
 	return &types.Verifier{
 		Type: "dummy",
-		Verify: func(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (err error) {
-			return nil
+		Verify: func(evidence *types.Evidence, expectedValidationValues *types.ValidationValues) (*types.Result, error) {
+			return &types.Result{Status: "OK"}, nil
 		},
 	}
 }
@@ -37,7 +40,7 @@ func TestVerifier(t *testing.T) {
 	}
 
 	// should fail as no converter yet registered for type dummy
-	err := d.Verify(ev, ref)
+	_, err := d.Verify(ev, ref)
 	assert.Error(t, err)
 
 	// register dummy converter
@@ -45,8 +48,9 @@ func TestVerifier(t *testing.T) {
 	assert.NoError(t, err)
 
 	// conversion should now succeed
-	err = d.Verify(ev, ref)
+	result, err := d.Verify(ev, ref)
 	assert.NoError(t, err)
+	assert.Equal(t, "OK", result.Status)
 
 	// trying to register dummy again should fail as already registered
 	err = d.Register(NewDummyVerifier())
@@ -56,3 +60,41 @@ func TestVerifier(t *testing.T) {
 	err = d.Register(simulation.NewSimulationVerifier())
 	assert.NoError(t, err)
 }
+
+func TestDefaultCredentialVerifier(t *testing.T) {
+	v := NewDefaultCredentialVerifier()
+	for _, attestationType := range []string{"epid-linkable", "epid-unlinkable", "dcap"} {
+		_, ok := v.dispatcher.verifiers[attestationType]
+		assert.True(t, ok, attestationType)
+	}
+	_, ok := v.dispatcher.verifiers["simulated"]
+	assert.False(t, ok)
+
+	// with simulation, every converter has a matching verifier
+	v = NewCredentialVerifierWithSimulation(DefaultVerificationPolicy())
+	for attestationType := range NewDefaultCredentialConverter().dispatcher.converters {
+		_, ok := v.dispatcher.verifiers[attestationType]
+		assert.True(t, ok, attestationType)
+	}
+}
+
+func TestDefaultCredentialVerifierRejectsSimulation(t *testing.T) {
+	// simulated credentials carry no proof, anyone can forge them
+	forged := &protos.Credentials{
+		SerializedAttestedData: &anypb.Any{Value: []byte("statement of a host-controlled key")},
+		Evidence:               []byte(`{"attestation_type":"simulated","evidence":"MA=="}`),
+	}
+	now := time.Now()
+
+	assert.Error(t, NewDefaultCredentialVerifier().VerifyCredentials(forged, "mrenclave", now))
+
+	// even with a policy that allows simulated results, as no verifier for simulated evidence is registered
+	assert.Error(t, NewDefaultCredentialVerifierWithPolicy(DefaultVerificationPolicy()).VerifyCredentials(forged, "mrenclave", now))
+
+	// the client policy rejects simulated results of verifiers with explicit simulation support
+	err := NewCredentialVerifierWithSimulation(ClientVerificationPolicy()).VerifyCredentials(forged, "mrenclave", now)
+	assert.ErrorContains(t, err, "verification policy violated")
+
+	// simulated credentials are only accepted with an explicit opt-in
+	assert.NoError(t, NewCredentialVerifierWithSimulation(DefaultVerificationPolicy()).VerifyCredentials(forged, "mrenclave", now))
+}
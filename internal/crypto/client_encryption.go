@@ -8,6 +8,9 @@ SPDX-License-Identifier: Apache-2.0
 package crypto
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 
@@ -20,16 +23,37 @@ import (
 
 var logger = flogging.MustGetLogger("fpc-client-crypto")
 
+// UnknownEnclaveError is returned by EncryptionContext.Verify and EncryptionContext.Reveal if a response is signed by
+// an enclave whose verification key is not known to the client, e.g., as it was registered after the keys were queried
+type UnknownEnclaveError struct {
+	EnclaveId string
+}
+
+func (e *UnknownEnclaveError) Error() string {
+	return fmt.Sprintf("response from unknown enclave '%s'", e.EnclaveId)
+}
+
 type EncryptionProvider interface {
-	NewEncryptionContext() (EncryptionContext, error)
+	NewEncryptionContext(ctx context.Context) (EncryptionContext, error)
 }
 
 type EncryptionProviderImpl struct {
 	CSP                CSP
-	GetCcEncryptionKey func() ([]byte, error)
+	GetCcEncryptionKey func(ctx context.Context) ([]byte, error)
+	// GetVerifiedKeys is optional; if set, it is used instead of GetCcEncryptionKey, and responses are only revealed if
+	// they are signed by one of the returned enclaves
+	GetVerifiedKeys func(ctx context.Context) (*VerifiedKeys, error)
 }
 
-func (p EncryptionProviderImpl) NewEncryptionContext() (EncryptionContext, error) {
+// VerifiedKeys are the keys of a chaincode taken from verified enclave credentials
+type VerifiedKeys struct {
+	// ChaincodeEncryptionKey is the (not base64-encoded) chaincode encryption key
+	ChaincodeEncryptionKey []byte
+	// EnclaveVerificationKeys are the verification keys of the chaincode enclaves, indexed by enclave id
+	EnclaveVerificationKeys map[string][]byte
+}
+
+func (p EncryptionProviderImpl) NewEncryptionContext(ctx context.Context) (EncryptionContext, error) {
 	// pick request encryption key
 	requestEncryptionKey, err := p.CSP.NewSymmetricKey()
 	if err != nil {
@@ -42,7 +66,25 @@ func (p EncryptionProviderImpl) NewEncryptionContext() (EncryptionContext, error
 		return nil, err
 	}
 
-	ccEncryptionKey, err := p.GetCcEncryptionKey()
+	if p.GetVerifiedKeys != nil {
+		keys, err := p.GetVerifiedKeys(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get verified chaincode keys: %s", err.Error())
+		}
+		if len(keys.EnclaveVerificationKeys) == 0 {
+			return nil, fmt.Errorf("no enclave verification keys")
+		}
+
+		return &EncryptionContextImpl{
+			csp:                     p.CSP,
+			requestEncryptionKey:    requestEncryptionKey,
+			responseEncryptionKey:   resultEncryptionKey,
+			chaincodeEncryptionKey:  keys.ChaincodeEncryptionKey,
+			enclaveVerificationKeys: keys.EnclaveVerificationKeys,
+		}, nil
+	}
+
+	ccEncryptionKey, err := p.GetCcEncryptionKey(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chaincode encryption key from ercc: %s", err.Error())
 	}
@@ -63,9 +105,12 @@ func (p EncryptionProviderImpl) NewEncryptionContext() (EncryptionContext, error
 // EncryptionContext defines the interface of an object responsible to encrypt the contents of a transaction invocation
 // and to decrypt the corresponding response.
 // Conceal and Reveal must be called only once during the lifetime of an object that implements this interface. That is,
-// an EncryptionContext is only valid for a single transaction invocation.
+// an EncryptionContext is only valid for a single transaction invocation. Verify may be called before Reveal to check a
+// response, e.g., before it is submitted for endorsement.
 type EncryptionContext interface {
 	Conceal(function string, args []string) (string, error)
+	ConcealBatch(requests [][]string, independent bool) (string, error)
+	Verify(r []byte) error
 	Reveal(r []byte) ([]byte, error)
 }
 
@@ -74,9 +119,38 @@ type EncryptionContextImpl struct {
 	requestEncryptionKey   []byte
 	responseEncryptionKey  []byte
 	chaincodeEncryptionKey []byte
+	// enclaveVerificationKeys are the keys of the enclaves whose responses are accepted; if nil, the enclave signature
+	// is not verified
+	enclaveVerificationKeys map[string][]byte
+	// chaincodeRequestMessageHash is the hash of the request created by Conceal
+	chaincodeRequestMessageHash []byte
+}
+
+// Verify checks the response of the enclave without decrypting it. If the context knows the verification keys of the
+// chaincode enclaves, the response must be signed by one of them. If the request was created by this context, the
+// response must refer to this request.
+func (e *EncryptionContextImpl) Verify(signedResponseBytesB64 []byte) error {
+	_, err := e.verify(signedResponseBytesB64)
+	return err
 }
 
+// Reveal verifies and decrypts the response of the enclave, see Verify
 func (e *EncryptionContextImpl) Reveal(signedResponseBytesB64 []byte) ([]byte, error) {
+	response, err := e.verify(signedResponseBytesB64)
+	if err != nil {
+		return nil, err
+	}
+
+	clearResponseBytes, err := e.csp.DecryptMessage(e.responseEncryptionKey, response.EncryptedResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "decryption of response failed")
+	}
+
+	return clearResponseBytes, nil
+}
+
+// verify returns the verified response message contained in the signed response
+func (e *EncryptionContextImpl) verify(signedResponseBytesB64 []byte) (*protos.ChaincodeResponseMessage, error) {
 	signedResponseBytes, err := base64.StdEncoding.DecodeString(string(signedResponseBytesB64))
 	if err != nil {
 		return nil, err
@@ -97,21 +171,68 @@ func (e *EncryptionContextImpl) Reveal(signedResponseBytesB64 []byte) ([]byte, e
 		return nil, errors.Wrap(err, "failed to extract response message")
 	}
 
-	clearResponseBytes, err := e.csp.DecryptMessage(e.responseEncryptionKey, response.EncryptedResponse)
-	if err != nil {
-		return nil, errors.Wrap(err, "decryption of response failed")
+	if e.enclaveVerificationKeys != nil {
+		enclaveVk, ok := e.enclaveVerificationKeys[response.GetEnclaveId()]
+		if !ok {
+			return nil, &UnknownEnclaveError{EnclaveId: response.GetEnclaveId()}
+		}
+		if err := e.csp.VerifyMessage(enclaveVk, responseBytes, signedResponse.GetSignature()); err != nil {
+			return nil, errors.Wrap(err, "enclave signature verification failed")
+		}
 	}
 
-	return clearResponseBytes, nil
+	if e.chaincodeRequestMessageHash != nil && !bytes.Equal(e.chaincodeRequestMessageHash, response.GetChaincodeRequestMessageHash()) {
+		return nil, fmt.Errorf("chaincode request message hash mismatch, the response is not for this request")
+	}
+
+	return response, nil
 }
 
 func (e *EncryptionContextImpl) Conceal(function string, args []string) (string, error) {
-	args = append([]string{function}, args...)
+	// prepare CleartextChaincodeRequest
+	ccRequest := &protos.CleartextChaincodeRequest{
+		Input: toChaincodeInput(append([]string{function}, args...)),
+	}
+	logger.Debugf("prepping chaincode params: %s", ccRequest)
+
+	return e.conceal(ccRequest)
+}
+
+// ConcealBatch encrypts a batch of invocations, each given as function name followed by the args, such that the
+// enclave executes all invocations as part of a single transaction.
+// If independent is false, the batch fails if one invocation fails; otherwise, only the state updates of the
+// successful invocations are committed. Each invocation observes the writes of the preceding successful invocations.
+func (e *EncryptionContextImpl) ConcealBatch(requests [][]string, independent bool) (string, error) {
+	if len(requests) == 0 {
+		return "", fmt.Errorf("empty batch")
+	}
+
+	batch := &protos.CleartextChaincodeBatchRequest{Independent: independent}
+	for _, r := range requests {
+		if len(r) == 0 {
+			return "", fmt.Errorf("batch request without function name")
+		}
+		batch.Inputs = append(batch.Inputs, toChaincodeInput(r))
+	}
+
+	// prepare CleartextChaincodeRequest
+	ccRequest := &protos.CleartextChaincodeRequest{
+		Batch: batch,
+	}
+	logger.Debugf("prepping chaincode batch with %d requests", len(requests))
+
+	return e.conceal(ccRequest)
+}
+
+func toChaincodeInput(args []string) *peer.ChaincodeInput {
 	bytes := make([][]byte, len(args))
 	for i, v := range args {
 		bytes[i] = []byte(v)
 	}
+	return &peer.ChaincodeInput{Args: bytes}
+}
 
+func (e *EncryptionContextImpl) conceal(ccRequest *protos.CleartextChaincodeRequest) (string, error) {
 	// prepare KeyTransportMessage
 	keyTransport := &protos.KeyTransportMessage{
 		RequestEncryptionKey:  e.requestEncryptionKey,
@@ -128,12 +249,6 @@ func (e *EncryptionContextImpl) Conceal(function string, args []string) (string,
 		return "", errors.Wrap(err, "encryption of request encryption key failed")
 	}
 
-	// prepare CleartextChaincodeRequest
-	ccRequest := &protos.CleartextChaincodeRequest{
-		Input: &peer.ChaincodeInput{Args: bytes},
-	}
-	logger.Debugf("prepping chaincode params: %s", ccRequest)
-
 	serializedCcRequest, err := utils.MarshallProto(ccRequest)
 	if err != nil {
 		return "", err
@@ -155,5 +270,9 @@ func (e *EncryptionContextImpl) Conceal(function string, args []string) (string,
 		return "", err
 	}
 
+	// the enclave includes the hash of the request in its response
+	hash := sha256.Sum256(serializedEncryptedCcRequest)
+	e.chaincodeRequestMessageHash = hash[:]
+
 	return base64.StdEncoding.EncodeToString(serializedEncryptedCcRequest), nil
 }
@@ -8,6 +8,8 @@ SPDX-License-Identifier: Apache-2.0
 package crypto
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"testing"
@@ -16,37 +18,38 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric/protoutil"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestNewEncryptionContext(t *testing.T) {
 	provider := &EncryptionProviderImpl{
 		CSP: GetDefaultCSP(),
-		GetCcEncryptionKey: func() ([]byte, error) {
+		GetCcEncryptionKey: func(context.Context) ([]byte, error) {
 			return nil, fmt.Errorf("some error while fetching key")
 		},
 	}
 	expectedErrorMsg := "failed to get chaincode encryption key from ercc: some error while fetching key"
-	ctx, err := provider.NewEncryptionContext()
+	ctx, err := provider.NewEncryptionContext(context.Background())
 	assert.Nil(t, ctx)
 	assert.Error(t, err, expectedErrorMsg)
 
 	provider = &EncryptionProviderImpl{
 		CSP: GetDefaultCSP(),
-		GetCcEncryptionKey: func() ([]byte, error) {
+		GetCcEncryptionKey: func(context.Context) ([]byte, error) {
 			return []byte("some invalid base64 encoded key"), nil
 		},
 	}
-	ctx, err = provider.NewEncryptionContext()
+	ctx, err = provider.NewEncryptionContext(context.Background())
 	assert.Nil(t, ctx)
 	assert.Error(t, err)
 
 	provider = &EncryptionProviderImpl{
 		CSP: GetDefaultCSP(),
-		GetCcEncryptionKey: func() ([]byte, error) {
+		GetCcEncryptionKey: func(context.Context) ([]byte, error) {
 			return []byte(base64.StdEncoding.EncodeToString([]byte("some key"))), nil
 		},
 	}
-	ctx, err = provider.NewEncryptionContext()
+	ctx, err = provider.NewEncryptionContext(context.Background())
 	assert.NotNil(t, ctx)
 	assert.NoError(t, err)
 }
@@ -83,11 +86,11 @@ func TestConceal(t *testing.T) {
 	assert.NoError(t, err)
 	provider := &EncryptionProviderImpl{
 		CSP: GetDefaultCSP(),
-		GetCcEncryptionKey: func() ([]byte, error) {
+		GetCcEncryptionKey: func(context.Context) ([]byte, error) {
 			return []byte(base64.StdEncoding.EncodeToString(pubKey)), nil
 		},
 	}
-	ctx, err := provider.NewEncryptionContext()
+	ctx, err := provider.NewEncryptionContext(context.Background())
 	assert.NotNil(t, ctx)
 	assert.NoError(t, err)
 
@@ -100,6 +103,59 @@ func TestConceal(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestConcealBatch(t *testing.T) {
+	pubKey, privKey, err := GetDefaultCSP().NewRSAKeys()
+	assert.NoError(t, err)
+	provider := &EncryptionProviderImpl{
+		CSP: GetDefaultCSP(),
+		GetCcEncryptionKey: func(context.Context) ([]byte, error) {
+			return []byte(base64.StdEncoding.EncodeToString(pubKey)), nil
+		},
+	}
+	ctx, err := provider.NewEncryptionContext(context.Background())
+	assert.NoError(t, err)
+
+	// empty batch
+	request, err := ctx.ConcealBatch(nil, false)
+	assert.Empty(t, request)
+	assert.EqualError(t, err, "empty batch")
+
+	// request without function
+	request, err = ctx.ConcealBatch([][]string{{"f1", "arg1"}, {}}, false)
+	assert.Empty(t, request)
+	assert.Error(t, err)
+
+	// should succeed
+	request, err = ctx.ConcealBatch([][]string{{"f1", "arg1"}, {"f2"}}, true)
+	assert.NotEmpty(t, request)
+	assert.NoError(t, err)
+
+	// decrypt and check batch
+	requestBytes, err := base64.StdEncoding.DecodeString(request)
+	assert.NoError(t, err)
+	requestMsg := &protos.ChaincodeRequestMessage{}
+	err = proto.Unmarshal(requestBytes, requestMsg)
+	assert.NoError(t, err)
+
+	keyTransportBytes, err := GetDefaultCSP().PkDecryptMessage(privKey, requestMsg.GetEncryptedKeyTransportMessage())
+	assert.NoError(t, err)
+	keyTransport := &protos.KeyTransportMessage{}
+	err = proto.Unmarshal(keyTransportBytes, keyTransport)
+	assert.NoError(t, err)
+
+	clearRequestBytes, err := GetDefaultCSP().DecryptMessage(keyTransport.GetRequestEncryptionKey(), requestMsg.GetEncryptedRequest())
+	assert.NoError(t, err)
+	clearRequest := &protos.CleartextChaincodeRequest{}
+	err = proto.Unmarshal(clearRequestBytes, clearRequest)
+	assert.NoError(t, err)
+
+	assert.Nil(t, clearRequest.GetInput())
+	assert.True(t, clearRequest.GetBatch().GetIndependent())
+	assert.Len(t, clearRequest.GetBatch().GetInputs(), 2)
+	assert.Equal(t, [][]byte{[]byte("f1"), []byte("arg1")}, clearRequest.GetBatch().GetInputs()[0].GetArgs())
+	assert.Equal(t, [][]byte{[]byte("f2")}, clearRequest.GetBatch().GetInputs()[1].GetArgs())
+}
+
 func TestReveal(t *testing.T) {
 	msg := []byte("some response")
 
@@ -158,3 +214,91 @@ func TestReveal(t *testing.T) {
 	assert.Equal(t, resp, msg)
 	assert.NoError(t, err)
 }
+
+func TestRevealVerified(t *testing.T) {
+	msg := []byte("some response")
+	csp := GetDefaultCSP()
+
+	chaincodeEk, _, err := csp.NewRSAKeys()
+	assert.NoError(t, err)
+	enclaveVk, enclaveSk, err := csp.NewECDSAKeys()
+	assert.NoError(t, err)
+	_, otherSk, err := csp.NewECDSAKeys()
+	assert.NoError(t, err)
+
+	provider := &EncryptionProviderImpl{
+		CSP: csp,
+		GetVerifiedKeys: func(context.Context) (*VerifiedKeys, error) {
+			return &VerifiedKeys{
+				ChaincodeEncryptionKey:  chaincodeEk,
+				EnclaveVerificationKeys: map[string][]byte{"enclave1": enclaveVk},
+			}, nil
+		},
+	}
+	ctx, err := provider.NewEncryptionContext(context.Background())
+	assert.NoError(t, err)
+	request, err := ctx.Conceal("some function", nil)
+	assert.NoError(t, err)
+	requestBytes, err := base64.StdEncoding.DecodeString(request)
+	assert.NoError(t, err)
+	requestHash := sha256.Sum256(requestBytes)
+
+	encryptedMsg, err := csp.EncryptMessage(ctx.(*EncryptionContextImpl).responseEncryptionKey, msg)
+	assert.NoError(t, err)
+
+	signedResponse := func(enclaveId string, hash []byte, sk []byte) []byte {
+		responseBytes := protoutil.MarshalOrPanic(&protos.ChaincodeResponseMessage{
+			EncryptedResponse:           encryptedMsg,
+			ChaincodeRequestMessageHash: hash,
+			EnclaveId:                   enclaveId,
+		})
+		signature, err := csp.SignMessage(sk, responseBytes)
+		assert.NoError(t, err)
+		return []byte(utils.MarshallProtoBase64(&protos.SignedChaincodeResponseMessage{
+			ChaincodeResponseMessage: responseBytes,
+			Signature:                signature,
+		}))
+	}
+
+	// should succeed
+	resp, err := ctx.Reveal(signedResponse("enclave1", requestHash[:], enclaveSk))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, resp)
+
+	assert.NoError(t, ctx.Verify(signedResponse("enclave1", requestHash[:], enclaveSk)))
+
+	// unknown enclave
+	err = ctx.Verify(signedResponse("enclave2", requestHash[:], otherSk))
+	assert.EqualError(t, err, "response from unknown enclave 'enclave2'")
+	var unknownEnclaveErr *UnknownEnclaveError
+	assert.ErrorAs(t, err, &unknownEnclaveErr)
+	resp, err = ctx.Reveal(signedResponse("enclave2", requestHash[:], otherSk))
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "response from unknown enclave 'enclave2'")
+
+	// forged signature
+	assert.ErrorContains(t, ctx.Verify(signedResponse("enclave1", requestHash[:], otherSk)), "enclave signature verification failed")
+	resp, err = ctx.Reveal(signedResponse("enclave1", requestHash[:], otherSk))
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "enclave signature verification failed")
+
+	// response to another request
+	otherHash := sha256.Sum256([]byte("another request"))
+	assert.EqualError(t, ctx.Verify(signedResponse("enclave1", otherHash[:], enclaveSk)), "chaincode request message hash mismatch, the response is not for this request")
+	resp, err = ctx.Reveal(signedResponse("enclave1", otherHash[:], enclaveSk))
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "chaincode request message hash mismatch, the response is not for this request")
+
+	// no verified enclaves
+	provider.GetVerifiedKeys = func(context.Context) (*VerifiedKeys, error) {
+		return &VerifiedKeys{ChaincodeEncryptionKey: chaincodeEk}, nil
+	}
+	_, err = provider.NewEncryptionContext(context.Background())
+	assert.EqualError(t, err, "no enclave verification keys")
+
+	provider.GetVerifiedKeys = func(context.Context) (*VerifiedKeys, error) {
+		return nil, fmt.Errorf("verification failed")
+	}
+	_, err = provider.NewEncryptionContext(context.Background())
+	assert.EqualError(t, err, "failed to get verified chaincode keys: verification failed")
+}
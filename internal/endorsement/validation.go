@@ -18,15 +18,49 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/pkg/errors"
 )
 
 var logger = flogging.MustGetLogger("validate")
 
+// EndorsedProposalObjectType is the composite key object type under which ECC records the transaction ids of the
+// proposals whose responses have been endorsed
+const EndorsedProposalObjectType = "namespaces/endorsedProposals"
+
+// StaleReadStatus is the response status returned by `__endorse` if it fails with a StaleReadError; it allows clients
+// to detect stale reads without relying on the error message. As any status >= shim.ERRORTHRESHOLD, it is treated as
+// an error by the peer.
+const StaleReadStatus = 409
+
+// StaleKeyStatus is the response status returned by `__invoke` if the enclave cannot decrypt the key transport message
+// of the request, and by `__endorse` if the enclave was created for another chaincode definition. In both cases, the
+// client prepared the request with outdated chaincode or enclave keys, e.g., after a chaincode upgrade, and can refresh
+// them from the enclave registry. As StaleReadStatus, it allows clients to detect such requests without relying on the
+// error message.
+const StaleKeyStatus = 412
+
+// StaleReadError is returned when the value of a key read by the enclave has changed in the meantime, i.e., the
+// transaction would be invalidated by the MVCC check at commit time. Such a transaction can be safely retried.
+type StaleReadError struct {
+	Key string
+}
+
+func (e *StaleReadError) Error() string {
+	return fmt.Sprintf("stale read of key %s: value hash mismatch", e.Key)
+}
+
+// IsStaleReadError returns true if the given error is or wraps a StaleReadError
+func IsStaleReadError(err error) bool {
+	var staleReadErr *StaleReadError
+	return errors.As(err, &staleReadErr)
+}
+
 type Validation interface {
 	ReplayReadWrites(stub shim.ChaincodeStubInterface, fpcrwset *protos.FPCKVSet) error
 	Validate(signedResponseMessage *protos.SignedChaincodeResponseMessage, attestedData *protos.AttestedData) error
+	CheckAndRecordProposal(stub shim.ChaincodeStubInterface, signedProposal *pb.SignedProposal) error
 }
 
 func NewValidator() *ValidatorImpl {
@@ -85,7 +119,7 @@ func (v *ValidatorImpl) ReplayReadWrites(stub shim.ChaincodeStubInterface, fpcrw
 				logger.Debugf("value(hex): %s", hex.EncodeToString(v))
 				logger.Debugf("computed hash(hex): %s", hex.EncodeToString(valueHash))
 				logger.Debugf("received hash(hex): %s", hex.EncodeToString(fpcrwset.ReadValueHashes[i]))
-				return fmt.Errorf("value hash mismatch for key %s", k)
+				return &StaleReadError{Key: k}
 			}
 		}
 	}
@@ -188,3 +222,44 @@ func (v *ValidatorImpl) Validate(signedResponseMessage *protos.SignedChaincodeRe
 
 	return nil
 }
+
+// CheckAndRecordProposal binds an endorsement to the original proposal processed by the enclave. It checks that the
+// creator of the current transaction matches the creator of the original proposal and that no response to this
+// proposal has been endorsed before. The transaction id of the original proposal is then recorded in the ledger state.
+// Note that the state read of the record also ensures that concurrent endorsements of the same response are
+// invalidated by the MVCC check at commit time.
+func (v *ValidatorImpl) CheckAndRecordProposal(stub shim.ChaincodeStubInterface, signedProposal *pb.SignedProposal) error {
+	txId, proposalCreator, err := utils.GetTxIdAndCreatorFromSignedProposal(signedProposal)
+	if err != nil {
+		return errors.Wrap(err, "failed to extract original proposal")
+	}
+
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return errors.Wrap(err, "failed to get creator")
+	}
+	if !bytes.Equal(creator, proposalCreator) {
+		return fmt.Errorf("creator does not match creator of the original proposal")
+	}
+
+	key, err := stub.CreateCompositeKey(EndorsedProposalObjectType, []string{txId})
+	if err != nil {
+		return errors.Wrap(err, "failed to create key")
+	}
+
+	record, err := stub.GetState(key)
+	if err != nil {
+		return fmt.Errorf("error (%s) reading key %s", err, key)
+	}
+	if record != nil {
+		return fmt.Errorf("response to proposal with txId %s already endorsed", txId)
+	}
+
+	// we record the id of the endorsement transaction
+	if err := stub.PutState(key, []byte(stub.GetTxID())); err != nil {
+		return fmt.Errorf("error (%s) writing key %s", err, key)
+	}
+	logger.Debugf("recorded endorsement of proposal with txId %s", txId)
+
+	return nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -98,6 +99,8 @@ func TestReplayReadWrites(t *testing.T) {
 	}
 	err = v.ReplayReadWrites(stub, fpcrwset)
 	assert.Error(t, err)
+	assert.IsType(t, &StaleReadError{}, err)
+	assert.True(t, IsStaleReadError(err))
 
 	// no errors (reads)
 	value := []byte("some value")
@@ -284,3 +287,85 @@ func hash(v []byte) []byte {
 	h.Write(v)
 	return h.Sum(nil)
 }
+
+func newSignedProposal(txId string, creator []byte) *peer.SignedProposal {
+	hdr := &common.Header{
+		ChannelHeader:   protoutil.MarshalOrPanic(&common.ChannelHeader{Type: int32(common.HeaderType_ENDORSER_TRANSACTION), TxId: txId}),
+		SignatureHeader: protoutil.MarshalOrPanic(&common.SignatureHeader{Creator: creator}),
+	}
+	proposal := &peer.Proposal{Header: protoutil.MarshalOrPanic(hdr)}
+	return &peer.SignedProposal{ProposalBytes: protoutil.MarshalOrPanic(proposal)}
+}
+
+func TestCheckAndRecordProposal(t *testing.T) {
+	v := &ValidatorImpl{}
+	creator := []byte("someCreator")
+	signedProposal := newSignedProposal("someTxId", creator)
+
+	newStub := func() *fakes.ChaincodeStub {
+		stub := &fakes.ChaincodeStub{}
+		stub.GetCreatorReturns(creator, nil)
+		stub.GetTxIDReturns("someEndorseTxId")
+		stub.CreateCompositeKeyStub = func(objectType string, attributes []string) (string, error) {
+			return shim.CreateCompositeKey(objectType, attributes)
+		}
+		return stub
+	}
+	expectedKey, _ := shim.CreateCompositeKey(EndorsedProposalObjectType, []string{"someTxId"})
+
+	// no proposal
+	err := v.CheckAndRecordProposal(newStub(), nil)
+	assert.Error(t, err)
+
+	// invalid proposal
+	err = v.CheckAndRecordProposal(newStub(), &peer.SignedProposal{ProposalBytes: []byte("garbage")})
+	assert.Error(t, err)
+
+	// creator mismatch
+	stub := newStub()
+	stub.GetCreatorReturns([]byte("someOtherCreator"), nil)
+	err = v.CheckAndRecordProposal(stub, signedProposal)
+	assert.EqualError(t, err, "creator does not match creator of the original proposal")
+	assert.Zero(t, stub.PutStateCallCount())
+
+	// proposal already endorsed
+	stub = newStub()
+	stub.GetStateReturns([]byte("someOtherEndorseTxId"), nil)
+	err = v.CheckAndRecordProposal(stub, signedProposal)
+	assert.EqualError(t, err, "response to proposal with txId someTxId already endorsed")
+	assert.Zero(t, stub.PutStateCallCount())
+
+	// error reading state
+	stub = newStub()
+	stub.GetStateReturns(nil, fmt.Errorf("some error"))
+	err = v.CheckAndRecordProposal(stub, signedProposal)
+	assert.Error(t, err)
+
+	// error writing state
+	stub = newStub()
+	stub.PutStateReturns(fmt.Errorf("some error"))
+	err = v.CheckAndRecordProposal(stub, signedProposal)
+	assert.Error(t, err)
+
+	// success
+	stub = newStub()
+	err = v.CheckAndRecordProposal(stub, signedProposal)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedKey, stub.GetStateArgsForCall(0))
+	k, value := stub.PutStateArgsForCall(0)
+	assert.Equal(t, expectedKey, k)
+	assert.Equal(t, []byte("someEndorseTxId"), value)
+}
+
+func TestIsStaleReadError(t *testing.T) {
+	assert.False(t, IsStaleReadError(nil))
+	assert.False(t, IsStaleReadError(fmt.Errorf("some error")))
+
+	err := &StaleReadError{Key: "someKey"}
+	assert.EqualError(t, err, "stale read of key someKey: value hash mismatch")
+	assert.True(t, IsStaleReadError(err))
+	assert.True(t, IsStaleReadError(errors.Wrap(err, "endorse failed")))
+
+	// stale reads are not detected from error messages, see StaleReadStatus
+	assert.False(t, IsStaleReadError(fmt.Errorf("endorsement failure: %s", err.Error())))
+}
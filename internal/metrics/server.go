@@ -0,0 +1,55 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package metrics provides the (optional) Prometheus metrics endpoint of the FPC chaincode servers
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
+	"github.com/hyperledger/fabric/common/metrics/prometheus"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// AddressEnv defines the address (host:port) of the metrics endpoint; if not set, metrics are disabled
+const AddressEnv = "FPC_METRICS_ADDRESS"
+
+// Path is the HTTP path of the metrics endpoint
+const Path = "/metrics"
+
+var logger = flogging.MustGetLogger("metrics")
+
+// ProviderFromEnv returns a Prometheus metrics provider and serves the metrics at the address defined by
+// FPC_METRICS_ADDRESS. If FPC_METRICS_ADDRESS is not set, a disabled provider is returned.
+func ProviderFromEnv() (metrics.Provider, error) {
+	addr := os.Getenv(AddressEnv)
+	if addr == "" {
+		return &disabled.Provider{}, nil
+	}
+
+	// listen before returning, so that an invalid or occupied address fails at startup
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot listen on metrics address %s", addr)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(Path, promhttp.Handler())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			logger.Errorf("metrics endpoint stopped: %s", err)
+		}
+	}()
+	logger.Infof("serving metrics at %s%s", listener.Addr(), Path)
+
+	return &prometheus.Provider{}, nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package metrics
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
+	"github.com/hyperledger/fabric/common/metrics/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderFromEnv(t *testing.T) {
+	// disabled by default
+	t.Setenv(AddressEnv, "")
+	p, err := ProviderFromEnv()
+	assert.NoError(t, err)
+	assert.IsType(t, &disabled.Provider{}, p)
+
+	// invalid address
+	t.Setenv(AddressEnv, "not an address")
+	_, err = ProviderFromEnv()
+	assert.ErrorContains(t, err, "cannot listen on metrics address not an address")
+
+	// serve metrics
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	t.Setenv(AddressEnv, addr)
+	p, err = ProviderFromEnv()
+	require.NoError(t, err)
+	assert.IsType(t, &prometheus.Provider{}, p)
+	p.NewCounter(metrics.CounterOpts{Namespace: "fpc", Subsystem: "test", Name: "some_counter"}).Add(1)
+
+	resp, err := http.Get("http://" + addr + Path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "fpc_test_some_counter 1")
+}
@@ -118,7 +118,7 @@ type HostParameters struct {
 	// Enclave_VK and CCParameters signed by a CA rooted in
 	// the MSP of the Organization hosting the peer running the FPC
 	// Chaincode enclave.  This shows the "ownership" of Org for that
-	// particular FPC Chaincode enclave.   See additional information in
+	//particular FPC Chaincode enclave.   See additional information in
 	// fpc-registration.puml in the 'Org-Enclave binding/certification' group.
 	// Note that this field may be moved elsewhere.
 	Certificate []byte `protobuf:"bytes,3,opt,name=certificate,proto3" json:"certificate,omitempty"`
@@ -336,6 +336,125 @@ func (x *Credentials) GetEvidence() []byte {
 	return nil
 }
 
+// EnclaveState is the state of an enclave that is sealed to local storage, so that the enclave can be
+// restored after a chaincode restart
+type EnclaveState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// enclave signing key and verification key (enclave_sk, enclave_vk)
+	EnclaveSk []byte `protobuf:"bytes,1,opt,name=enclave_sk,json=enclaveSk,proto3" json:"enclave_sk,omitempty"`
+	EnclaveVk []byte `protobuf:"bytes,2,opt,name=enclave_vk,json=enclaveVk,proto3" json:"enclave_vk,omitempty"`
+	// enclave decryption key (enclave_dk); not used by the go enclave
+	EnclaveDk []byte `protobuf:"bytes,3,opt,name=enclave_dk,json=enclaveDk,proto3" json:"enclave_dk,omitempty"`
+	// chaincode decryption key and encryption key (chaincode_dk, chaincode_ek)
+	ChaincodeDk []byte `protobuf:"bytes,4,opt,name=chaincode_dk,json=chaincodeDk,proto3" json:"chaincode_dk,omitempty"`
+	ChaincodeEk []byte `protobuf:"bytes,5,opt,name=chaincode_ek,json=chaincodeEk,proto3" json:"chaincode_ek,omitempty"`
+	// state encryption key (sek)
+	StateEncryptionKey []byte `protobuf:"bytes,6,opt,name=state_encryption_key,json=stateEncryptionKey,proto3" json:"state_encryption_key,omitempty"`
+	// serialization of type **CCParameters** and **HostParameters**, and the attestation parameters
+	// as passed at enclave initialization
+	CcParams          []byte `protobuf:"bytes,7,opt,name=cc_params,json=ccParams,proto3" json:"cc_params,omitempty"`
+	HostParams        []byte `protobuf:"bytes,8,opt,name=host_params,json=hostParams,proto3" json:"host_params,omitempty"`
+	AttestationParams []byte `protobuf:"bytes,9,opt,name=attestation_params,json=attestationParams,proto3" json:"attestation_params,omitempty"`
+}
+
+func (x *EnclaveState) Reset() {
+	*x = EnclaveState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_fpc_fpc_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EnclaveState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnclaveState) ProtoMessage() {}
+
+func (x *EnclaveState) ProtoReflect() protoreflect.Message {
+	mi := &file_fpc_fpc_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnclaveState.ProtoReflect.Descriptor instead.
+func (*EnclaveState) Descriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EnclaveState) GetEnclaveSk() []byte {
+	if x != nil {
+		return x.EnclaveSk
+	}
+	return nil
+}
+
+func (x *EnclaveState) GetEnclaveVk() []byte {
+	if x != nil {
+		return x.EnclaveVk
+	}
+	return nil
+}
+
+func (x *EnclaveState) GetEnclaveDk() []byte {
+	if x != nil {
+		return x.EnclaveDk
+	}
+	return nil
+}
+
+func (x *EnclaveState) GetChaincodeDk() []byte {
+	if x != nil {
+		return x.ChaincodeDk
+	}
+	return nil
+}
+
+func (x *EnclaveState) GetChaincodeEk() []byte {
+	if x != nil {
+		return x.ChaincodeEk
+	}
+	return nil
+}
+
+func (x *EnclaveState) GetStateEncryptionKey() []byte {
+	if x != nil {
+		return x.StateEncryptionKey
+	}
+	return nil
+}
+
+func (x *EnclaveState) GetCcParams() []byte {
+	if x != nil {
+		return x.CcParams
+	}
+	return nil
+}
+
+func (x *EnclaveState) GetHostParams() []byte {
+	if x != nil {
+		return x.HostParams
+	}
+	return nil
+}
+
+func (x *EnclaveState) GetAttestationParams() []byte {
+	if x != nil {
+		return x.AttestationParams
+	}
+	return nil
+}
+
 type InitEnclaveMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -351,7 +470,7 @@ type InitEnclaveMessage struct {
 func (x *InitEnclaveMessage) Reset() {
 	*x = InitEnclaveMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_fpc_fpc_proto_msgTypes[4]
+		mi := &file_fpc_fpc_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -364,7 +483,7 @@ func (x *InitEnclaveMessage) String() string {
 func (*InitEnclaveMessage) ProtoMessage() {}
 
 func (x *InitEnclaveMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[4]
+	mi := &file_fpc_fpc_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -377,7 +496,7 @@ func (x *InitEnclaveMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InitEnclaveMessage.ProtoReflect.Descriptor instead.
 func (*InitEnclaveMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{4}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *InitEnclaveMessage) GetPeerEndpoint() string {
@@ -401,12 +520,17 @@ type CleartextChaincodeRequest struct {
 
 	// the function and args to invoke
 	Input *peer.ChaincodeInput `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	// a batch of invocations; if set, input is ignored and the response payload is a
+	// serialization of CleartextChaincodeBatchResponse.
+	// Note that batches are currently supported by Go chaincodes (ecc_go) only; C++ chaincodes do not run
+	// input but return an error response with message "batch unsupported"
+	Batch *CleartextChaincodeBatchRequest `protobuf:"bytes,2,opt,name=batch,proto3" json:"batch,omitempty"`
 }
 
 func (x *CleartextChaincodeRequest) Reset() {
 	*x = CleartextChaincodeRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_fpc_fpc_proto_msgTypes[5]
+		mi := &file_fpc_fpc_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -419,7 +543,7 @@ func (x *CleartextChaincodeRequest) String() string {
 func (*CleartextChaincodeRequest) ProtoMessage() {}
 
 func (x *CleartextChaincodeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[5]
+	mi := &file_fpc_fpc_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -432,7 +556,7 @@ func (x *CleartextChaincodeRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CleartextChaincodeRequest.ProtoReflect.Descriptor instead.
 func (*CleartextChaincodeRequest) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{5}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *CleartextChaincodeRequest) GetInput() *peer.ChaincodeInput {
@@ -442,6 +566,120 @@ func (x *CleartextChaincodeRequest) GetInput() *peer.ChaincodeInput {
 	return nil
 }
 
+func (x *CleartextChaincodeRequest) GetBatch() *CleartextChaincodeBatchRequest {
+	if x != nil {
+		return x.Batch
+	}
+	return nil
+}
+
+type CleartextChaincodeBatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the functions and args to invoke, executed in the given order
+	Inputs []*peer.ChaincodeInput `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	// if false, the batch fails if one invocation fails; if true, only the rwsets of successful invocations
+	// are included in the response. In both cases, an invocation observes the writes of the preceding
+	// successful invocations
+	Independent bool `protobuf:"varint,2,opt,name=independent,proto3" json:"independent,omitempty"`
+}
+
+func (x *CleartextChaincodeBatchRequest) Reset() {
+	*x = CleartextChaincodeBatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_fpc_fpc_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CleartextChaincodeBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CleartextChaincodeBatchRequest) ProtoMessage() {}
+
+func (x *CleartextChaincodeBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fpc_fpc_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CleartextChaincodeBatchRequest.ProtoReflect.Descriptor instead.
+func (*CleartextChaincodeBatchRequest) Descriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CleartextChaincodeBatchRequest) GetInputs() []*peer.ChaincodeInput {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *CleartextChaincodeBatchRequest) GetIndependent() bool {
+	if x != nil {
+		return x.Independent
+	}
+	return false
+}
+
+type CleartextChaincodeBatchResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the responses of the invocations, in the order of CleartextChaincodeBatchRequest.inputs
+	Responses []*peer.Response `protobuf:"bytes,1,rep,name=responses,proto3" json:"responses,omitempty"`
+}
+
+func (x *CleartextChaincodeBatchResponse) Reset() {
+	*x = CleartextChaincodeBatchResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_fpc_fpc_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CleartextChaincodeBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CleartextChaincodeBatchResponse) ProtoMessage() {}
+
+func (x *CleartextChaincodeBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fpc_fpc_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CleartextChaincodeBatchResponse.ProtoReflect.Descriptor instead.
+func (*CleartextChaincodeBatchResponse) Descriptor() ([]byte, []int) {
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CleartextChaincodeBatchResponse) GetResponses() []*peer.Response {
+	if x != nil {
+		return x.Responses
+	}
+	return nil
+}
+
 type ChaincodeRequestMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -456,7 +694,7 @@ type ChaincodeRequestMessage struct {
 func (x *ChaincodeRequestMessage) Reset() {
 	*x = ChaincodeRequestMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_fpc_fpc_proto_msgTypes[6]
+		mi := &file_fpc_fpc_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -469,7 +707,7 @@ func (x *ChaincodeRequestMessage) String() string {
 func (*ChaincodeRequestMessage) ProtoMessage() {}
 
 func (x *ChaincodeRequestMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[6]
+	mi := &file_fpc_fpc_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -482,7 +720,7 @@ func (x *ChaincodeRequestMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChaincodeRequestMessage.ProtoReflect.Descriptor instead.
 func (*ChaincodeRequestMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{6}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ChaincodeRequestMessage) GetEncryptedRequest() []byte {
@@ -513,7 +751,7 @@ type KeyTransportMessage struct {
 func (x *KeyTransportMessage) Reset() {
 	*x = KeyTransportMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_fpc_fpc_proto_msgTypes[7]
+		mi := &file_fpc_fpc_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -526,7 +764,7 @@ func (x *KeyTransportMessage) String() string {
 func (*KeyTransportMessage) ProtoMessage() {}
 
 func (x *KeyTransportMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[7]
+	mi := &file_fpc_fpc_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -539,7 +777,7 @@ func (x *KeyTransportMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KeyTransportMessage.ProtoReflect.Descriptor instead.
 func (*KeyTransportMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{7}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *KeyTransportMessage) GetRequestEncryptionKey() []byte {
@@ -568,7 +806,7 @@ type CleartextChaincodeResponse struct {
 func (x *CleartextChaincodeResponse) Reset() {
 	*x = CleartextChaincodeResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_fpc_fpc_proto_msgTypes[8]
+		mi := &file_fpc_fpc_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -581,7 +819,7 @@ func (x *CleartextChaincodeResponse) String() string {
 func (*CleartextChaincodeResponse) ProtoMessage() {}
 
 func (x *CleartextChaincodeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[8]
+	mi := &file_fpc_fpc_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -594,7 +832,7 @@ func (x *CleartextChaincodeResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CleartextChaincodeResponse.ProtoReflect.Descriptor instead.
 func (*CleartextChaincodeResponse) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{8}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *CleartextChaincodeResponse) GetResponse() *peer.Response {
@@ -618,7 +856,7 @@ type FPCKVSet struct {
 func (x *FPCKVSet) Reset() {
 	*x = FPCKVSet{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_fpc_fpc_proto_msgTypes[9]
+		mi := &file_fpc_fpc_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -631,7 +869,7 @@ func (x *FPCKVSet) String() string {
 func (*FPCKVSet) ProtoMessage() {}
 
 func (x *FPCKVSet) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[9]
+	mi := &file_fpc_fpc_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -644,7 +882,7 @@ func (x *FPCKVSet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FPCKVSet.ProtoReflect.Descriptor instead.
 func (*FPCKVSet) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{9}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *FPCKVSet) GetRwSet() *kvrwset.KVRWSet {
@@ -684,7 +922,7 @@ type ChaincodeResponseMessage struct {
 func (x *ChaincodeResponseMessage) Reset() {
 	*x = ChaincodeResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_fpc_fpc_proto_msgTypes[10]
+		mi := &file_fpc_fpc_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -697,7 +935,7 @@ func (x *ChaincodeResponseMessage) String() string {
 func (*ChaincodeResponseMessage) ProtoMessage() {}
 
 func (x *ChaincodeResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[10]
+	mi := &file_fpc_fpc_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -710,7 +948,7 @@ func (x *ChaincodeResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChaincodeResponseMessage.ProtoReflect.Descriptor instead.
 func (*ChaincodeResponseMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{10}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ChaincodeResponseMessage) GetEncryptedResponse() []byte {
@@ -762,7 +1000,7 @@ type SignedChaincodeResponseMessage struct {
 func (x *SignedChaincodeResponseMessage) Reset() {
 	*x = SignedChaincodeResponseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_fpc_fpc_proto_msgTypes[11]
+		mi := &file_fpc_fpc_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -775,7 +1013,7 @@ func (x *SignedChaincodeResponseMessage) String() string {
 func (*SignedChaincodeResponseMessage) ProtoMessage() {}
 
 func (x *SignedChaincodeResponseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_fpc_fpc_proto_msgTypes[11]
+	mi := &file_fpc_fpc_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -788,7 +1026,7 @@ func (x *SignedChaincodeResponseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SignedChaincodeResponseMessage.ProtoReflect.Descriptor instead.
 func (*SignedChaincodeResponseMessage) Descriptor() ([]byte, []int) {
-	return file_fpc_fpc_proto_rawDescGZIP(), []int{11}
+	return file_fpc_fpc_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *SignedChaincodeResponseMessage) GetChaincodeResponseMessage() []byte {
@@ -860,76 +1098,113 @@ var file_fpc_fpc_proto_rawDesc = []byte{
 	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61,
 	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65,
 	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x65, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65,
-	0x22, 0x68, 0x0a, 0x12, 0x49, 0x6e, 0x69, 0x74, 0x45, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x65,
-	0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70,
-	0x65, 0x65, 0x72, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x2d, 0x0a, 0x12, 0x61,
-	0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d,
-	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x11, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x22, 0x49, 0x0a, 0x19, 0x43, 0x6c,
-	0x65, 0x61, 0x72, 0x74, 0x65, 0x78, 0x74, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
-	0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x05,
-	0x69, 0x6e, 0x70, 0x75, 0x74, 0x22, 0x8d, 0x01, 0x0a, 0x17, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x63,
-	0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x12, 0x2b, 0x0a, 0x11, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x72,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x10, 0x65, 0x6e,
-	0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x45,
-	0x0a, 0x1f, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x6b, 0x65, 0x79, 0x5f,
-	0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x1c, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
-	0x65, 0x64, 0x4b, 0x65, 0x79, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x83, 0x01, 0x0a, 0x13, 0x4b, 0x65, 0x79, 0x54, 0x72, 0x61,
-	0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x34, 0x0a,
-	0x16, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
-	0x69, 0x6f, 0x6e, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x14, 0x72,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e,
-	0x4b, 0x65, 0x79, 0x12, 0x36, 0x0a, 0x17, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f,
-	0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x15, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x45, 0x6e,
-	0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x22, 0x4a, 0x0a, 0x1a, 0x43,
+	0x22, 0xd0, 0x02, 0x0a, 0x0c, 0x45, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x5f, 0x73, 0x6b, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x65, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x53, 0x6b,
+	0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x5f, 0x76, 0x6b, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x65, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x56, 0x6b, 0x12,
+	0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x5f, 0x64, 0x6b, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x09, 0x65, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x44, 0x6b, 0x12, 0x21,
+	0x0a, 0x0c, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x64, 0x6b, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x44,
+	0x6b, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x65,
+	0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f,
+	0x64, 0x65, 0x45, 0x6b, 0x12, 0x30, 0x0a, 0x14, 0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x65, 0x6e,
+	0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x12, 0x73, 0x74, 0x61, 0x74, 0x65, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x63, 0x5f, 0x70, 0x61, 0x72,
+	0x61, 0x6d, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x63, 0x63, 0x50, 0x61, 0x72,
+	0x61, 0x6d, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x70, 0x61, 0x72, 0x61,
+	0x6d, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x68, 0x6f, 0x73, 0x74, 0x50, 0x61,
+	0x72, 0x61, 0x6d, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x11, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x72,
+	0x61, 0x6d, 0x73, 0x22, 0x68, 0x0a, 0x12, 0x49, 0x6e, 0x69, 0x74, 0x45, 0x6e, 0x63, 0x6c, 0x61,
+	0x76, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x65, 0x65,
+	0x72, 0x5f, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0c, 0x70, 0x65, 0x65, 0x72, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x2d,
+	0x0a, 0x12, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x61,
+	0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x11, 0x61, 0x74, 0x74, 0x65,
+	0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x22, 0x84, 0x01,
+	0x0a, 0x19, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x74, 0x65, 0x78, 0x74, 0x43, 0x68, 0x61, 0x69, 0x6e,
+	0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x05, 0x69,
+	0x6e, 0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x73, 0x2e, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x70,
+	0x75, 0x74, 0x52, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x39, 0x0a, 0x05, 0x62, 0x61, 0x74,
+	0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x66, 0x70, 0x63, 0x2e, 0x43,
 	0x6c, 0x65, 0x61, 0x72, 0x74, 0x65, 0x78, 0x74, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64,
-	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x08, 0x72, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x08, 0x72,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x5f, 0x0a, 0x08, 0x46, 0x50, 0x43, 0x4b, 0x56,
-	0x53, 0x65, 0x74, 0x12, 0x27, 0x0a, 0x06, 0x72, 0x77, 0x5f, 0x73, 0x65, 0x74, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6b, 0x76, 0x72, 0x77, 0x73, 0x65, 0x74, 0x2e, 0x4b, 0x56,
-	0x52, 0x57, 0x53, 0x65, 0x74, 0x52, 0x05, 0x72, 0x77, 0x53, 0x65, 0x74, 0x12, 0x2a, 0x0a, 0x11,
-	0x72, 0x65, 0x61, 0x64, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x65,
-	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0f, 0x72, 0x65, 0x61, 0x64, 0x56, 0x61, 0x6c,
-	0x75, 0x65, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x8e, 0x02, 0x0a, 0x18, 0x43, 0x68, 0x61,
-	0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2d, 0x0a, 0x12, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
-	0x65, 0x64, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x11, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x0a, 0x66, 0x70, 0x63, 0x5f, 0x72, 0x77, 0x5f, 0x73,
-	0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x66, 0x70, 0x63, 0x2e, 0x46,
-	0x50, 0x43, 0x4b, 0x56, 0x53, 0x65, 0x74, 0x52, 0x08, 0x66, 0x70, 0x63, 0x52, 0x77, 0x53, 0x65,
-	0x74, 0x12, 0x32, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x53, 0x69, 0x67,
-	0x6e, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x52, 0x08, 0x70, 0x72, 0x6f,
-	0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x43, 0x0a, 0x1e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f,
-	0x64, 0x65, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x1b, 0x63,
-	0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e,
-	0x63, 0x6c, 0x61, 0x76, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
-	0x65, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x49, 0x64, 0x22, 0x7c, 0x0a, 0x1e, 0x53, 0x69, 0x67,
-	0x6e, 0x65, 0x64, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3c, 0x0a, 0x1a, 0x63,
-	0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x18, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67,
-	0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69,
-	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x42, 0x41, 0x5a, 0x3f, 0x67, 0x69, 0x74, 0x68, 0x75,
-	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x68, 0x79, 0x70, 0x65, 0x72, 0x6c, 0x65, 0x64, 0x67, 0x65,
-	0x72, 0x2f, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x2d, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65,
-	0x2d, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72,
-	0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+	0x65, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x05, 0x62,
+	0x61, 0x74, 0x63, 0x68, 0x22, 0x72, 0x0a, 0x1e, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x74, 0x65, 0x78,
+	0x74, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2e, 0x0a, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x06,
+	0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x69, 0x6e, 0x64, 0x65, 0x70, 0x65,
+	0x6e, 0x64, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x69, 0x6e, 0x64,
+	0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x74, 0x22, 0x51, 0x0a, 0x1f, 0x43, 0x6c, 0x65, 0x61,
+	0x72, 0x74, 0x65, 0x78, 0x74, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x61,
+	0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x09, 0x72,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x52, 0x09, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73, 0x22, 0x8d, 0x01, 0x0a, 0x17,
+	0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x65, 0x6e, 0x63, 0x72, 0x79,
+	0x70, 0x74, 0x65, 0x64, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x10, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x45, 0x0a, 0x1f, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65,
+	0x64, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x5f,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x1c, 0x65,
+	0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x54, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x83, 0x01, 0x0a, 0x13,
+	0x4b, 0x65, 0x79, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x34, 0x0a, 0x16, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x65,
+	0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x14, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x45, 0x6e, 0x63, 0x72,
+	0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x12, 0x36, 0x0a, 0x17, 0x72, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x15, 0x72, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x65,
+	0x79, 0x22, 0x4a, 0x0a, 0x1a, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x74, 0x65, 0x78, 0x74, 0x43, 0x68,
+	0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x2c, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x5f, 0x0a,
+	0x08, 0x46, 0x50, 0x43, 0x4b, 0x56, 0x53, 0x65, 0x74, 0x12, 0x27, 0x0a, 0x06, 0x72, 0x77, 0x5f,
+	0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6b, 0x76, 0x72, 0x77,
+	0x73, 0x65, 0x74, 0x2e, 0x4b, 0x56, 0x52, 0x57, 0x53, 0x65, 0x74, 0x52, 0x05, 0x72, 0x77, 0x53,
+	0x65, 0x74, 0x12, 0x2a, 0x0a, 0x11, 0x72, 0x65, 0x61, 0x64, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x5f, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0f, 0x72,
+	0x65, 0x61, 0x64, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x8e,
+	0x02, 0x0a, 0x18, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2d, 0x0a, 0x12, 0x65,
+	0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x11, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74,
+	0x65, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x0a, 0x66, 0x70,
+	0x63, 0x5f, 0x72, 0x77, 0x5f, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d,
+	0x2e, 0x66, 0x70, 0x63, 0x2e, 0x46, 0x50, 0x43, 0x4b, 0x56, 0x53, 0x65, 0x74, 0x52, 0x08, 0x66,
+	0x70, 0x63, 0x52, 0x77, 0x53, 0x65, 0x74, 0x12, 0x32, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x70, 0x6f,
+	0x73, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x73, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61,
+	0x6c, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x43, 0x0a, 0x1e, 0x63,
+	0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x1b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x61, 0x73, 0x68,
+	0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x6e, 0x63, 0x6c, 0x61, 0x76, 0x65, 0x49, 0x64, 0x22,
+	0x7c, 0x0a, 0x1e, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f,
+	0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x3c, 0x0a, 0x1a, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x72,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x18, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x42, 0x41, 0x5a,
+	0x3f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x68, 0x79, 0x70, 0x65,
+	0x72, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x2f, 0x66, 0x61, 0x62, 0x72, 0x69, 0x63, 0x2d, 0x70,
+	0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x2d, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x63, 0x6f, 0x64, 0x65,
+	0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -944,40 +1219,46 @@ func file_fpc_fpc_proto_rawDescGZIP() []byte {
 	return file_fpc_fpc_proto_rawDescData
 }
 
-var file_fpc_fpc_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_fpc_fpc_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_fpc_fpc_proto_goTypes = []interface{}{
-	(*CCParameters)(nil),                   // 0: fpc.CCParameters
-	(*HostParameters)(nil),                 // 1: fpc.HostParameters
-	(*AttestedData)(nil),                   // 2: fpc.AttestedData
-	(*Credentials)(nil),                    // 3: fpc.Credentials
-	(*InitEnclaveMessage)(nil),             // 4: fpc.InitEnclaveMessage
-	(*CleartextChaincodeRequest)(nil),      // 5: fpc.CleartextChaincodeRequest
-	(*ChaincodeRequestMessage)(nil),        // 6: fpc.ChaincodeRequestMessage
-	(*KeyTransportMessage)(nil),            // 7: fpc.KeyTransportMessage
-	(*CleartextChaincodeResponse)(nil),     // 8: fpc.CleartextChaincodeResponse
-	(*FPCKVSet)(nil),                       // 9: fpc.FPCKVSet
-	(*ChaincodeResponseMessage)(nil),       // 10: fpc.ChaincodeResponseMessage
-	(*SignedChaincodeResponseMessage)(nil), // 11: fpc.SignedChaincodeResponseMessage
-	(*anypb.Any)(nil),                      // 12: google.protobuf.Any
-	(*peer.ChaincodeInput)(nil),            // 13: protos.ChaincodeInput
-	(*peer.Response)(nil),                  // 14: protos.Response
-	(*kvrwset.KVRWSet)(nil),                // 15: kvrwset.KVRWSet
-	(*peer.SignedProposal)(nil),            // 16: protos.SignedProposal
+	(*CCParameters)(nil),                    // 0: fpc.CCParameters
+	(*HostParameters)(nil),                  // 1: fpc.HostParameters
+	(*AttestedData)(nil),                    // 2: fpc.AttestedData
+	(*Credentials)(nil),                     // 3: fpc.Credentials
+	(*EnclaveState)(nil),                    // 4: fpc.EnclaveState
+	(*InitEnclaveMessage)(nil),              // 5: fpc.InitEnclaveMessage
+	(*CleartextChaincodeRequest)(nil),       // 6: fpc.CleartextChaincodeRequest
+	(*CleartextChaincodeBatchRequest)(nil),  // 7: fpc.CleartextChaincodeBatchRequest
+	(*CleartextChaincodeBatchResponse)(nil), // 8: fpc.CleartextChaincodeBatchResponse
+	(*ChaincodeRequestMessage)(nil),         // 9: fpc.ChaincodeRequestMessage
+	(*KeyTransportMessage)(nil),             // 10: fpc.KeyTransportMessage
+	(*CleartextChaincodeResponse)(nil),      // 11: fpc.CleartextChaincodeResponse
+	(*FPCKVSet)(nil),                        // 12: fpc.FPCKVSet
+	(*ChaincodeResponseMessage)(nil),        // 13: fpc.ChaincodeResponseMessage
+	(*SignedChaincodeResponseMessage)(nil),  // 14: fpc.SignedChaincodeResponseMessage
+	(*anypb.Any)(nil),                       // 15: google.protobuf.Any
+	(*peer.ChaincodeInput)(nil),             // 16: protos.ChaincodeInput
+	(*peer.Response)(nil),                   // 17: protos.Response
+	(*kvrwset.KVRWSet)(nil),                 // 18: kvrwset.KVRWSet
+	(*peer.SignedProposal)(nil),             // 19: protos.SignedProposal
 }
 var file_fpc_fpc_proto_depIdxs = []int32{
 	0,  // 0: fpc.AttestedData.cc_params:type_name -> fpc.CCParameters
 	1,  // 1: fpc.AttestedData.host_params:type_name -> fpc.HostParameters
-	12, // 2: fpc.Credentials.serialized_attested_data:type_name -> google.protobuf.Any
-	13, // 3: fpc.CleartextChaincodeRequest.input:type_name -> protos.ChaincodeInput
-	14, // 4: fpc.CleartextChaincodeResponse.response:type_name -> protos.Response
-	15, // 5: fpc.FPCKVSet.rw_set:type_name -> kvrwset.KVRWSet
-	9,  // 6: fpc.ChaincodeResponseMessage.fpc_rw_set:type_name -> fpc.FPCKVSet
-	16, // 7: fpc.ChaincodeResponseMessage.proposal:type_name -> protos.SignedProposal
-	8,  // [8:8] is the sub-list for method output_type
-	8,  // [8:8] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	15, // 2: fpc.Credentials.serialized_attested_data:type_name -> google.protobuf.Any
+	16, // 3: fpc.CleartextChaincodeRequest.input:type_name -> protos.ChaincodeInput
+	7,  // 4: fpc.CleartextChaincodeRequest.batch:type_name -> fpc.CleartextChaincodeBatchRequest
+	16, // 5: fpc.CleartextChaincodeBatchRequest.inputs:type_name -> protos.ChaincodeInput
+	17, // 6: fpc.CleartextChaincodeBatchResponse.responses:type_name -> protos.Response
+	17, // 7: fpc.CleartextChaincodeResponse.response:type_name -> protos.Response
+	18, // 8: fpc.FPCKVSet.rw_set:type_name -> kvrwset.KVRWSet
+	12, // 9: fpc.ChaincodeResponseMessage.fpc_rw_set:type_name -> fpc.FPCKVSet
+	19, // 10: fpc.ChaincodeResponseMessage.proposal:type_name -> protos.SignedProposal
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_fpc_fpc_proto_init() }
@@ -1035,7 +1316,7 @@ func file_fpc_fpc_proto_init() {
 			}
 		}
 		file_fpc_fpc_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*InitEnclaveMessage); i {
+			switch v := v.(*EnclaveState); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1047,7 +1328,7 @@ func file_fpc_fpc_proto_init() {
 			}
 		}
 		file_fpc_fpc_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CleartextChaincodeRequest); i {
+			switch v := v.(*InitEnclaveMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1059,7 +1340,7 @@ func file_fpc_fpc_proto_init() {
 			}
 		}
 		file_fpc_fpc_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ChaincodeRequestMessage); i {
+			switch v := v.(*CleartextChaincodeRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1071,7 +1352,7 @@ func file_fpc_fpc_proto_init() {
 			}
 		}
 		file_fpc_fpc_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KeyTransportMessage); i {
+			switch v := v.(*CleartextChaincodeBatchRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1083,7 +1364,7 @@ func file_fpc_fpc_proto_init() {
 			}
 		}
 		file_fpc_fpc_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CleartextChaincodeResponse); i {
+			switch v := v.(*CleartextChaincodeBatchResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1095,7 +1376,7 @@ func file_fpc_fpc_proto_init() {
 			}
 		}
 		file_fpc_fpc_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*FPCKVSet); i {
+			switch v := v.(*ChaincodeRequestMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1107,7 +1388,7 @@ func file_fpc_fpc_proto_init() {
 			}
 		}
 		file_fpc_fpc_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ChaincodeResponseMessage); i {
+			switch v := v.(*KeyTransportMessage); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1119,6 +1400,42 @@ func file_fpc_fpc_proto_init() {
 			}
 		}
 		file_fpc_fpc_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CleartextChaincodeResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_fpc_fpc_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FPCKVSet); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_fpc_fpc_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChaincodeResponseMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_fpc_fpc_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SignedChaincodeResponseMessage); i {
 			case 0:
 				return &v.state
@@ -1137,7 +1454,7 @@ func file_fpc_fpc_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_fpc_fpc_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
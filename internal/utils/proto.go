@@ -233,6 +233,39 @@ func GetChaincodeRequestMessageFromSignedProposal(signedProposal *pb.SignedPropo
 	return chaincodeRequestMessageBytes, nil
 }
 
+// GetTxIdAndCreatorFromSignedProposal returns the transaction id and the serialized creator identity of the given signed proposal
+func GetTxIdAndCreatorFromSignedProposal(signedProposal *pb.SignedProposal) (txId string, creator []byte, e error) {
+	if signedProposal == nil {
+		return "", nil, fmt.Errorf("no signed proposal to parse")
+	}
+
+	proposal, err := protoutil.UnmarshalProposal(signedProposal.ProposalBytes)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to extract Proposal from SignedProposal: %s", err)
+	}
+
+	hdr, err := protoutil.UnmarshalHeader(proposal.GetHeader())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to extract proposal header: %s", err)
+	}
+
+	chdr, err := protoutil.UnmarshalChannelHeader(hdr.GetChannelHeader())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to extract channel header: %s", err)
+	}
+
+	shdr, err := protoutil.UnmarshalSignatureHeader(hdr.GetSignatureHeader())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to extract signature header: %s", err)
+	}
+
+	if chdr.GetTxId() == "" {
+		return "", nil, fmt.Errorf("proposal has no transaction id")
+	}
+
+	return chdr.GetTxId(), shdr.GetCreator(), nil
+}
+
 // UnwrapResponse unmarshalls the given serialized peer.Response message and returns the Payload field if Status is 200;
 // otherwise, the Message field is returned as an error
 func UnwrapResponse(responseBytes []byte) (payload []byte, err error) {
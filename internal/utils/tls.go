@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/pkg/errors"
+)
+
+// Environment variables for the TLS configuration of a chaincode server (chaincode-as-a-service), following the
+// conventions of the Fabric samples
+const (
+	ChaincodeTLSDisabledEnv  = "CHAINCODE_TLS_DISABLED"
+	ChaincodeTLSKeyEnv       = "CHAINCODE_TLS_KEY"
+	ChaincodeTLSCertEnv      = "CHAINCODE_TLS_CERT"
+	ChaincodeClientCACertEnv = "CHAINCODE_CLIENT_CA_CERT"
+)
+
+// GetChaincodeServerTLSProperties returns the TLS properties of a chaincode server from the environment.
+// TLS is enabled if CHAINCODE_TLS_DISABLED is set to false or, if CHAINCODE_TLS_DISABLED is not set, if any of the
+// TLS files is configured; it is only disabled if CHAINCODE_TLS_DISABLED is set to true or nothing is configured.
+// If enabled, the server key and certificate (CHAINCODE_TLS_KEY, CHAINCODE_TLS_CERT) and the CA certificate of the
+// peer client certificates (CHAINCODE_CLIENT_CA_CERT) are read from the given PEM files, and the server requires
+// mutual TLS. An error is returned if only some of the files are configured.
+func GetChaincodeServerTLSProperties() (shim.TLSProperties, error) {
+	if s := os.Getenv(ChaincodeTLSDisabledEnv); s != "" {
+		disabled, err := strconv.ParseBool(s)
+		if err != nil {
+			return shim.TLSProperties{}, errors.Wrapf(err, "invalid value for %s", ChaincodeTLSDisabledEnv)
+		}
+		if disabled {
+			return shim.TLSProperties{Disabled: true}, nil
+		}
+	} else if !tlsFilesConfigured() {
+		return shim.TLSProperties{Disabled: true}, nil
+	}
+
+	key, err := readPEMFileFromEnv(ChaincodeTLSKeyEnv)
+	if err != nil {
+		return shim.TLSProperties{}, err
+	}
+	cert, err := readPEMFileFromEnv(ChaincodeTLSCertEnv)
+	if err != nil {
+		return shim.TLSProperties{}, err
+	}
+	clientCACerts, err := readPEMFileFromEnv(ChaincodeClientCACertEnv)
+	if err != nil {
+		return shim.TLSProperties{}, err
+	}
+
+	// fail at startup rather than with the first connection of the peer
+	if _, err := tls.X509KeyPair(cert, key); err != nil {
+		return shim.TLSProperties{}, errors.Wrapf(err, "invalid TLS key pair (%s, %s)", os.Getenv(ChaincodeTLSKeyEnv), os.Getenv(ChaincodeTLSCertEnv))
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(clientCACerts) {
+		return shim.TLSProperties{}, fmt.Errorf("no valid certificate in client CA file %s", os.Getenv(ChaincodeClientCACertEnv))
+	}
+
+	return shim.TLSProperties{
+		Disabled:      false,
+		Key:           key,
+		Cert:          cert,
+		ClientCACerts: clientCACerts,
+	}, nil
+}
+
+// tlsFilesConfigured returns true if any of the TLS files of a chaincode server is configured
+func tlsFilesConfigured() bool {
+	for _, key := range []string{ChaincodeTLSKeyEnv, ChaincodeTLSCertEnv, ChaincodeClientCACertEnv} {
+		if os.Getenv(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func readPEMFileFromEnv(key string) ([]byte, error) {
+	path := os.Getenv(key)
+	if path == "" {
+		return nil, fmt.Errorf("%s must be set if TLS is enabled", key)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s (%s)", path, key)
+	}
+	return data, nil
+}
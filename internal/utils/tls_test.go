@@ -0,0 +1,173 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// writeSelfSignedCert writes a self-signed certificate and its key as PEM files into dir
+func writeSelfSignedCert(dir string) (keyPath, certPath string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ShouldNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ecc"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ShouldNot(HaveOccurred())
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	keyPath = filepath.Join(dir, "server.key")
+	certPath = filepath.Join(dir, "server.crt")
+	Expect(os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+	Expect(os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600)).To(Succeed())
+	return keyPath, certPath
+}
+
+var _ = Describe("Chaincode server TLS", func() {
+
+	var (
+		dir      string
+		keyPath  string
+		certPath string
+	)
+
+	setEnv := func(key, value string) {
+		Expect(os.Setenv(key, value)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "tls")
+		Expect(err).ShouldNot(HaveOccurred())
+		keyPath, certPath = writeSelfSignedCert(dir)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		for _, key := range []string{ChaincodeTLSDisabledEnv, ChaincodeTLSKeyEnv, ChaincodeTLSCertEnv, ChaincodeClientCACertEnv} {
+			os.Unsetenv(key)
+		}
+	})
+
+	When("TLS is not enabled", func() {
+		It("should disable TLS", func() {
+			setEnv(ChaincodeTLSDisabledEnv, "")
+			props, err := GetChaincodeServerTLSProperties()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(props.Disabled).To(BeTrue())
+		})
+
+		It("should disable TLS if explicitly disabled", func() {
+			setEnv(ChaincodeTLSDisabledEnv, "true")
+			setEnv(ChaincodeTLSKeyEnv, keyPath)
+			setEnv(ChaincodeTLSCertEnv, certPath)
+			setEnv(ChaincodeClientCACertEnv, certPath)
+			props, err := GetChaincodeServerTLSProperties()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(props.Disabled).To(BeTrue())
+		})
+	})
+
+	When("the TLS files are configured", func() {
+		BeforeEach(func() {
+			setEnv(ChaincodeTLSKeyEnv, keyPath)
+			setEnv(ChaincodeTLSCertEnv, certPath)
+			setEnv(ChaincodeClientCACertEnv, certPath)
+		})
+
+		It("should enable mutual TLS", func() {
+			props, err := GetChaincodeServerTLSProperties()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(props.Disabled).To(BeFalse())
+			Expect(props.Key).NotTo(BeEmpty())
+			Expect(props.Cert).NotTo(BeEmpty())
+			Expect(props.ClientCACerts).To(Equal(props.Cert))
+		})
+
+		It("should fail if only key and cert are configured", func() {
+			os.Unsetenv(ChaincodeClientCACertEnv)
+			_, err := GetChaincodeServerTLSProperties()
+			Expect(err).To(MatchError("CHAINCODE_CLIENT_CA_CERT must be set if TLS is enabled"))
+		})
+
+		It("should fail if only the key is configured", func() {
+			os.Unsetenv(ChaincodeTLSCertEnv)
+			os.Unsetenv(ChaincodeClientCACertEnv)
+			_, err := GetChaincodeServerTLSProperties()
+			Expect(err).To(MatchError("CHAINCODE_TLS_CERT must be set if TLS is enabled"))
+		})
+	})
+
+	When("CHAINCODE_TLS_DISABLED is invalid", func() {
+		It("should return an error", func() {
+			setEnv(ChaincodeTLSDisabledEnv, "maybe")
+			_, err := GetChaincodeServerTLSProperties()
+			Expect(err).To(MatchError(ContainSubstring("invalid value for CHAINCODE_TLS_DISABLED")))
+		})
+	})
+
+	When("TLS is enabled", func() {
+		BeforeEach(func() {
+			setEnv(ChaincodeTLSDisabledEnv, "false")
+			setEnv(ChaincodeTLSKeyEnv, keyPath)
+			setEnv(ChaincodeTLSCertEnv, certPath)
+			setEnv(ChaincodeClientCACertEnv, certPath)
+		})
+
+		It("should return the key, cert and client CA certs", func() {
+			props, err := GetChaincodeServerTLSProperties()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(props.Disabled).To(BeFalse())
+			Expect(props.Key).NotTo(BeEmpty())
+			Expect(props.Cert).NotTo(BeEmpty())
+			Expect(props.ClientCACerts).To(Equal(props.Cert))
+		})
+
+		It("should fail if the client CA is not set", func() {
+			setEnv(ChaincodeClientCACertEnv, "")
+			_, err := GetChaincodeServerTLSProperties()
+			Expect(err).To(MatchError("CHAINCODE_CLIENT_CA_CERT must be set if TLS is enabled"))
+		})
+
+		It("should fail if the key file is missing", func() {
+			setEnv(ChaincodeTLSKeyEnv, keyPath+".missing")
+			_, err := GetChaincodeServerTLSProperties()
+			Expect(err).To(MatchError(ContainSubstring("cannot read " + keyPath + ".missing (CHAINCODE_TLS_KEY)")))
+		})
+
+		It("should fail if key and cert do not match", func() {
+			setEnv(ChaincodeTLSKeyEnv, certPath)
+			_, err := GetChaincodeServerTLSProperties()
+			Expect(err).To(MatchError(ContainSubstring("invalid TLS key pair")))
+		})
+
+		It("should fail if the client CA file contains no certificate", func() {
+			setEnv(ChaincodeClientCACertEnv, keyPath)
+			_, err := GetChaincodeServerTLSProperties()
+			Expect(err).To(MatchError(ContainSubstring("no valid certificate in client CA file")))
+		})
+	})
+})
@@ -128,7 +128,7 @@ int invoke(
     {
         // ouch error
         LOG_ERROR("AuctionCC: Response buffer too small");
-        *actual_response_len = 0;
+        *actual_response_len = neededSize;
         return -1;
     }
 
@@ -110,7 +110,7 @@ int invoke(
     {
         // ouch error
         LOG_ERROR("Response buffer too small");
-        *actual_response_len = 0;
+        *actual_response_len = neededSize;
         return -1;
     }
     memcpy(response, result.c_str(), neededSize);
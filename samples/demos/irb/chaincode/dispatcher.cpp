@@ -61,7 +61,9 @@ Contract::Dispatcher::Dispatcher(const std::string& functionName,
         LOG_ERROR("Response string too long to be output");
         CUSTOM_ERROR_REPORT(
             errorReport_, EC_SHORT_RESPONSE_BUFFER, "Response string too long to be output");
-        errorReport_.toStatusProtoString(responseString_);
+        // report the required size, so the invocation is retried with a larger buffer (see shim.h)
+        *actual_response_len_ = responseString_.length();
+        return;
     }
 
     // write response string (if possible)
@@ -0,0 +1,315 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/simulation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+)
+
+func printHelp() {
+	fmt.Fprintf(flag.CommandLine.Output(),
+		`Usage: %s [-mrenclave <hex>] [-format text|json] [-policy <file>] [<file>]
+Decodes (base64-encoded) Credentials protobufs, prints the attested data and verifies the attestation evidence.
+The credentials are read from <file> or, if not given, from stdin. The input is either a single credentials string,
+as returned by ercc.QueryEnclaveCredentials, or a json array of credentials strings, as returned by
+ercc.QueryListEnclaveCredentials.
+Without -mrenclave, the credentials are only checked against their own version, thus, they do not pass.
+Simulated credentials carry no proof and are flagged as such, even if the policy allows them.
+The exit code is 0 if all credentials pass the verification, 1 if any fails, and 2 for invalid input.
+`,
+		os.Args[0])
+	flag.PrintDefaults()
+}
+
+// Report is the inspection and verification result of a single credentials string
+type Report struct {
+	EnclaveId          string      `json:"enclave_id"`
+	CcParams           *CcParams   `json:"cc_params"`
+	HostParams         *HostParams `json:"host_params"`
+	EnclaveVkSha256    string      `json:"enclave_vk_sha256"`
+	ChaincodeEkSha256  string      `json:"chaincode_ek_sha256"`
+	ChannelHash        string      `json:"channel_hash"`
+	TlccMrenclave      string      `json:"tlcc_mrenclave,omitempty"`
+	AttestationType    string      `json:"attestation_type"`
+	ExpectedMrenclave  string      `json:"expected_mrenclave"`
+	MrenclavePinned    bool        `json:"mrenclave_pinned"`
+	Simulated          bool        `json:"simulated"`
+	VerificationResult *Result     `json:"verification_result,omitempty"`
+	Passed             bool        `json:"passed"`
+	Errors             []string    `json:"errors,omitempty"`
+}
+
+type CcParams struct {
+	ChaincodeId string `json:"chaincode_id"`
+	Version     string `json:"version"`
+	Sequence    int64  `json:"sequence"`
+	ChannelId   string `json:"channel_id"`
+}
+
+type HostParams struct {
+	PeerMspId        string `json:"peer_msp_id"`
+	PeerEndpoint     string `json:"peer_endpoint"`
+	CertificateBytes int    `json:"certificate_bytes"`
+}
+
+type Result struct {
+	Status      string     `json:"status"`
+	AdvisoryIDs []string   `json:"advisory_ids,omitempty"`
+	Timestamp   *time.Time `json:"timestamp,omitempty"`
+	Debug       bool       `json:"debug"`
+}
+
+func main() {
+	mrenclave := flag.String("mrenclave", "", "expected mrenclave (hex); without it, the credentials are checked against their own version and do not pass")
+	format := flag.String("format", "text", "output format, text or json")
+	policyPath := flag.String("policy", "", "verification policy file; defaults to $"+attestation.VerificationPolicyEnv+" or the default policy")
+	flag.Usage = printHelp
+	flag.Parse()
+
+	if *format != "text" && *format != "json" {
+		fail("unknown format '%s'", *format)
+	}
+
+	var in io.Reader = os.Stdin
+	if flag.NArg() > 1 {
+		fail("expected at most one input file")
+	} else if flag.NArg() == 1 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fail("couldn't open input: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	input, err := io.ReadAll(in)
+	if err != nil {
+		fail("couldn't read input: %v", err)
+	}
+
+	credentialsList, err := parseInput(input)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	var policy *attestation.VerificationPolicy
+	if len(*policyPath) > 0 {
+		policy, err = attestation.LoadVerificationPolicy(*policyPath)
+	} else {
+		policy, err = attestation.VerificationPolicyFromEnv()
+	}
+	if err != nil {
+		fail("couldn't load verification policy: %v", err)
+	}
+	// the enclave registry accepts simulated credentials if its policy allows them, so we inspect them too
+	verifier := attestation.NewCredentialVerifierWithSimulation(policy)
+
+	reports := make([]*Report, len(credentialsList))
+	passed := true
+	for i, c := range credentialsList {
+		reports[i] = inspect(verifier, c, *mrenclave, time.Now())
+		passed = passed && reports[i].Passed
+	}
+
+	if *format == "json" {
+		out, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			fail("couldn't marshal report: %v", err)
+		}
+		fmt.Println(string(out))
+	} else {
+		for i, r := range reports {
+			fmt.Printf("Credentials %d/%d\n", i+1, len(reports))
+			printText(r)
+		}
+	}
+
+	if !passed {
+		os.Exit(1)
+	}
+}
+
+func fail(format string, a ...any) {
+	fmt.Fprintf(os.Stderr, "ERROR: "+format+"\n", a...)
+	os.Exit(2)
+}
+
+// parseInput returns the credentials strings of the input, which is either a single (possibly json-quoted)
+// credentials string or a json array of credentials strings
+func parseInput(input []byte) ([]string, error) {
+	s := strings.TrimSpace(string(input))
+	if len(s) == 0 {
+		return nil, fmt.Errorf("no credentials")
+	}
+
+	switch s[0] {
+	case '[':
+		var l []string
+		if err := json.Unmarshal([]byte(s), &l); err != nil {
+			return nil, fmt.Errorf("invalid json array of credentials: %v", err)
+		}
+		if len(l) == 0 {
+			return nil, fmt.Errorf("no credentials")
+		}
+		return l, nil
+	case '"':
+		var c string
+		if err := json.Unmarshal([]byte(s), &c); err != nil {
+			return nil, fmt.Errorf("invalid json string: %v", err)
+		}
+		return []string{c}, nil
+	}
+	return []string{s}, nil
+}
+
+// inspect decodes the credentials and verifies them against the expected mrenclave, performing the same checks on
+// the attested data as the enclave registry does
+func inspect(verifier *attestation.CredentialVerifier, credentialsBase64, expectedMrenclave string, now time.Time) *Report {
+	r := &Report{ExpectedMrenclave: expectedMrenclave, MrenclavePinned: len(expectedMrenclave) > 0}
+
+	credentials, err := utils.UnmarshalCredentials(credentialsBase64)
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+		return r
+	}
+
+	attestedData, err := utils.UnmarshalAttestedData(credentials.GetSerializedAttestedData())
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+		return r
+	}
+
+	r.EnclaveId = utils.GetEnclaveId(attestedData)
+	r.CcParams = &CcParams{
+		ChaincodeId: attestedData.GetCcParams().GetChaincodeId(),
+		Version:     attestedData.GetCcParams().GetVersion(),
+		Sequence:    attestedData.GetCcParams().GetSequence(),
+		ChannelId:   attestedData.GetCcParams().GetChannelId(),
+	}
+	r.HostParams = &HostParams{
+		PeerMspId:        attestedData.GetHostParams().GetPeerMspId(),
+		PeerEndpoint:     attestedData.GetHostParams().GetPeerEndpoint(),
+		CertificateBytes: len(attestedData.GetHostParams().GetCertificate()),
+	}
+	r.EnclaveVkSha256 = fingerprint(attestedData.GetEnclaveVk())
+	r.ChaincodeEkSha256 = fingerprint(attestedData.GetChaincodeEk())
+	r.ChannelHash = hex.EncodeToString(attestedData.GetChannelHash())
+	r.TlccMrenclave = attestedData.GetTlccMrenclave()
+
+	evidence := &struct {
+		Type string `json:"attestation_type"`
+	}{}
+	if len(credentials.GetEvidence()) == 0 {
+		r.Errors = append(r.Errors, "evidence is empty")
+	} else if err := json.Unmarshal(credentials.GetEvidence(), evidence); err == nil {
+		r.AttestationType = evidence.Type
+	}
+
+	if !r.MrenclavePinned {
+		// the credentials can claim any version, so this only checks the evidence for consistency
+		r.ExpectedMrenclave = r.CcParams.Version
+		r.Errors = append(r.Errors, "mrenclave not pinned, use -mrenclave to check the credentials against the expected mrenclave")
+	} else if r.CcParams.Version != r.ExpectedMrenclave {
+		r.Errors = append(r.Errors, fmt.Sprintf("mrenclave does not match, expected %s but got %s", r.ExpectedMrenclave, r.CcParams.Version))
+	}
+	if len(attestedData.GetEnclaveVk()) == 0 {
+		r.Errors = append(r.Errors, "no enclave verification key")
+	}
+	if len(attestedData.GetChaincodeEk()) == 0 {
+		r.Errors = append(r.Errors, "no chaincode encryption key")
+	}
+	if err := utils.ValidateEndpoint(r.HostParams.PeerEndpoint); err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("invalid peer endpoint: %v", err))
+	}
+
+	result, err := verifier.EvaluateCredentials(credentials, r.ExpectedMrenclave, now)
+	if result != nil {
+		r.Simulated = result.Status == simulation.SimulatedStatus
+		r.VerificationResult = &Result{
+			Status:      result.Status,
+			AdvisoryIDs: result.AdvisoryIDs,
+			Debug:       result.Debug,
+		}
+		if !result.Timestamp.IsZero() {
+			r.VerificationResult.Timestamp = &result.Timestamp
+		}
+	}
+	if err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("evidence verification failed: %v", err))
+	}
+
+	r.Passed = len(r.Errors) == 0
+	return r
+}
+
+func fingerprint(key []byte) string {
+	if len(key) == 0 {
+		return ""
+	}
+	h := sha256.Sum256(key)
+	return hex.EncodeToString(h[:])
+}
+
+func printText(r *Report) {
+	line := func(name string, value any) {
+		fmt.Printf("  %-22s %v\n", name+":", value)
+	}
+
+	if r.CcParams != nil {
+		line("enclave id", r.EnclaveId)
+		line("chaincode id", r.CcParams.ChaincodeId)
+		line("version (mrenclave)", r.CcParams.Version)
+		line("sequence", r.CcParams.Sequence)
+		line("channel id", r.CcParams.ChannelId)
+		line("peer msp id", r.HostParams.PeerMspId)
+		line("peer endpoint", r.HostParams.PeerEndpoint)
+		line("enclave vk sha256", r.EnclaveVkSha256)
+		line("chaincode ek sha256", r.ChaincodeEkSha256)
+		line("channel hash", r.ChannelHash)
+		if len(r.TlccMrenclave) > 0 {
+			line("tlcc mrenclave", r.TlccMrenclave)
+		}
+		line("attestation type", r.AttestationType)
+		line("expected mrenclave", r.ExpectedMrenclave)
+	}
+	if r.Simulated {
+		fmt.Println("  WARNING: SIMULATED credentials, the evidence is not verified and can be forged by anyone")
+	}
+	if r.VerificationResult != nil {
+		line("status", r.VerificationResult.Status)
+		if len(r.VerificationResult.AdvisoryIDs) > 0 {
+			line("advisory ids", strings.Join(r.VerificationResult.AdvisoryIDs, ", "))
+		}
+		if r.VerificationResult.Timestamp != nil {
+			line("issued at", r.VerificationResult.Timestamp.Format(time.RFC3339))
+		}
+		line("debug enclave", r.VerificationResult.Debug)
+	}
+
+	if r.Passed && r.Simulated {
+		line("verification", "PASS (SIMULATED, allowed by policy)")
+		return
+	} else if r.Passed {
+		line("verification", "PASS")
+		return
+	}
+	line("verification", "FAIL")
+	for _, e := range r.Errors {
+		fmt.Printf("    - %s\n", e)
+	}
+}
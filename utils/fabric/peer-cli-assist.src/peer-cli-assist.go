@@ -9,6 +9,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -128,12 +129,12 @@ func handleEncryptedRequestAndResponse(chaincodeEncryptionKey string, resultPipe
 	// setup crypto context
 	ep := &crypto.EncryptionProviderImpl{
 		CSP: crypto.GetDefaultCSP(),
-		GetCcEncryptionKey: func() ([]byte, error) {
+		GetCcEncryptionKey: func(context.Context) ([]byte, error) {
 			// TODO: might have to do some re-formatting, e.g., de-hex, here?
 			return []byte(chaincodeEncryptionKey), nil
 		}}
 
-	ctx, err := ep.NewEncryptionContext()
+	ctx, err := ep.NewEncryptionContext(context.Background())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: could not setup crypto context: %v\n", err)
 		os.Exit(1)
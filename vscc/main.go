@@ -0,0 +1,22 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/vscc/plugin"
+	validation "github.com/hyperledger/fabric/core/handlers/validation/api"
+)
+
+// go build -buildmode=plugin -o fpc-vscc.so
+
+// NewPluginFactory is the function ran by the plugin infrastructure to create a validation plugin factory.
+func NewPluginFactory() validation.PluginFactory {
+	return &plugin.ValidationFactory{}
+}
+
+func main() {
+}
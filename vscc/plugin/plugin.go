@@ -0,0 +1,179 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package plugin implements a Fabric custom validation plugin (VSCC) for FPC chaincodes.
+//
+// In addition to the endorsement policy checks performed by Fabric's builtin validation plugin, this plugin
+// verifies at commit time that the transaction carries a valid enclave endorsement. That is, it checks that
+//   - the enclave credentials are registered at ERCC (as found in the committed ERCC state),
+//   - the credentials match the committed chaincode definition of the FPC chaincode,
+//   - the enclave signature over the chaincode response message is valid,
+//   - the chaincode request message hash matches the request in the embedded proposal,
+//   - the transaction read/write set corresponds to the FPC read/write set signed by the enclave, and
+//   - the transaction is submitted by the creator of the original proposal; `__endorse` transactions record the
+//     proposal's transaction id, so that a response cannot be endorsed twice, and directly submitted `__invoke`
+//     transactions must have the transaction id of the proposal, so that a response cannot be replayed in another
+//     transaction.
+//
+// As these checks are performed by every committing peer, the `__endorse` re-execution of ECC becomes optional
+// and clients may directly submit the endorsements of an `__invoke` transaction.
+package plugin
+
+import (
+	"bytes"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/flogging"
+	validation "github.com/hyperledger/fabric/core/handlers/validation/api"
+	vs "github.com/hyperledger/fabric/core/handlers/validation/api/state"
+	"github.com/hyperledger/fabric/core/handlers/validation/builtin"
+	"github.com/pkg/errors"
+)
+
+var logger = flogging.MustGetLogger("fpc.vscc")
+
+const (
+	invokeFunction  = "__invoke"
+	endorseFunction = "__endorse"
+)
+
+// ValidationFactory creates FPC validation plugin instances
+type ValidationFactory struct{}
+
+// New returns a new FPC validation plugin that uses Fabric's builtin validation for endorsement policy evaluation
+func (*ValidationFactory) New() validation.Plugin {
+	return &Validation{
+		DefaultValidation: &builtin.DefaultValidation{},
+		Validator:         endorsement.NewValidator(),
+	}
+}
+
+// Validation implements the validation.Plugin interface for FPC transactions
+type Validation struct {
+	DefaultValidation validation.Plugin
+	Validator         endorsement.Validation
+	StateFetcher      vs.StateFetcher
+}
+
+// Init injects dependencies into the plugin. All dependencies are also passed to the default validation plugin.
+func (v *Validation) Init(dependencies ...validation.Dependency) error {
+	for _, dep := range dependencies {
+		if stateFetcher, isStateFetcher := dep.(vs.StateFetcher); isStateFetcher {
+			v.StateFetcher = stateFetcher
+		}
+	}
+	if v.StateFetcher == nil {
+		return errors.New("stateFetcher not passed in init")
+	}
+
+	return v.DefaultValidation.Init(dependencies...)
+}
+
+// Validate returns nil if the action at the given position inside the transaction at the given position in the given
+// block is a valid FPC transaction, or an error if not.
+func (v *Validation) Validate(block *common.Block, namespace string, txPosition int, actionPosition int, contextData ...validation.ContextDatum) error {
+	// first, the "normal" Fabric endorsement policy must be satisfied
+	if err := v.DefaultValidation.Validate(block, namespace, txPosition, actionPosition, contextData...); err != nil {
+		return err
+	}
+
+	tx, err := extractTransaction(block, namespace, txPosition, actionPosition)
+	if err != nil {
+		return errors.Wrap(err, "invalid FPC transaction")
+	}
+
+	state, err := v.StateFetcher.FetchState()
+	if err != nil {
+		return &validation.ExecutionFailureError{Reason: errors.Wrap(err, "cannot fetch state").Error()}
+	}
+	defer state.Done()
+
+	if err := v.validateTransaction(state, namespace, tx); err != nil {
+		logger.Debugf("block %d, namespace: %s, tx %d FPC validation failed: %s", block.Header.Number, namespace, txPosition, err)
+		return err
+	}
+
+	logger.Debugf("block %d, namespace: %s, tx %d FPC validation successful", block.Header.Number, namespace, txPosition)
+	return nil
+}
+
+func (v *Validation) validateTransaction(state vs.State, namespace string, tx *transaction) error {
+	signedResponseMsg, err := tx.signedChaincodeResponseMessage()
+	if err != nil {
+		return err
+	}
+
+	responseMsg, err := utils.UnmarshalChaincodeResponseMessage(signedResponseMsg.GetChaincodeResponseMessage())
+	if err != nil {
+		return err
+	}
+
+	// get the chaincode parameters from the committed chaincode definition
+	chaincodeParams, err := queryChaincodeParams(state, tx.channelId, namespace)
+	if err != nil {
+		return err
+	}
+
+	// get corresponding enclave credentials from the committed ercc state
+	credentials, err := queryEnclaveCredentials(state, namespace, responseMsg.GetEnclaveId())
+	if err != nil {
+		return err
+	}
+	if credentials == nil {
+		return errors.Errorf("no credentials found for enclaveId = %s", responseMsg.GetEnclaveId())
+	}
+
+	attestedData, err := utils.UnmarshalAttestedData(credentials.GetSerializedAttestedData())
+	if err != nil {
+		return err
+	}
+
+	if !ccParamsMatch(attestedData.GetCcParams(), chaincodeParams) {
+		return errors.New("ccParams don't match")
+	}
+
+	// validate enclave endorsement signature and request hash
+	if err := v.Validator.Validate(signedResponseMsg, attestedData); err != nil {
+		return err
+	}
+
+	// the transaction must be bound to the original proposal
+	txId, proposalCreator, err := utils.GetTxIdAndCreatorFromSignedProposal(responseMsg.GetProposal())
+	if err != nil {
+		return errors.Wrap(err, "failed to extract original proposal")
+	}
+	if !bytes.Equal(tx.creator, proposalCreator) {
+		return errors.New("creator does not match creator of the original proposal")
+	}
+
+	txRWSet := tx.rwset
+	switch tx.function {
+	case endorseFunction:
+		// see endorsement.CheckAndRecordProposal
+		txRWSet, err = removeEndorsedProposalRecord(txRWSet, txId)
+		if err != nil {
+			return err
+		}
+	case invokeFunction:
+		// the response of a directly submitted __invoke is the response to the proposal of this very transaction
+		if tx.txId != txId {
+			return errors.Errorf("txId %s does not match txId %s of the original proposal", tx.txId, txId)
+		}
+	}
+
+	// check that the transaction rwset is what the enclave produced
+	return checkReadWriteSet(txRWSet, responseMsg.GetFpcRwSet())
+}
+
+func ccParamsMatch(expected, actual *protos.CCParameters) bool {
+	return expected.GetChaincodeId() == actual.GetChaincodeId() &&
+		expected.GetChannelId() == actual.GetChannelId() &&
+		expected.GetVersion() == actual.GetVersion() &&
+		expected.GetSequence() == actual.GetSequence()
+}
@@ -0,0 +1,548 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package plugin
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/ecc_go/chaincode/enclave_go"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-private-chaincode/vscc/plugin/fakes"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	lb "github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/bccsp/signer"
+	validation "github.com/hyperledger/fabric/core/handlers/validation/api"
+	vs "github.com/hyperledger/fabric/core/handlers/validation/api/state"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+
+//counterfeiter:generate -o fakes/plugin.go -fake-name ValidationPlugin . validationPlugin
+//lint:ignore U1000 This is just used to generate fake
+type validationPlugin interface {
+	validation.Plugin
+}
+
+//counterfeiter:generate -o fakes/statefetcher.go -fake-name StateFetcher . stateFetcher
+//lint:ignore U1000 This is just used to generate fake
+type stateFetcher interface {
+	vs.StateFetcher
+}
+
+//counterfeiter:generate -o fakes/state.go -fake-name State . state
+//lint:ignore U1000 This is just used to generate fake
+type state interface {
+	vs.State
+}
+
+//counterfeiter:generate -o fakes/validation.go -fake-name Validator . validator
+//lint:ignore U1000 This is just used to generate fake
+type validator interface {
+	endorsement.Validation
+}
+
+//counterfeiter:generate -o fakes/chaincodestub.go -fake-name ChaincodeStub . chaincodeStub
+//lint:ignore U1000 This is just used to generate fake
+type chaincodeStub interface {
+	shim.ChaincodeStubInterface
+}
+
+const (
+	channelId   = "mychannel"
+	chaincodeId = "mycc"
+	enclaveId   = "some-enclave-id"
+	txId        = "some-tx-id"
+)
+
+var creator = []byte("some-creator")
+
+var ccParams = &protos.CCParameters{
+	ChaincodeId: chaincodeId,
+	Version:     "some-mrenclave",
+	Sequence:    1,
+	ChannelId:   channelId,
+}
+
+type testState map[string][]byte
+
+func (s testState) put(namespace, key string, value []byte) {
+	s[namespace+"/"+key] = value
+}
+
+func (s testState) getStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
+	var values [][]byte
+	for _, k := range keys {
+		values = append(values, s[namespace+"/"+k])
+	}
+	return values, nil
+}
+
+func newTestState(params *protos.CCParameters) testState {
+	s := testState{}
+
+	s.put(lifecycleNamespace, fmt.Sprintf(lifecycleFieldKeyFormat, chaincodeId, "Sequence"),
+		protoutil.MarshalOrPanic(&lb.StateData{Type: &lb.StateData_Int64{Int64: params.Sequence}}))
+	endorsementInfo := protoutil.MarshalOrPanic(&lb.ChaincodeEndorsementInfo{Version: params.Version})
+	s.put(lifecycleNamespace, fmt.Sprintf(lifecycleFieldKeyFormat, chaincodeId, "EndorsementInfo"),
+		protoutil.MarshalOrPanic(&lb.StateData{Type: &lb.StateData_Bytes{Bytes: endorsementInfo}}))
+
+	attestedData, _ := anypb.New(&protos.AttestedData{CcParams: params})
+	credentials := utils.MarshallProtoBase64(&protos.Credentials{SerializedAttestedData: attestedData})
+	s.put(erccNamespace, createCompositeKey(credentialsObjectType, []string{chaincodeId, enclaveId}), []byte(credentials))
+
+	return s
+}
+
+func newFPCRWSet() *protos.FPCKVSet {
+	return &protos.FPCKVSet{
+		RwSet: &kvrwset.KVRWSet{
+			Reads: []*kvrwset.KVRead{
+				{Key: "key1"},
+				{Key: "\x00asset\x00key2\x00"},
+			},
+			Writes: []*kvrwset.KVWrite{
+				{Key: "key1", Value: []byte("value1")},
+				{Key: "\x00asset\x00key2\x00", IsDelete: true},
+			},
+		},
+	}
+}
+
+func newTxRWSet() *kvrwset.KVRWSet {
+	return &kvrwset.KVRWSet{
+		Reads: []*kvrwset.KVRead{
+			{Key: "key1"},
+			{Key: "\x00asset\x00key2\x00"},
+		},
+		Writes: []*kvrwset.KVWrite{
+			{Key: "key1", Value: []byte("value1")},
+			{Key: "\x00asset\x00key2\x00", IsDelete: true},
+		},
+	}
+}
+
+// newEndorseTxRWSet returns the tx rwset including the record of the original proposal
+func newEndorseTxRWSet() *kvrwset.KVRWSet {
+	key := createCompositeKey(endorsement.EndorsedProposalObjectType, []string{txId})
+	txRWSet := newTxRWSet()
+	txRWSet.Reads = append(txRWSet.Reads, &kvrwset.KVRead{Key: key})
+	txRWSet.Writes = append(txRWSet.Writes, &kvrwset.KVWrite{Key: key, Value: []byte("some-endorse-tx-id")})
+	return txRWSet
+}
+
+func newSignedProposal(txId string, creator []byte) *peer.SignedProposal {
+	hdr := &common.Header{
+		ChannelHeader:   protoutil.MarshalOrPanic(&common.ChannelHeader{Type: int32(common.HeaderType_ENDORSER_TRANSACTION), ChannelId: channelId, TxId: txId}),
+		SignatureHeader: protoutil.MarshalOrPanic(&common.SignatureHeader{Creator: creator}),
+	}
+	proposal := &peer.Proposal{Header: protoutil.MarshalOrPanic(hdr)}
+	return &peer.SignedProposal{ProposalBytes: protoutil.MarshalOrPanic(proposal)}
+}
+
+func newSignedResponseBase64(fpcRWSet *protos.FPCKVSet) []byte {
+	return newSignedResponseBase64ForProposal(fpcRWSet, newSignedProposal(txId, creator))
+}
+
+func newSignedResponseBase64ForProposal(fpcRWSet *protos.FPCKVSet, proposal *peer.SignedProposal) []byte {
+	responseMsg := &protos.ChaincodeResponseMessage{
+		EnclaveId: enclaveId,
+		FpcRwSet:  fpcRWSet,
+		Proposal:  proposal,
+	}
+	signedResponseMsg := &protos.SignedChaincodeResponseMessage{
+		ChaincodeResponseMessage: utils.MarshalOrPanic(responseMsg),
+	}
+	return []byte(utils.MarshallProtoBase64(signedResponseMsg))
+}
+
+func newBlock(function string, args [][]byte, responsePayload []byte, txRWSet *kvrwset.KVRWSet) *common.Block {
+	results := protoutil.MarshalOrPanic(&rwset.TxReadWriteSet{
+		DataModel: rwset.TxReadWriteSet_KV,
+		NsRwset: []*rwset.NsReadWriteSet{
+			{Namespace: erccNamespace, Rwset: protoutil.MarshalOrPanic(&kvrwset.KVRWSet{})},
+			{Namespace: chaincodeId, Rwset: protoutil.MarshalOrPanic(txRWSet)},
+		},
+	})
+
+	ca := &peer.ChaincodeAction{
+		Results:  results,
+		Response: &peer.Response{Status: 200, Payload: responsePayload},
+	}
+	prp := &peer.ProposalResponsePayload{Extension: protoutil.MarshalOrPanic(ca)}
+
+	cis := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			ChaincodeId: &peer.ChaincodeID{Name: chaincodeId},
+			Input:       &peer.ChaincodeInput{Args: append([][]byte{[]byte(function)}, args...)},
+		},
+	}
+	cpp := &peer.ChaincodeProposalPayload{Input: protoutil.MarshalOrPanic(cis)}
+
+	cap := &peer.ChaincodeActionPayload{
+		ChaincodeProposalPayload: protoutil.MarshalOrPanic(cpp),
+		Action:                   &peer.ChaincodeEndorsedAction{ProposalResponsePayload: protoutil.MarshalOrPanic(prp)},
+	}
+	tx := &peer.Transaction{Actions: []*peer.TransactionAction{{Payload: protoutil.MarshalOrPanic(cap)}}}
+
+	// a directly submitted __invoke is the transaction of the original proposal, __endorse is another transaction
+	blockTxId := txId
+	if function == endorseFunction {
+		blockTxId = "some-endorse-tx-id"
+	}
+	chdr := &common.ChannelHeader{Type: int32(common.HeaderType_ENDORSER_TRANSACTION), ChannelId: channelId, TxId: blockTxId}
+	payload := &common.Payload{
+		Header: &common.Header{
+			ChannelHeader:   protoutil.MarshalOrPanic(chdr),
+			SignatureHeader: protoutil.MarshalOrPanic(&common.SignatureHeader{Creator: creator}),
+		},
+		Data: protoutil.MarshalOrPanic(tx),
+	}
+	env := &common.Envelope{Payload: protoutil.MarshalOrPanic(payload)}
+
+	return &common.Block{
+		Header: &common.BlockHeader{Number: 1},
+		Data:   &common.BlockData{Data: [][]byte{protoutil.MarshalOrPanic(env)}},
+	}
+}
+
+func newEndorseBlock() *common.Block {
+	return newBlock(endorseFunction, [][]byte{newSignedResponseBase64(newFPCRWSet())}, nil, newEndorseTxRWSet())
+}
+
+func newValidation(s testState) (*Validation, *fakes.ValidationPlugin, *fakes.Validator, *fakes.StateFetcher) {
+	defaultValidation := &fakes.ValidationPlugin{}
+	validator := &fakes.Validator{}
+
+	st := &fakes.State{}
+	st.GetStateMultipleKeysStub = s.getStateMultipleKeys
+	stateFetcher := &fakes.StateFetcher{}
+	stateFetcher.FetchStateReturns(st, nil)
+
+	v := &Validation{DefaultValidation: defaultValidation, Validator: validator}
+	_ = v.Init(stateFetcher)
+
+	return v, defaultValidation, validator, stateFetcher
+}
+
+func TestInit(t *testing.T) {
+	defaultValidation := &fakes.ValidationPlugin{}
+	v := &Validation{DefaultValidation: defaultValidation}
+
+	err := v.Init()
+	assert.EqualError(t, err, "stateFetcher not passed in init")
+
+	stateFetcher := &fakes.StateFetcher{}
+	err = v.Init(stateFetcher)
+	assert.NoError(t, err)
+	assert.Equal(t, stateFetcher, v.StateFetcher)
+	assert.Equal(t, 1, defaultValidation.InitCallCount())
+}
+
+func TestValidate(t *testing.T) {
+	// endorse tx
+	v, _, validator, _ := newValidation(newTestState(ccParams))
+	err := v.Validate(newEndorseBlock(), chaincodeId, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, validator.ValidateCallCount())
+	_, attestedData := validator.ValidateArgsForCall(0)
+	assert.Equal(t, chaincodeId, attestedData.GetCcParams().GetChaincodeId())
+
+	// invoke tx
+	v, _, _, _ = newValidation(newTestState(ccParams))
+	block := newBlock(invokeFunction, nil, newSignedResponseBase64(newFPCRWSet()), newTxRWSet())
+	err = v.Validate(block, chaincodeId, 0, 0)
+	assert.NoError(t, err)
+}
+
+func TestValidateFailures(t *testing.T) {
+	// default validation fails
+	v, defaultValidation, validator, _ := newValidation(newTestState(ccParams))
+	defaultValidation.ValidateReturns(fmt.Errorf("endorsement policy failure"))
+	err := v.Validate(newEndorseBlock(), chaincodeId, 0, 0)
+	assert.EqualError(t, err, "endorsement policy failure")
+	assert.Equal(t, 0, validator.ValidateCallCount())
+
+	// invalid function
+	v, _, _, _ = newValidation(newTestState(ccParams))
+	err = v.Validate(newBlock("__initEnclave", nil, nil, newTxRWSet()), chaincodeId, 0, 0)
+	assert.EqualError(t, err, "invalid FPC transaction function '__initEnclave'")
+
+	// invalid action position
+	v, _, _, _ = newValidation(newTestState(ccParams))
+	err = v.Validate(newEndorseBlock(), chaincodeId, 0, 1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid FPC transaction")
+
+	// invalid response encoding
+	v, _, _, _ = newValidation(newTestState(ccParams))
+	err = v.Validate(newBlock(endorseFunction, [][]byte{[]byte("not base64!")}, nil, newTxRWSet()), chaincodeId, 0, 0)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot decode chaincode response message")
+
+	// state not available
+	v, _, _, stateFetcher := newValidation(newTestState(ccParams))
+	stateFetcher.FetchStateReturns(nil, fmt.Errorf("some error"))
+	err = v.Validate(newEndorseBlock(), chaincodeId, 0, 0)
+	assert.IsType(t, &validation.ExecutionFailureError{}, err)
+
+	// no chaincode definition
+	v, _, _, _ = newValidation(testState{})
+	err = v.Validate(newEndorseBlock(), chaincodeId, 0, 0)
+	assert.EqualError(t, err, "no chaincode definition found for chaincode='mycc'")
+
+	// no credentials
+	s := newTestState(ccParams)
+	delete(s, erccNamespace+"/"+createCompositeKey(credentialsObjectType, []string{chaincodeId, enclaveId}))
+	v, _, _, _ = newValidation(s)
+	err = v.Validate(newEndorseBlock(), chaincodeId, 0, 0)
+	assert.EqualError(t, err, "no credentials found for enclaveId = some-enclave-id")
+
+	// chaincode definition has changed since enclave registration
+	s = newTestState(&protos.CCParameters{ChaincodeId: chaincodeId, Version: "some-mrenclave", Sequence: 2, ChannelId: channelId})
+	s.put(lifecycleNamespace, fmt.Sprintf(lifecycleFieldKeyFormat, chaincodeId, "Sequence"),
+		protoutil.MarshalOrPanic(&lb.StateData{Type: &lb.StateData_Int64{Int64: 3}}))
+	v, _, _, _ = newValidation(s)
+	err = v.Validate(newEndorseBlock(), chaincodeId, 0, 0)
+	assert.EqualError(t, err, "ccParams don't match")
+
+	// invalid enclave signature
+	v, _, validator, _ = newValidation(newTestState(ccParams))
+	validator.ValidateReturns(fmt.Errorf("signature invalid"))
+	err = v.Validate(newEndorseBlock(), chaincodeId, 0, 0)
+	assert.EqualError(t, err, "signature invalid")
+
+	// endorse tx without record of the original proposal
+	v, _, _, _ = newValidation(newTestState(ccParams))
+	block := newBlock(endorseFunction, [][]byte{newSignedResponseBase64(newFPCRWSet())}, nil, newTxRWSet())
+	err = v.Validate(block, chaincodeId, 0, 0)
+	assert.EqualError(t, err, "no record of the original proposal with txId some-tx-id")
+
+	// endorse tx for a proposal that has already been endorsed
+	v, _, _, _ = newValidation(newTestState(ccParams))
+	txRWSet := newEndorseTxRWSet()
+	txRWSet.Reads[2].Version = &kvrwset.Version{BlockNum: 1}
+	block = newBlock(endorseFunction, [][]byte{newSignedResponseBase64(newFPCRWSet())}, nil, txRWSet)
+	err = v.Validate(block, chaincodeId, 0, 0)
+	assert.EqualError(t, err, "response to proposal with txId some-tx-id already endorsed")
+
+	// tx rwset does not match the enclave rwset
+	v, _, _, _ = newValidation(newTestState(ccParams))
+	txRWSet = newEndorseTxRWSet()
+	txRWSet.Writes[0].Value = []byte("some other value")
+	block = newBlock(endorseFunction, [][]byte{newSignedResponseBase64(newFPCRWSet())}, nil, txRWSet)
+	err = v.Validate(block, chaincodeId, 0, 0)
+	assert.EqualError(t, err, "write of key key1 does not match FPC rwset")
+
+	// invoke tx replaying the response to another proposal
+	v, _, _, _ = newValidation(newTestState(ccParams))
+	block = newBlock(invokeFunction, nil, newSignedResponseBase64ForProposal(newFPCRWSet(), newSignedProposal("other-tx-id", creator)), newTxRWSet())
+	err = v.Validate(block, chaincodeId, 0, 0)
+	assert.EqualError(t, err, "txId some-tx-id does not match txId other-tx-id of the original proposal")
+
+	// invoke tx submitted by another creator
+	v, _, _, _ = newValidation(newTestState(ccParams))
+	block = newBlock(invokeFunction, nil, newSignedResponseBase64ForProposal(newFPCRWSet(), newSignedProposal(txId, []byte("other-creator"))), newTxRWSet())
+	err = v.Validate(block, chaincodeId, 0, 0)
+	assert.EqualError(t, err, "creator does not match creator of the original proposal")
+}
+
+func TestCheckReadWriteSet(t *testing.T) {
+	err := checkReadWriteSet(newTxRWSet(), newFPCRWSet())
+	assert.NoError(t, err)
+
+	// empty rwsets
+	err = checkReadWriteSet(&kvrwset.KVRWSet{}, nil)
+	assert.NoError(t, err)
+
+	// missing read
+	txRWSet := newTxRWSet()
+	txRWSet.Reads = txRWSet.Reads[:1]
+	err = checkReadWriteSet(txRWSet, newFPCRWSet())
+	assert.EqualError(t, err, "read of key \x00asset\x00key2\x00 missing in transaction rwset")
+
+	// additional write
+	txRWSet = newTxRWSet()
+	txRWSet.Writes = append(txRWSet.Writes, &kvrwset.KVWrite{Key: "key3", Value: []byte("value3")})
+	err = checkReadWriteSet(txRWSet, newFPCRWSet())
+	assert.EqualError(t, err, "3 writes in transaction rwset but 2 writes in FPC rwset")
+
+	// different write key
+	txRWSet = newTxRWSet()
+	txRWSet.Writes[0].Key = "key3"
+	err = checkReadWriteSet(txRWSet, newFPCRWSet())
+	assert.EqualError(t, err, "write of key key1 missing in transaction rwset")
+
+	// delete mismatch
+	txRWSet = newTxRWSet()
+	txRWSet.Writes[1].IsDelete = false
+	err = checkReadWriteSet(txRWSet, newFPCRWSet())
+	assert.EqualError(t, err, "write of key \x00asset\x00key2\x00 does not match FPC rwset")
+
+	// range queries
+	fpcRWSet := newFPCRWSet()
+	fpcRWSet.RwSet.RangeQueriesInfo = []*kvrwset.RangeQueryInfo{{StartKey: "a", EndKey: "b"}}
+	err = checkReadWriteSet(newTxRWSet(), fpcRWSet)
+	assert.EqualError(t, err, "RangeQuery support not implemented")
+}
+
+// goChaincode reads key1, writes key1 and a composite key, and deletes key2
+type goChaincode struct{}
+
+func (goChaincode) Init(shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (goChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	if _, err := stub.GetState("key1"); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := stub.PutState("key1", []byte("value1")); err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := stub.DelState("key2"); err != nil {
+		return shim.Error(err.Error())
+	}
+	key, err := stub.CreateCompositeKey("asset", []string{"key3"})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := stub.PutState(key, []byte("value3")); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// newCreator returns a serialized identity with a self-signed certificate and a signer for it
+func newCreator(t *testing.T) ([]byte, func(msg []byte) []byte) {
+	require.NoError(t, factory.InitFactories(nil))
+	csp := factory.GetDefault()
+	key, err := csp.KeyGen(&bccsp.ECDSAP256KeyGenOpts{Temporary: true})
+	require.NoError(t, err)
+	s, err := signer.New(csp, key)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, s.Public(), s)
+	require.NoError(t, err)
+	creator := protoutil.MarshalOrPanic(&msp.SerializedIdentity{
+		Mspid:   "Org1MSP",
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+
+	sign := func(msg []byte) []byte {
+		digest := sha256.Sum256(msg)
+		sig, err := s.Sign(rand.Reader, digest[:], nil)
+		require.NoError(t, err)
+		return sig
+	}
+	return creator, sign
+}
+
+func TestCheckReadWriteSetOfGoEnclaveInvoke(t *testing.T) {
+	enclave := enclave_go.NewEnclaveStub(goChaincode{})
+	serializedCredentials, err := enclave.Init(utils.MarshalOrPanic(ccParams), utils.MarshalOrPanic(&protos.HostParameters{}), nil)
+	require.NoError(t, err)
+	credentials, err := utils.UnmarshalCredentials(base64.StdEncoding.EncodeToString(serializedCredentials))
+	require.NoError(t, err)
+	attestedData, err := utils.UnmarshalAttestedData(credentials.GetSerializedAttestedData())
+	require.NoError(t, err)
+
+	// the client encrypts the request for the enclave
+	provider := &crypto.EncryptionProviderImpl{
+		CSP: crypto.GetDefaultCSP(),
+		GetCcEncryptionKey: func(context.Context) ([]byte, error) {
+			return []byte(base64.StdEncoding.EncodeToString(attestedData.GetChaincodeEk())), nil
+		},
+	}
+	encCtx, err := provider.NewEncryptionContext(context.Background())
+	require.NoError(t, err)
+	request, err := encCtx.Conceal("put", nil)
+	require.NoError(t, err)
+	requestBytes, err := base64.StdEncoding.DecodeString(request)
+	require.NoError(t, err)
+
+	proposalCreator, sign := newCreator(t)
+	proposal := newSignedProposal(txId, proposalCreator)
+	proposal.Signature = sign(proposal.GetProposalBytes())
+
+	// the peer records the Fabric rwset of the __invoke proposal
+	txRWSet := &kvrwset.KVRWSet{}
+	stub := &fakes.ChaincodeStub{}
+	stub.GetSignedProposalReturns(proposal, nil)
+	stub.GetTxIDReturns(txId)
+	stub.GetChannelIDReturns(channelId)
+	stub.CreateCompositeKeyStub = shim.CreateCompositeKey
+	stub.GetStateCalls(func(key string) ([]byte, error) {
+		txRWSet.Reads = append(txRWSet.Reads, &kvrwset.KVRead{Key: key})
+		return nil, nil
+	})
+	stub.PutStateCalls(func(key string, value []byte) error {
+		txRWSet.Writes = append(txRWSet.Writes, &kvrwset.KVWrite{Key: key, Value: value})
+		return nil
+	})
+	stub.DelStateCalls(func(key string) error {
+		txRWSet.Writes = append(txRWSet.Writes, &kvrwset.KVWrite{Key: key, IsDelete: true})
+		return nil
+	})
+
+	signedResponseBytes, err := enclave.ChaincodeInvoke(stub, requestBytes)
+	require.NoError(t, err)
+	signedResponse, err := utils.UnmarshalSignedChaincodeResponseMessage(signedResponseBytes)
+	require.NoError(t, err)
+	response, err := utils.UnmarshalChaincodeResponseMessage(signedResponse.GetChaincodeResponseMessage())
+	require.NoError(t, err)
+
+	// the writes of the enclave are in the Fabric rwset, thus, a directly submitted __invoke passes the check
+	assert.Len(t, response.GetFpcRwSet().GetRwSet().GetWrites(), 3)
+	assert.NoError(t, checkReadWriteSet(txRWSet, response.GetFpcRwSet()))
+}
+
+func TestSignedChaincodeResponseMessage(t *testing.T) {
+	tx := &transaction{function: endorseFunction, args: [][]byte{newSignedResponseBase64(nil)}}
+	msg, err := tx.signedChaincodeResponseMessage()
+	assert.NoError(t, err)
+	responseMsg, err := utils.UnmarshalChaincodeResponseMessage(msg.GetChaincodeResponseMessage())
+	assert.NoError(t, err)
+	assert.Equal(t, enclaveId, responseMsg.GetEnclaveId())
+
+	tx = &transaction{function: endorseFunction}
+	_, err = tx.signedChaincodeResponseMessage()
+	assert.EqualError(t, err, "unexpected number of arguments for __endorse")
+
+	tx = &transaction{function: invokeFunction, response: []byte(base64.StdEncoding.EncodeToString([]byte("garbage")))}
+	_, err = tx.signedChaincodeResponseMessage()
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	lb "github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+	validation "github.com/hyperledger/fabric/core/handlers/validation/api"
+	vs "github.com/hyperledger/fabric/core/handlers/validation/api/state"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	//lint:ignore SA1019 old protos are needed for fabric
+	protoV1 "github.com/golang/protobuf/proto"
+)
+
+const (
+	erccNamespace      = "ercc"
+	lifecycleNamespace = "_lifecycle"
+
+	// see `core/chaincode/lifecycle` in Fabric for the layout of the chaincode definitions in the lifecycle namespace
+	lifecycleFieldKeyFormat = "namespaces/fields/%s/%s"
+
+	// see `ercc/registry` for the layout of the ercc state
+	credentialsObjectType = "namespaces/credentials"
+
+	compositeKeyNamespace = "\x00"
+	minUnicodeRuneValue   = "\x00"
+)
+
+// getState returns the committed value of a key in a namespace. If the state cannot be accessed, a
+// validation.ExecutionFailureError is returned, as the validity of the transaction cannot be decided.
+func getState(state vs.State, namespace, key string) ([]byte, error) {
+	values, err := state.GetStateMultipleKeys(namespace, []string{key})
+	if err != nil {
+		return nil, &validation.ExecutionFailureError{Reason: fmt.Sprintf("cannot get state for key %s in namespace %s: %s", key, namespace, err)}
+	}
+	if len(values) != 1 {
+		return nil, &validation.ExecutionFailureError{Reason: fmt.Sprintf("expected one value for key %s in namespace %s but got %d", key, namespace, len(values))}
+	}
+	return values[0], nil
+}
+
+// queryChaincodeParams reads the committed chaincode definition of the given chaincode from the lifecycle namespace
+func queryChaincodeParams(state vs.State, channelId, chaincodeId string) (*protos.CCParameters, error) {
+	sequenceBytes, err := getState(state, lifecycleNamespace, fmt.Sprintf(lifecycleFieldKeyFormat, chaincodeId, "Sequence"))
+	if err != nil {
+		return nil, err
+	}
+	if sequenceBytes == nil {
+		return nil, errors.Errorf("no chaincode definition found for chaincode='%s'", chaincodeId)
+	}
+
+	sequence := &lb.StateData{}
+	if err := proto.Unmarshal(sequenceBytes, protoV1.MessageV2(sequence)); err != nil {
+		return nil, errors.Wrap(err, "invalid chaincode sequence")
+	}
+
+	endorsementInfoBytes, err := getState(state, lifecycleNamespace, fmt.Sprintf(lifecycleFieldKeyFormat, chaincodeId, "EndorsementInfo"))
+	if err != nil {
+		return nil, err
+	}
+
+	endorsementInfoData := &lb.StateData{}
+	if err := proto.Unmarshal(endorsementInfoBytes, protoV1.MessageV2(endorsementInfoData)); err != nil {
+		return nil, errors.Wrap(err, "invalid chaincode endorsement info")
+	}
+
+	endorsementInfo := &lb.ChaincodeEndorsementInfo{}
+	if err := proto.Unmarshal(endorsementInfoData.GetBytes(), protoV1.MessageV2(endorsementInfo)); err != nil {
+		return nil, errors.Wrap(err, "invalid chaincode endorsement info")
+	}
+
+	return &protos.CCParameters{
+		ChaincodeId: chaincodeId,
+		Version:     endorsementInfo.GetVersion(),
+		Sequence:    sequence.GetInt64(),
+		ChannelId:   channelId,
+	}, nil
+}
+
+// queryEnclaveCredentials reads the credentials of an enclave registered at ercc; returns nil if no credentials are found
+func queryEnclaveCredentials(state vs.State, chaincodeId, enclaveId string) (*protos.Credentials, error) {
+	credentialsBase64, err := getState(state, erccNamespace, createCompositeKey(credentialsObjectType, []string{chaincodeId, enclaveId}))
+	if err != nil {
+		return nil, err
+	}
+	if len(credentialsBase64) == 0 {
+		return nil, nil
+	}
+
+	return utils.UnmarshalCredentials(string(credentialsBase64))
+}
+
+// createCompositeKey follows the composite key format of the Fabric chaincode shim
+func createCompositeKey(objectType string, attributes []string) string {
+	var sb strings.Builder
+	sb.WriteString(compositeKeyNamespace)
+	sb.WriteString(objectType)
+	sb.WriteString(minUnicodeRuneValue)
+	for _, att := range attributes {
+		sb.WriteString(att)
+		sb.WriteString(minUnicodeRuneValue)
+	}
+	return sb.String()
+}
@@ -0,0 +1,236 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/endorsement"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// transaction holds the parts of an FPC transaction action that are relevant for validation
+type transaction struct {
+	channelId string
+	txId      string
+	creator   []byte
+	function  string
+	args      [][]byte
+	response  []byte
+	rwset     *kvrwset.KVRWSet
+}
+
+// extractTransaction parses the action at actionPosition of the transaction at txPosition in the given block
+func extractTransaction(block *common.Block, namespace string, txPosition int, actionPosition int) (*transaction, error) {
+	env, err := protoutil.GetEnvelopeFromBlock(block.Data.Data[txPosition])
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := protoutil.UnmarshalPayload(env.GetPayload())
+	if err != nil {
+		return nil, err
+	}
+
+	chdr, err := protoutil.UnmarshalChannelHeader(payload.GetHeader().GetChannelHeader())
+	if err != nil {
+		return nil, err
+	}
+
+	shdr, err := protoutil.UnmarshalSignatureHeader(payload.GetHeader().GetSignatureHeader())
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := protoutil.UnmarshalTransaction(payload.GetData())
+	if err != nil {
+		return nil, err
+	}
+
+	if actionPosition >= len(tx.GetActions()) {
+		return nil, errors.Errorf("transaction has only %d actions, but requested action at position %d", len(tx.GetActions()), actionPosition)
+	}
+
+	cap, err := protoutil.UnmarshalChaincodeActionPayload(tx.GetActions()[actionPosition].GetPayload())
+	if err != nil {
+		return nil, err
+	}
+
+	cpp, err := protoutil.UnmarshalChaincodeProposalPayload(cap.GetChaincodeProposalPayload())
+	if err != nil {
+		return nil, err
+	}
+
+	cis, err := protoutil.UnmarshalChaincodeInvocationSpec(cpp.GetInput())
+	if err != nil {
+		return nil, err
+	}
+
+	args := cis.GetChaincodeSpec().GetInput().GetArgs()
+	if len(args) == 0 {
+		return nil, errors.New("no function name")
+	}
+
+	prp, err := protoutil.UnmarshalProposalResponsePayload(cap.GetAction().GetProposalResponsePayload())
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := protoutil.UnmarshalChaincodeAction(prp.GetExtension())
+	if err != nil {
+		return nil, err
+	}
+
+	txRWSet, err := protoutil.UnmarshalTxReadWriteSet(ca.GetResults())
+	if err != nil {
+		return nil, err
+	}
+
+	// we only consider the rwset of the FPC chaincode namespace; there may be other namespaces, for instance,
+	// when ECC queries ercc and _lifecycle during __endorse
+	rwset := &kvrwset.KVRWSet{}
+	for _, nsRWSet := range txRWSet.GetNsRwset() {
+		if nsRWSet.GetNamespace() != namespace {
+			continue
+		}
+		rwset, err = protoutil.UnmarshalKVRWSet(nsRWSet.GetRwset())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &transaction{
+		channelId: chdr.GetChannelId(),
+		txId:      chdr.GetTxId(),
+		creator:   shdr.GetCreator(),
+		function:  string(args[0]),
+		args:      args[1:],
+		response:  ca.GetResponse().GetPayload(),
+		rwset:     rwset,
+	}, nil
+}
+
+// signedChaincodeResponseMessage returns the enclave response of the transaction. For `__endorse` transactions,
+// the enclave response is the argument of the invocation, for `__invoke` transactions it is the response payload.
+func (tx *transaction) signedChaincodeResponseMessage() (*protos.SignedChaincodeResponseMessage, error) {
+	var responseBase64 []byte
+	switch tx.function {
+	case endorseFunction:
+		if len(tx.args) != 1 {
+			return nil, errors.Errorf("unexpected number of arguments for %s", endorseFunction)
+		}
+		responseBase64 = tx.args[0]
+	case invokeFunction:
+		responseBase64 = tx.response
+	default:
+		return nil, errors.Errorf("invalid FPC transaction function '%s'", tx.function)
+	}
+
+	serializedResponse, err := base64.StdEncoding.DecodeString(string(responseBase64))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode chaincode response message")
+	}
+
+	return utils.UnmarshalSignedChaincodeResponseMessage(serializedResponse)
+}
+
+// removeEndorsedProposalRecord checks that an `__endorse` transaction records the transaction id of the original
+// proposal (see endorsement.CheckAndRecordProposal) and returns the transaction rwset without this record
+func removeEndorsedProposalRecord(txRWSet *kvrwset.KVRWSet, txId string) (*kvrwset.KVRWSet, error) {
+	key := createCompositeKey(endorsement.EndorsedProposalObjectType, []string{txId})
+
+	result := &kvrwset.KVRWSet{}
+	recordRead := false
+	for _, r := range txRWSet.GetReads() {
+		if r.GetKey() == key {
+			// the record must not exist before this transaction
+			if r.GetVersion() != nil {
+				return nil, errors.Errorf("response to proposal with txId %s already endorsed", txId)
+			}
+			recordRead = true
+			continue
+		}
+		result.Reads = append(result.Reads, r)
+	}
+
+	recordWritten := false
+	for _, w := range txRWSet.GetWrites() {
+		if w.GetKey() == key && !w.GetIsDelete() {
+			recordWritten = true
+			continue
+		}
+		result.Writes = append(result.Writes, w)
+	}
+
+	if !recordRead || !recordWritten {
+		return nil, errors.Errorf("no record of the original proposal with txId %s", txId)
+	}
+	result.RangeQueriesInfo = txRWSet.GetRangeQueriesInfo()
+	result.MetadataWrites = txRWSet.GetMetadataWrites()
+
+	return result, nil
+}
+
+// checkReadWriteSet checks that the transaction writes are exactly the writes of the FPC rwset and that all reads of
+// the FPC rwset are covered by the transaction reads, so that Fabric's MVCC check applies to them
+func checkReadWriteSet(txRWSet *kvrwset.KVRWSet, fpcRWSet *protos.FPCKVSet) error {
+	expectedReads := fpcRWSet.GetRwSet().GetReads()
+	expectedWrites := fpcRWSet.GetRwSet().GetWrites()
+
+	if len(fpcRWSet.GetRwSet().GetRangeQueriesInfo()) > 0 {
+		return fmt.Errorf("RangeQuery support not implemented")
+	}
+
+	reads := make(map[string]bool)
+	for _, r := range txRWSet.GetReads() {
+		reads[r.GetKey()] = true
+	}
+	for _, r := range expectedReads {
+		k := toFabricKey(r.GetKey())
+		if !reads[k] {
+			return fmt.Errorf("read of key %s missing in transaction rwset", k)
+		}
+	}
+
+	if len(txRWSet.GetWrites()) != len(expectedWrites) {
+		return fmt.Errorf("%d writes in transaction rwset but %d writes in FPC rwset", len(txRWSet.GetWrites()), len(expectedWrites))
+	}
+
+	writes := make(map[string]*kvrwset.KVWrite)
+	for _, w := range txRWSet.GetWrites() {
+		writes[w.GetKey()] = w
+	}
+	for _, expected := range expectedWrites {
+		k := toFabricKey(expected.GetKey())
+		w, ok := writes[k]
+		if !ok {
+			return fmt.Errorf("write of key %s missing in transaction rwset", k)
+		}
+		if w.GetIsDelete() != expected.GetIsDelete() || !bytes.Equal(w.GetValue(), expected.GetValue()) {
+			return fmt.Errorf("write of key %s does not match FPC rwset", k)
+		}
+	}
+
+	return nil
+}
+
+// toFabricKey derives the Fabric key from a key in the FPC rwset (see also endorsement.ReplayReadWrites)
+func toFabricKey(key string) string {
+	k := utils.TransformToFPCKey(key)
+	if utils.IsFPCCompositeKey(k) {
+		comp := utils.SplitFPCCompositeKey(k)
+		return createCompositeKey(comp[0], comp[1:])
+	}
+	return k
+}